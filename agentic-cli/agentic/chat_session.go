@@ -17,16 +17,22 @@ const (
 	MaxHistorySize = 50
 	// MaxContextTokens approximates max context length
 	MaxContextTokens = 4000
+	// maxParallelTools bounds how many read-only tool calls run at once
+	maxParallelTools = 4
 )
 
 // ChatSession represents an agentic chat session with MCP tools
 type ChatSession struct {
-	ctx         context.Context
-	client      *openai.Client
-	model       string
-	temperature float64
-	maxTokens   int
-	history     []openai.Message
+	ctx              context.Context
+	client           *openai.Client
+	model            string
+	temperature      float64
+	maxTokens        int
+	history          []openai.Message
+	tools            []openai.Tool   // Every tool that can be enabled
+	enabled          map[string]bool // Enabled tools by name
+	toolPrompt       string          // System prompt generated for the enabled tools
+	promptToolModels []string        // Patterns of models without native tool calls
 
 	mu sync.Mutex
 }
@@ -38,6 +44,17 @@ type SessionConfig struct {
 	Model       string
 	Temperature float64
 	MaxTokens   int
+	// EnabledTools lists the tools enabled at the start, e.g. those of a
+	// profile; nil enables every tool
+	EnabledTools []string
+	// DisabledTools lists tool names that should not be registered
+	DisabledTools []string
+	// PromptToolModels lists name patterns of models without native tool
+	// calls, which get their tools through the system prompt
+	PromptToolModels []string
+	// ReasoningStyle is how the provider takes the thinking level, one of
+	// the openai.ReasoningStyle constants
+	ReasoningStyle string
 }
 
 // NewChatSession creates a new agentic chat session
@@ -47,16 +64,20 @@ func NewChatSession(ctx context.Context, config SessionConfig) (*ChatSession, er
 	}
 
 	client := openai.NewClient(config.BaseURL, config.APIKey)
+	client.ReasoningStyle = config.ReasoningStyle
 
-	// Add MCP tools
-	filesystemTools := mcp.CreateFilesystemTools()
-	for _, tool := range filesystemTools {
-		client.AddTool(tool)
+	disabled := make(map[string]bool, len(config.DisabledTools))
+	for _, name := range config.DisabledTools {
+		disabled[name] = true
 	}
 
-	websearchTools := mcp.CreateWebSearchTools()
-	for _, tool := range websearchTools {
-		client.AddTool(tool)
+	// Add MCP tools; every call runs through the shared middleware chain
+	client.Use(mcp.ToolMiddleware()...)
+	var tools []openai.Tool
+	for _, tool := range mcp.CreateTools() {
+		if !disabled[tool.Function.Name] {
+			tools = append(tools, tool)
+		}
 	}
 
 	// Set defaults if not provided
@@ -70,43 +91,29 @@ func NewChatSession(ctx context.Context, config SessionConfig) (*ChatSession, er
 	}
 
 	session := &ChatSession{
-		ctx:         ctx,
-		client:      client,
-		model:       config.Model,
-		temperature: temperature,
-		maxTokens:   maxTokens,
-		history:     make([]openai.Message, 0),
+		ctx:              ctx,
+		client:           client,
+		model:            config.Model,
+		temperature:      temperature,
+		maxTokens:        maxTokens,
+		history:          make([]openai.Message, 0),
+		tools:            tools,
+		enabled:          make(map[string]bool, len(tools)),
+		promptToolModels: config.PromptToolModels,
 	}
 
-	// Add default system message for better tool usage
-	session.SetSystemMessage(`You are an AI assistant with MANDATORY tool usage requirements.
-
-CRITICAL INSTRUCTIONS - YOU MUST FOLLOW THESE:
-
-1. TOOL USAGE IS REQUIRED for these queries:
-   - ANY question about current events, news, or recent information → USE web_search tool
-   - ANY weather-related query → USE web_search tool  
-   - ANY request to read, write, list, or search files → USE appropriate filesystem tool
-   - ANY request for current information that you don't have → USE web_search tool
-
-2. NEVER respond with "I couldn't find" or "I'm sorry" without first using tools.
-
-3. When you receive a query like:
-   - "search for X" → You MUST call web_search with query="X"
-   - "what's the weather in Y" → You MUST call web_search with query="weather in Y today"
-   - "list files" → You MUST call list_directory
-   - "read file X" → You MUST call read_file
-
-4. Available tools you MUST use:
-   - web_search: For ANY current information, news, weather, or real-time data
-   - read_file, write_file, list_directory, search_files, create_directory: For file operations
-
-5. INCORRECT BEHAVIOR (DO NOT DO THIS):
-   - Saying "I couldn't retrieve" without calling tools
-   - Apologizing for not finding information without searching
-   - Suggesting the user check elsewhere without trying tools first
-
-Remember: You have tools available. USE THEM. Do not respond without attempting to use relevant tools first.`)
+	// Enable the requested tools; this also adds the default system message,
+	// which describes them
+	for _, tool := range tools {
+		session.enabled[tool.Function.Name] = config.EnabledTools == nil
+	}
+	for _, name := range config.EnabledTools {
+		if !session.hasTool(name) && !disabled[name] {
+			return nil, fmt.Errorf("unknown tool %q", name)
+		}
+		session.enabled[name] = !disabled[name]
+	}
+	session.applyTools()
 
 	return session, nil
 }
@@ -175,7 +182,7 @@ func (s *ChatSession) SetSystemMessage(content string) {
 }
 
 // SendMessage sends a message and handles tool calls
-func (s *ChatSession) SendMessage(input string) (*AgenticResponse, error) {
+func (s *ChatSession) SendMessage(input string, thinkingLevel string) (*AgenticResponse, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -195,15 +202,10 @@ func (s *ChatSession) SendMessage(input string) (*AgenticResponse, error) {
 	for iteration < maxIterations {
 		iteration++
 		// Create chat completion request
-		req := openai.ChatCompletionRequest{
-			Model:       s.model,
-			Messages:    s.history,
-			Temperature: s.temperature,
-			MaxTokens:   s.maxTokens,
-		}
+		req := s.request()
 
 		// Send request to LM Studio
-		resp, err := s.client.CreateChatCompletion(s.ctx, req)
+		resp, err := s.client.CreateChatCompletion(s.ctx, req, thinkingLevel)
 		if err != nil {
 			return nil, fmt.Errorf("chat completion failed: %w", err)
 		}
@@ -214,6 +216,9 @@ func (s *ChatSession) SendMessage(input string) (*AgenticResponse, error) {
 
 		choice := resp.Choices[0]
 		assistantMsg := choice.Message
+		if req.PromptTools {
+			s.parseToolCalls(&assistantMsg)
+		}
 
 		// Add assistant message to history
 		s.history = append(s.history, assistantMsg)
@@ -259,18 +264,28 @@ func (s *ChatSession) SendMessage(input string) (*AgenticResponse, error) {
 	}, nil
 }
 
-// executeToolCalls executes tool calls and adds results to history
+// executeToolCalls executes tool calls and adds results to history in the
+// order the model issued them. Consecutive read-only calls run concurrently;
+// a mutating call waits for the calls before it and runs on its own, so it
+// never races a read of the same file.
 func (s *ChatSession) executeToolCalls(toolCalls []openai.ToolCall) error {
-	for _, toolCall := range toolCalls {
-		result, err := s.client.ExecuteTool(s.ctx, toolCall)
-		if err != nil {
-			result = fmt.Sprintf("Error executing tool %s: %v", toolCall.Function.Name, err)
+	results := make([]string, len(toolCalls))
+	for start := 0; start < len(toolCalls); {
+		end := start + 1
+		if s.client.IsReadOnly(toolCalls[start].Function.Name) {
+			for end < len(toolCalls) && s.client.IsReadOnly(toolCalls[end].Function.Name) {
+				end++
+			}
 		}
+		s.runToolBatch(toolCalls[start:end], results[start:end])
+		start = end
+	}
 
+	for i, toolCall := range toolCalls {
 		// Add tool result to history
 		toolResultMsg := openai.Message{
 			Role:       "tool",
-			Content:    result,
+			Content:    results[i],
 			ToolCallID: toolCall.ID,
 		}
 		s.history = append(s.history, toolResultMsg)
@@ -278,6 +293,38 @@ func (s *ChatSession) executeToolCalls(toolCalls []openai.ToolCall) error {
 	return nil
 }
 
+// runToolBatch executes calls with at most maxParallelTools at a time,
+// storing each result at the index of its call
+func (s *ChatSession) runToolBatch(calls []openai.ToolCall, results []string) {
+	if len(calls) == 1 {
+		results[0] = s.executeTool(calls[0])
+		return
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxParallelTools)
+	for i := range calls {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = s.executeTool(calls[i])
+		}(i)
+	}
+	wg.Wait()
+}
+
+// executeTool runs one tool call, turning a failure into a result the model
+// can read
+func (s *ChatSession) executeTool(toolCall openai.ToolCall) string {
+	result, err := s.client.ExecuteTool(s.ctx, toolCall)
+	if err != nil {
+		return fmt.Sprintf("Error executing tool %s: %v", toolCall.Function.Name, err)
+	}
+	return result
+}
+
 // GetHistory returns the current conversation history
 func (s *ChatSession) GetHistory() []openai.Message {
 	s.mu.Lock()
@@ -361,6 +408,7 @@ func (s *ChatSession) ModelInfo() (string, error) {
 		"type":        "OpenAI Compatible",
 		"base_url":    s.client.BaseURL,
 		"tools_count": len(s.client.Tools),
+		"tool_calls":  s.toolCallMode(),
 		"tools":       s.getToolNames(),
 	}
 
@@ -417,16 +465,13 @@ func (s *ChatSession) SendMessageStream(input string, thinkingLevel string, show
 		iteration++
 		
 		// Create chat completion request
-		req := openai.ChatCompletionRequest{
-			Model:       s.model,
-			Messages:    s.history,
-			Temperature: s.temperature,
-			MaxTokens:   s.maxTokens,
-		}
+		req := s.request()
 
 		// Stream the response
 		var currentMessage openai.Message
 		var toolCalls []openai.ToolCall
+		// Tool calls written as text are held back instead of shown
+		var filter openai.ToolCallFilter
 		
 		streamCallback := func(chunk openai.ChatCompletionStreamResponse) error {
 			if len(chunk.Choices) == 0 {
@@ -436,23 +481,32 @@ func (s *ChatSession) SendMessageStream(input string, thinkingLevel string, show
 			choice := chunk.Choices[0]
 			
 			// Handle reasoning (thinking) delta
-			if choice.Delta.Reasoning != "" && showThinking {
-				// This is thinking content from LM Studio
-				if err := callback(choice.Delta.Reasoning, true); err != nil {
-					return err
+			if choice.Delta.Reasoning != "" {
+				// Kept with the reply for later inspection, shown if requested
+				currentMessage.Reasoning += choice.Delta.Reasoning
+				if showThinking {
+					if err := callback(choice.Delta.Reasoning, true); err != nil {
+						return err
+					}
 				}
 			}
 			
 			// Handle content delta
 			if choice.Delta.Content != "" {
+				content := choice.Delta.Content
+				if req.PromptTools {
+					content = filter.Write(content)
+				}
 				// This is regular response content
-				if err := callback(choice.Delta.Content, false); err != nil {
-					return err
+				if content != "" {
+					if err := callback(content, false); err != nil {
+						return err
+					}
+					completeContent.WriteString(content)
 				}
 				
 				// Accumulate content
 				currentMessage.Content += choice.Delta.Content
-				completeContent.WriteString(choice.Delta.Content)
 			}
 			
 			// Handle role
@@ -484,10 +538,23 @@ func (s *ChatSession) SendMessageStream(input string, thinkingLevel string, show
 		if currentMessage.Role == "" {
 			currentMessage.Role = "assistant"
 		}
+		currentMessage.Reasoning = strings.TrimSpace(currentMessage.Reasoning)
 
 		// Add tool calls if any
 		if len(toolCalls) > 0 {
 			currentMessage.ToolCalls = toolCalls
+		} else if req.PromptTools {
+			s.parseToolCalls(&currentMessage)
+			toolCalls = currentMessage.ToolCalls
+			if len(toolCalls) > 0 {
+				toolCallsUsed = true
+			} else if held := filter.Flush(); held != "" {
+				// The held back text was not a tool call after all
+				if err := callback(held, false); err != nil {
+					return nil, fmt.Errorf("streaming chat completion failed: %w", err)
+				}
+				completeContent.WriteString(held)
+			}
 		}
 
 		// Add assistant message to history
@@ -2,7 +2,14 @@ package agentic
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/vivesm/GOSS-CLI/agentic-cli/openai"
 )
@@ -162,3 +169,268 @@ func TestAgenticResponseFormatResponse(t *testing.T) {
 		t.Errorf("Expected %q, got %q", expected, formatted)
 	}
 }
+
+func TestExecuteToolCallsParallel(t *testing.T) {
+	var mu sync.Mutex
+	active, peak := 0, 0
+	var order []string
+	track := func(name string, delta int) {
+		mu.Lock()
+		defer mu.Unlock()
+		active += delta
+		if active > peak {
+			peak = active
+		}
+		if delta > 0 {
+			order = append(order, name)
+		}
+	}
+
+	client := openai.NewClient("http://localhost:1234/v1", "")
+	client.AddTool(openai.Tool{Type: "function", Function: openai.ToolFunction{
+		Name:     "slow_read",
+		ReadOnly: true,
+		Handler: func(_ context.Context, args map[string]interface{}) (string, error) {
+			track("read", 1)
+			defer track("read", -1)
+			time.Sleep(30 * time.Millisecond)
+			return "read " + args["id"].(string), nil
+		},
+	}})
+	client.AddTool(openai.Tool{Type: "function", Function: openai.ToolFunction{
+		Name: "write",
+		Handler: func(context.Context, map[string]interface{}) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if active != 0 {
+				t.Errorf("Mutating call ran alongside %d read-only calls", active)
+			}
+			order = append(order, "write")
+			return "written", nil
+		},
+	}})
+	session := &ChatSession{ctx: context.Background(), client: client}
+
+	var calls []openai.ToolCall
+	for i, name := range []string{"slow_read", "slow_read", "slow_read", "slow_read", "slow_read", "write", "slow_read", "slow_read"} {
+		calls = append(calls, openai.ToolCall{
+			ID:       fmt.Sprintf("call_%d", i),
+			Type:     "function",
+			Function: openai.Function{Name: name, Arguments: fmt.Sprintf(`{"id": "%d"}`, i)},
+		})
+	}
+
+	if err := session.executeToolCalls(calls); err != nil {
+		t.Fatal(err)
+	}
+
+	if peak < 2 || peak > maxParallelTools {
+		t.Errorf("Expected between 2 and %d concurrent reads, got %d", maxParallelTools, peak)
+	}
+	if strings.Join(order[5:], ",") != "write,read,read" {
+		t.Errorf("Expected the write to run after the first reads and before the last, got %v", order)
+	}
+	if len(session.history) != len(calls) {
+		t.Fatalf("Expected %d tool results, got %d", len(calls), len(session.history))
+	}
+	for i, msg := range session.history {
+		want := fmt.Sprintf("read %d", i)
+		if calls[i].Function.Name == "write" {
+			want = "written"
+		}
+		if msg.ToolCallID != calls[i].ID || msg.Content != want {
+			t.Errorf("Result %d: expected %s %q, got %s %q", i, calls[i].ID, want, msg.ToolCallID, msg.Content)
+		}
+	}
+}
+
+func TestSessionTools(t *testing.T) {
+	session, err := NewChatSession(context.Background(), SessionConfig{
+		BaseURL:       "http://localhost:1234/v1",
+		EnabledTools:  []string{"read_file", "web_search", "run_command"},
+		DisabledTools: []string{"run_command"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if names := session.getToolNames(); strings.Join(names, ",") != "read_file,web_search" {
+		t.Errorf("Expected only the enabled tools to be registered, got %v", names)
+	}
+	prompt := session.history[0].Content
+	if !strings.Contains(prompt, "- read_file: For file operations") || strings.Contains(prompt, "write_file") ||
+		!strings.Contains(prompt, "call web_search with") {
+		t.Errorf("Expected the prompt to describe the enabled tools:\n%s", prompt)
+	}
+
+	if err := session.EnableTools(false, "web_search"); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.EnableTools(true, "write_file"); err != nil {
+		t.Fatal(err)
+	}
+	prompt = session.history[0].Content
+	if strings.Contains(prompt, "web_search") || !strings.Contains(prompt, "- read_file, write_file: For file operations") {
+		t.Errorf("Expected the prompt to follow the enabled tools:\n%s", prompt)
+	}
+	if err := session.EnableTools(true, "run_command"); err == nil {
+		t.Error("Expected a tool disabled in the configuration to stay unavailable")
+	}
+
+	// A replaced system prompt is kept
+	session.SetSystemMessage("Be brief.")
+	if err := session.UseTools(nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(session.client.Tools) != 0 || session.history[0].Content != "Be brief." {
+		t.Errorf("Expected no tools and the custom prompt, got %d tools and %q", len(session.client.Tools), session.history[0].Content)
+	}
+}
+
+// streamServer serves each reply as a stream of deltas, recording the
+// request bodies
+func streamServer(t *testing.T, replies ...[]map[string]string) (*httptest.Server, *[]map[string]interface{}) {
+	t.Helper()
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		requests = append(requests, body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range replies[len(requests)-1] {
+			data, _ := json.Marshal(map[string]interface{}{
+				"choices": []interface{}{map[string]interface{}{"delta": delta}},
+			})
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func contentDeltas(chunks ...string) []map[string]string {
+	deltas := make([]map[string]string, len(chunks))
+	for i, chunk := range chunks {
+		deltas[i] = map[string]string{"content": chunk}
+	}
+	return deltas
+}
+
+func TestPromptTools(t *testing.T) {
+	server, recorded := streamServer(t,
+		contentDeltas("I'll check. <tool", "_call>\n{\"name\": \"read_file\", ", "\"arguments\": {\"path\": \"missing.txt\"}}\n</tool_call>"),
+		contentDeltas("It does ", "not exist."),
+	)
+
+	session, err := NewChatSession(context.Background(), SessionConfig{
+		BaseURL:          server.URL,
+		Model:            "google/gemma-3-12b",
+		EnabledTools:     []string{"read_file"},
+		PromptToolModels: []string{"Gemma-*"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var shown strings.Builder
+	resp, err := session.SendMessageStream("Does missing.txt exist?", "", false, func(content string, isThinking bool) error {
+		shown.WriteString(content)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := *recorded
+	if shown.String() != "I'll check. It does not exist." || resp.Content != shown.String() || !resp.ToolCalls {
+		t.Errorf("Expected the call to be hidden, got %q (response %+v)", shown.String(), resp)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+	if _, ok := requests[0]["tools"]; ok {
+		t.Error("Expected no native tools in the request")
+	}
+	messages, _ := requests[1]["messages"].([]interface{})
+	last, _ := messages[len(messages)-1].(map[string]interface{})
+	if system, _ := messages[0].(map[string]interface{}); !strings.Contains(fmt.Sprint(system["content"]), "<tools>") ||
+		last["role"] != "user" || !strings.HasPrefix(fmt.Sprint(last["content"]), "<tool_response>") {
+		t.Errorf("Expected the tools and the result as text, got %v", messages)
+	}
+
+	call := session.history[len(session.history)-3]
+	if call.Content != "I'll check." || len(call.ToolCalls) != 1 || call.ToolCalls[0].Function.Name != "read_file" ||
+		session.history[len(session.history)-2].Role != "tool" {
+		t.Errorf("Expected the call stored as a native tool call, got %+v", call)
+	}
+	if !strings.Contains(mustModelInfo(t, session), `"tool_calls": "prompt"`) {
+		t.Error("Expected the model info to report prompt tool calls")
+	}
+	session.SetModel("qwen3-8b")
+	if !strings.Contains(mustModelInfo(t, session), `"tool_calls": "native"`) {
+		t.Error("Expected other models to use native tool calls")
+	}
+}
+
+func TestStreamReasoning(t *testing.T) {
+	server, recorded := streamServer(t,
+		[]map[string]string{{"reasoning_content": "The user "}, {"reasoning_content": "greets me."}, {"content": "Hello!"}},
+		contentDeltas("<think>Another", " greeting.</think>\n\n", "Hi again."),
+	)
+	session, err := NewChatSession(context.Background(), SessionConfig{
+		BaseURL:        server.URL,
+		EnabledTools:   []string{},
+		ReasoningStyle: openai.ReasoningStyleEffort,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var thinking, shown strings.Builder
+	callback := func(content string, isThinking bool) error {
+		if isThinking {
+			thinking.WriteString(content)
+		} else {
+			shown.WriteString(content)
+		}
+		return nil
+	}
+	if _, err := session.SendMessageStream("Hello", "high", true, callback); err != nil {
+		t.Fatal(err)
+	}
+	if thinking.String() != "The user greets me." || shown.String() != "Hello!" {
+		t.Errorf("Expected reasoning_content as thinking, got %q and %q", thinking.String(), shown.String())
+	}
+
+	resp, err := session.SendMessageStream("Hello", "low", false, callback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "Hi again." || strings.Contains(thinking.String(), "Another") {
+		t.Errorf("Expected hidden <think> reasoning, got %q", resp.Content)
+	}
+	last := session.history[len(session.history)-1]
+	if last.Content != "Hi again." || session.LastReasoning() != "Another greeting." {
+		t.Errorf("Expected the reasoning stored apart from the content, got %+v", last)
+	}
+
+	requests := *recorded
+	if requests[0]["reasoning_effort"] != "high" || requests[1]["reasoning_effort"] != "low" {
+		t.Errorf("Expected the thinking level as reasoning_effort, got %v and %v", requests[0]["reasoning_effort"], requests[1]["reasoning_effort"])
+	}
+	if data, _ := json.Marshal(requests[1]["messages"]); strings.Contains(string(data), "greets me") {
+		t.Errorf("Expected earlier reasoning to stay out of requests, got %s", data)
+	}
+}
+
+func mustModelInfo(t *testing.T, session *ChatSession) string {
+	t.Helper()
+	info, err := session.ModelInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
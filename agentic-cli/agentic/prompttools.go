@@ -0,0 +1,68 @@
+package agentic
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/vivesm/GOSS-CLI/agentic-cli/openai"
+)
+
+// usesPromptTools reports whether the current model is one without native
+// tool calls, whose tools go through the system prompt. The caller must hold
+// s.mu.
+func (s *ChatSession) usesPromptTools() bool {
+	if len(s.client.Tools) == 0 {
+		return false
+	}
+	for _, pattern := range s.promptToolModels {
+		if modelMatches(s.model, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// modelMatches reports whether a model name, or its last path element,
+// matches a pattern in which * matches anything. Case is ignored.
+func modelMatches(model, pattern string) bool {
+	expr := "(?i)^" + strings.ReplaceAll(regexp.QuoteMeta(strings.TrimSpace(pattern)), `\*`, ".*") + "$"
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(model) || re.MatchString(model[strings.LastIndex(model, "/")+1:])
+}
+
+// request returns the next completion request for the history. The caller
+// must hold s.mu.
+func (s *ChatSession) request() openai.ChatCompletionRequest {
+	req := openai.ChatCompletionRequest{
+		Model:       s.model,
+		Messages:    s.history,
+		Temperature: s.temperature,
+		MaxTokens:   s.maxTokens,
+	}
+	if s.usesPromptTools() {
+		req.Messages = openai.PromptToolMessages(s.history, s.client.Tools)
+		req.PromptTools = true
+	}
+	return req
+}
+
+// parseToolCalls moves tool calls written as text in a reply to its
+// ToolCalls, so the history holds them the same way as native ones
+func (s *ChatSession) parseToolCalls(msg *openai.Message) {
+	if len(msg.ToolCalls) > 0 {
+		return
+	}
+	msg.Content, msg.ToolCalls = openai.ParseToolCalls(msg.Content, s.client.Tools)
+}
+
+// toolCallMode describes how the current model calls tools: "native" or
+// "prompt"
+func (s *ChatSession) toolCallMode() string {
+	if s.usesPromptTools() {
+		return "prompt"
+	}
+	return "native"
+}
@@ -0,0 +1,22 @@
+package agentic
+
+// LastReasoning returns the reasoning behind the latest reply, gathered from
+// every model turn since the last user message, or "" when the model did not
+// report any
+func (s *ChatSession) LastReasoning() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var reasoning string
+	for i := len(s.history) - 1; i >= 0 && s.history[i].Role != "user"; i-- {
+		msg := s.history[i]
+		if msg.Role != "assistant" || msg.Reasoning == "" {
+			continue
+		}
+		if reasoning != "" {
+			reasoning = "\n\n" + reasoning
+		}
+		reasoning = msg.Reasoning + reasoning
+	}
+	return reasoning
+}
@@ -0,0 +1,226 @@
+package agentic
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vivesm/GOSS-CLI/agentic-cli/openai"
+)
+
+// ToolInfo describes a tool the session can use and whether it is enabled
+type ToolInfo struct {
+	Name        string
+	Description string
+	ReadOnly    bool
+	Enabled     bool
+}
+
+// Tools lists every tool the session can use, enabled or not
+func (s *ChatSession) Tools() []ToolInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := make([]ToolInfo, len(s.tools))
+	for i, tool := range s.tools {
+		infos[i] = ToolInfo{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			ReadOnly:    tool.Function.ReadOnly,
+			Enabled:     s.enabled[tool.Function.Name],
+		}
+	}
+	return infos
+}
+
+// EnableTools turns the named tools on or off. An unknown name is an error
+// and leaves the session unchanged.
+func (s *ChatSession) EnableTools(enable bool, names ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkToolNames(names); err != nil {
+		return err
+	}
+	for _, name := range names {
+		s.enabled[name] = enable
+	}
+	s.applyTools()
+	return nil
+}
+
+// UseTools enables exactly the named tools, such as those of a profile
+func (s *ChatSession) UseTools(names []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkToolNames(names); err != nil {
+		return err
+	}
+	s.enabled = make(map[string]bool, len(names))
+	for _, name := range names {
+		s.enabled[name] = true
+	}
+	s.applyTools()
+	return nil
+}
+
+func (s *ChatSession) checkToolNames(names []string) error {
+	for _, name := range names {
+		if !s.hasTool(name) {
+			return fmt.Errorf("tool %q is not available in this session", name)
+		}
+	}
+	return nil
+}
+
+func (s *ChatSession) hasTool(name string) bool {
+	for _, tool := range s.tools {
+		if tool.Function.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTools registers the enabled tools with the client and rewrites the
+// system prompt's list of them. A system prompt the user replaced is left
+// alone. The caller must hold s.mu.
+func (s *ChatSession) applyTools() {
+	var enabled []openai.Tool
+	for _, tool := range s.tools {
+		if s.enabled[tool.Function.Name] {
+			enabled = append(enabled, tool)
+		}
+	}
+	s.client.SetTools(enabled)
+
+	prompt := systemPrompt(enabled)
+	switch {
+	case len(s.history) == 0:
+		s.history = append(s.history, openai.Message{Role: "system", Content: prompt})
+	case s.history[0].Role == "system" && s.history[0].Content == s.toolPrompt:
+		s.history[0].Content = prompt
+	}
+	s.toolPrompt = prompt
+}
+
+// toolGuides tell the model when to use each group of built-in tools
+var toolGuides = []struct {
+	tools []string
+	use   string
+}{
+	{[]string{"web_search"}, "For ANY current information, news, weather, or real-time data"},
+	{[]string{"fetch_url"}, "To read a web page (e.g. a search result) as Markdown"},
+	{[]string{"read_file", "write_file", "list_directory", "search_files", "create_directory"}, "For file operations"},
+	{[]string{"grep_files"}, "To find where text or code appears inside files"},
+	{[]string{"move_path", "copy_path", "delete_path", "stat_path", "directory_tree"}, "To reorganize and inspect files"},
+	{[]string{"run_command"}, `To build, test or search the project (e.g. "go test ./...", "grep -rn name .")`},
+}
+
+// promptRule is a line of the system prompt that only applies when one of
+// its tools is enabled
+type promptRule struct {
+	tools []string
+	text  string
+}
+
+var (
+	requiredUseRules = []promptRule{
+		{[]string{"web_search"}, "ANY question about current events, news, or recent information → USE web_search tool"},
+		{[]string{"web_search"}, "ANY weather-related query → USE web_search tool"},
+		{[]string{"read_file", "write_file", "list_directory", "search_files"}, "ANY request to read, write, list, or search files → USE appropriate filesystem tool"},
+		{[]string{"web_search"}, "ANY request for current information that you don't have → USE web_search tool"},
+	}
+	exampleRules = []promptRule{
+		{[]string{"web_search"}, `"search for X" → You MUST call web_search with query="X"`},
+		{[]string{"web_search"}, `"what's the weather in Y" → You MUST call web_search with query="weather in Y today"`},
+		{[]string{"list_directory"}, `"list files" → You MUST call list_directory`},
+		{[]string{"read_file"}, `"read file X" → You MUST call read_file`},
+	}
+)
+
+// systemPrompt returns the default system prompt, describing only the
+// enabled tools
+func systemPrompt(tools []openai.Tool) string {
+	if len(tools) == 0 {
+		return "You are a helpful AI assistant. No tools are available in this session: answer from your own " +
+			"knowledge, and say so plainly when a request needs current information, files or commands."
+	}
+
+	enabled := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		enabled[tool.Function.Name] = true
+	}
+	anyEnabled := func(names []string) bool {
+		for _, name := range names {
+			if enabled[name] {
+				return true
+			}
+		}
+		return false
+	}
+	rules := func(rules []promptRule) string {
+		var b strings.Builder
+		for _, rule := range rules {
+			if anyEnabled(rule.tools) {
+				b.WriteString("\n   - " + rule.text)
+			}
+		}
+		return b.String()
+	}
+
+	var b strings.Builder
+	section := 0
+	heading := func(text string) {
+		section++
+		fmt.Fprintf(&b, "%d. %s", section, text)
+	}
+	b.WriteString("You are an AI assistant with MANDATORY tool usage requirements.\n\n")
+	b.WriteString("CRITICAL INSTRUCTIONS - YOU MUST FOLLOW THESE:\n\n")
+	if used := rules(requiredUseRules); used != "" {
+		heading("TOOL USAGE IS REQUIRED for these queries:" + used + "\n\n")
+	}
+	heading("NEVER respond with \"I couldn't find\" or \"I'm sorry\" without first using tools.\n\n")
+	if enabled["web_search"] {
+		b.WriteString("   If web_search reports \"Status: no_results\", call it again with a rephrased query (at most twice).\n")
+		b.WriteString("   If it still finds nothing, or reports \"Status: error\", say so plainly. Only cite URLs returned by a tool.\n\n")
+	}
+	if examples := rules(exampleRules); examples != "" {
+		heading("When you receive a query like:" + examples + "\n\n")
+	}
+
+	heading("Available tools you MUST use:")
+	described := make(map[string]bool)
+	for _, guide := range toolGuides {
+		var names []string
+		for _, name := range guide.tools {
+			if enabled[name] {
+				names = append(names, name)
+				described[name] = true
+			}
+		}
+		if len(names) > 0 {
+			fmt.Fprintf(&b, "\n   - %s: %s", strings.Join(names, ", "), guide.use)
+		}
+	}
+	var others []openai.Tool
+	for _, tool := range tools {
+		if !described[tool.Function.Name] {
+			others = append(others, tool)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool { return others[i].Function.Name < others[j].Function.Name })
+	for _, tool := range others {
+		fmt.Fprintf(&b, "\n   - %s: %s", tool.Function.Name, tool.Function.Description)
+	}
+	b.WriteString("\n   No other tools are available in this session.\n\n")
+
+	heading(`INCORRECT BEHAVIOR (DO NOT DO THIS):
+   - Saying "I couldn't retrieve" without calling tools
+   - Apologizing for not finding information without searching
+   - Suggesting the user check elsewhere without trying tools first
+
+Remember: You have tools available. USE THEM. Do not respond without attempting to use relevant tools first.`)
+	return b.String()
+}
@@ -2,13 +2,18 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"os"
 	"os/user"
+	"path/filepath"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/vivesm/GOSS-CLI/agentic-cli/agentic"
 	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/chat"
 	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/config"
+	"github.com/vivesm/GOSS-CLI/agentic-cli/mcp"
 )
 
 const (
@@ -29,6 +34,10 @@ func run() int {
 	var opts chat.Opts
 	var configPath string
 	var baseURL string
+	var provider string
+	var printSchema bool
+	var offline bool
+	var toolSpec string
 	rootCmd.Flags().StringVarP(&opts.GenerativeModel, "model", "m", agentic.DefaultModel,
 		"generative model name")
 	rootCmd.Flags().BoolVar(&opts.Multiline, "multiline", false,
@@ -40,23 +49,104 @@ func run() int {
 	rootCmd.Flags().IntVarP(&opts.WordWrap, "wrap", "w", 80,
 		"line length for response word wrapping")
 	rootCmd.Flags().StringVarP(&configPath, "config", "c", defaultConfigPath,
-		"path to configuration file in JSON format")
+		"path to configuration file (JSON, YAML or TOML, chosen by extension)")
 	rootCmd.Flags().StringVarP(&baseURL, "base-url", "b", defaultBaseURL,
 		"LM Studio API base URL")
+	rootCmd.Flags().StringVarP(&provider, "provider", "P", "",
+		"named provider from the Providers section of the configuration")
+	rootCmd.Flags().BoolVar(&printSchema, "config-schema", false,
+		"print the configuration JSON Schema and exit")
+	rootCmd.Flags().BoolVar(&opts.WatchConfig, "watch-config", true,
+		"reload the configuration file when it changes")
+	rootCmd.Flags().BoolVar(&offline, "offline", false,
+		"answer web searches only from the search cache and never contact search providers")
+	rootCmd.Flags().StringVar(&toolSpec, "tools", "",
+		"tools to enable: comma-separated profiles (readonly, research, dev) and tool names")
+
+	rootCmd.RunE = func(cmd *cobra.Command, _ []string) error {
+		if printSchema {
+			_, err := os.Stdout.Write(config.Schema())
+			return err
+		}
 
-	rootCmd.RunE = func(_ *cobra.Command, _ []string) error {
 		configuration, err := config.NewConfig(configPath)
 		if err != nil {
 			return err
 		}
 
+		apiKey := os.Getenv(apiKeyEnv) // Optional for LM Studio
+		var reasoningStyle string
+		if provider != "" {
+			p, ok := configuration.Providers[provider]
+			if !ok {
+				return fmt.Errorf("unknown provider %q", provider)
+			}
+			// Explicit flags take precedence over the provider settings
+			if !cmd.Flags().Changed("base-url") {
+				baseURL = p.BaseURL
+			}
+			if !cmd.Flags().Changed("model") && p.Model != "" {
+				opts.GenerativeModel = p.Model
+			}
+			if p.APIKeyEnv != "" {
+				apiKey = os.Getenv(p.APIKeyEnv)
+			}
+			reasoningStyle = p.Reasoning
+		}
+
+		mcp.SetOffline(offline)
+
+		// Plugins must be registered before tool names are resolved
+		if pluginDir, err := mcp.DefaultPluginDir(); err == nil {
+			plugins, errs := mcp.LoadPlugins(pluginDir)
+			for _, err := range errs {
+				fmt.Fprintf(os.Stderr, "Warning: %v; skipping it\n", err)
+			}
+			mcp.SetPlugins(plugins)
+		}
+
+		// The flag replaces the configured profile; neither enables every tool
+		if !cmd.Flags().Changed("tools") {
+			toolSpec = configuration.Tools.Profile
+		}
+		var enabledTools []string
+		if toolSpec != "" {
+			if enabledTools, err = mcp.ResolveTools(toolSpec, configuration.Tools.Profiles); err != nil {
+				return err
+			}
+		}
+
+		// Daily tool quotas are counted across sessions in the data directory,
+		// where tool calls are also logged
+		var usagePath string
+		if dataDir, err := config.DataDir(); err == nil {
+			usagePath = filepath.Join(dataDir, "tool-usage.json")
+			if logFile, err := os.OpenFile(filepath.Join(dataDir, "tool-calls.log"),
+				os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600); err == nil {
+				defer logFile.Close()
+				mcp.SetToolLogger(slog.New(slog.NewJSONHandler(logFile, nil)))
+			}
+		}
+		if os.Getenv("GOSS_DEBUG") != "" {
+			mcp.SetToolLogger(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+		}
+		mcp.SetToolLimiter(mcp.NewToolLimiter(nil, usagePath))
+
+		store := config.NewStore(configuration)
+		applyPolicies(configuration)
+		store.OnSwap(applyPolicies)
+
 		// Create agentic chat session
 		sessionConfig := agentic.SessionConfig{
-			BaseURL:     baseURL,
-			APIKey:      os.Getenv(apiKeyEnv), // Optional for LM Studio
-			Model:       opts.GenerativeModel,
-			Temperature: 0.3, // Default focused temperature, changeable with !t
-			MaxTokens:   2048,
+			BaseURL:          baseURL,
+			APIKey:           apiKey,
+			Model:            opts.GenerativeModel,
+			Temperature:      0.3, // Default focused temperature, changeable with !t
+			MaxTokens:        2048,
+			EnabledTools:     enabledTools,
+			DisabledTools:    configuration.Tools.Disabled,
+			PromptToolModels: configuration.Tools.PromptModels,
+			ReasoningStyle:   reasoningStyle,
 		}
 
 		chatSession, err := agentic.NewChatSession(context.Background(), sessionConfig)
@@ -64,7 +154,7 @@ func run() int {
 			return err
 		}
 
-		chatHandler, err := chat.NewAgentic(getCurrentUser(), chatSession, configuration, &opts)
+		chatHandler, err := chat.NewAgentic(getCurrentUser(), chatSession, store, &opts)
 		if err != nil {
 			return err
 		}
@@ -80,6 +170,91 @@ func run() int {
 	return 0
 }
 
+// applyPolicies pushes the configured filesystem and command limits, the
+// tool rate limits, the search providers and the search cache to the MCP
+// tools.
+func applyPolicies(configuration *config.Config) {
+	// Deleted files go to a per-project trash in the data directory
+	var trashDir string
+	dataDir, dataErr := config.DataDir()
+	if dataErr == nil {
+		if wd, err := os.Getwd(); err == nil {
+			trashDir = filepath.Join(dataDir, "trash", config.ProjectKey(wd))
+		}
+	}
+
+	mcp.SetPolicy(mcp.Policy{
+		ConfirmMutations:     configuration.Policies.ConfirmMutations,
+		FetchPrivateNetworks: configuration.Policies.FetchPrivateNetworks,
+		TrashDir:             trashDir,
+		MaxFileSize:          configuration.Policies.MaxFileSize,
+		AllowedRoots:         configuration.Policies.AllowedRoots,
+		RestrictedPaths:      configuration.Policies.RestrictedPaths,
+		Commands: mcp.CommandPolicy{
+			Allow:     configuration.Policies.Commands.Allow,
+			Deny:      configuration.Policies.Commands.Deny,
+			Timeout:   time.Duration(configuration.Policies.Commands.TimeoutSeconds) * time.Second,
+			MaxOutput: configuration.Policies.Commands.MaxOutputBytes,
+		},
+	})
+
+	// Updating keeps the session and daily counts across reloads
+	mcp.CurrentToolLimiter().Update(toolLimits(configuration.Tools.Limits))
+
+	mcp.SetSearchProviders(searchProviders(configuration.Search))
+
+	// Keep the current cache, and its statistics, unless the TTL changed
+	if dataErr == nil {
+		ttl := time.Duration(configuration.Search.CacheTTLMinutes) * time.Minute
+		if ttl <= 0 {
+			ttl = mcp.DefaultSearchCacheTTL
+		}
+		if cache := mcp.CurrentSearchCache(); cache == nil || cache.TTL() != ttl {
+			mcp.SetSearchCache(mcp.NewSearchCache(filepath.Join(dataDir, "cache", "search"), ttl))
+		}
+	}
+}
+
+// toolLimits overlays the configured tool limits on the built-in ones.
+func toolLimits(configured map[string]config.ToolLimitConfig) map[string]mcp.ToolLimit {
+	limits := mcp.DefaultToolLimits()
+	for tool, l := range configured {
+		limits[tool] = mcp.ToolLimit{
+			RatePerMinute: l.RatePerMinute,
+			Burst:         l.Burst,
+			SessionQuota:  l.SessionQuota,
+			DailyQuota:    l.DailyQuota,
+		}
+	}
+	return limits
+}
+
+// searchProviders builds the configured search providers, skipping any that
+// cannot be used (for example because their API key is missing).
+func searchProviders(search config.SearchConfig) []mcp.SearchProvider {
+	var providers []mcp.SearchProvider
+	for _, p := range search.Providers {
+		var apiKey string
+		if p.APIKeyEnv != "" {
+			apiKey = os.Getenv(p.APIKeyEnv)
+		}
+		provider, err := mcp.NewSearchProvider(mcp.SearchProviderConfig{
+			Type:    p.Type,
+			Name:    p.Name,
+			BaseURL: p.BaseURL,
+			APIKey:  apiKey,
+			Paths:   p.Paths,
+			Timeout: time.Duration(p.TimeoutSeconds) * time.Second,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v; skipping it\n", err)
+			continue
+		}
+		providers = append(providers, provider)
+	}
+	return providers
+}
+
 func getCurrentUser() string {
 	currentUser, err := user.Current()
 	if err != nil {
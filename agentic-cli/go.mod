@@ -3,11 +3,15 @@ module github.com/vivesm/GOSS-CLI/agentic-cli
 go 1.21
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/charmbracelet/glamour v0.7.0
 	github.com/chzyer/readline v1.5.1
+	github.com/fsnotify/fsnotify v1.7.0
 	github.com/manifoldco/promptui v0.9.0
 	github.com/muesli/termenv v0.15.2
 	github.com/spf13/cobra v1.8.0
+	golang.org/x/net v0.17.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -27,6 +31,5 @@ require (
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/yuin/goldmark v1.5.4 // indirect
 	github.com/yuin/goldmark-emoji v1.0.2 // indirect
-	golang.org/x/net v0.17.0 // indirect
 	golang.org/x/sys v0.13.0 // indirect
 )
@@ -1,27 +1,36 @@
 package chat
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
 	"github.com/vivesm/GOSS-CLI/agentic-cli/agentic"
 	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/config"
 	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/handler"
 	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/terminal"
+	"github.com/vivesm/GOSS-CLI/agentic-cli/mcp"
 )
 
 // NewAgentic returns a new Chat with agentic capabilities
 func NewAgentic(
 	user string, session *agentic.ChatSession,
-	configuration *config.Config, opts *Opts,
+	configuration *config.Store, opts *Opts,
 ) (*Chat, error) {
 	terminalIOConfig := &terminal.IOConfig{
 		User:           user,
 		Multiline:      opts.Multiline,
 		LineTerminator: opts.LineTerminator,
+		AutoComplete:   terminal.NewCompleter(completionSources(session, configuration)),
+		History:        inputHistory(configuration.Get()),
 	}
 
 	terminalIO, err := terminal.NewIO(terminalIOConfig)
 	if err != nil {
 		return nil, err
 	}
+	mcp.SetApprover(newApprover(terminalIO))
 
 	// Create agentic query handler
 	agenticIO := handler.NewIO(terminalIO, terminalIO.Prompt.Goss)
@@ -40,7 +49,68 @@ func NewAgentic(
 
 	return &Chat{
 		io:            terminalIO,
+		config:        configuration,
+		opts:          opts,
 		gossHandler:   agenticHandler, // Updated field name for GOSS
 		systemHandler: systemHandler,
 	}, nil
 }
+
+// inputHistory opens the persistent input history of the current project.
+// Failures are reported and fall back to an in-memory history.
+func inputHistory(cfg *config.Config) *terminal.History {
+	pattern := cfg.Input.HistoryIgnore
+	if pattern == "" {
+		pattern = terminal.DefaultHistoryIgnore
+	}
+	ignore, err := regexp.Compile(pattern)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: invalid input history filter: %v\n", err)
+		return nil
+	}
+
+	dataDir, err := config.DataDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: input history disabled: %v\n", err)
+		return nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		wd = "."
+	}
+
+	path := filepath.Join(dataDir, "history", config.ProjectKey(wd)+".jsonl")
+	history, err := terminal.NewHistory(path, cfg.Input.HistorySize, ignore)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: input history disabled: %v\n", err)
+		return nil
+	}
+	return history
+}
+
+// completionSources exposes session and configuration data to tab completion.
+func completionSources(session *agentic.ChatSession, configuration *config.Store) terminal.CompletionSources {
+	return terminal.CompletionSources{
+		Models: func() []string {
+			models, err := session.ListModels()
+			if err != nil {
+				return nil
+			}
+			return models
+		},
+		Sessions: func() []string {
+			var ids []string
+			for id := range configuration.Get().History {
+				ids = append(ids, id)
+			}
+			return ids
+		},
+		Prompts: func() []string {
+			var names []string
+			for name := range configuration.Get().SystemPrompts {
+				names = append(names, name)
+			}
+			return names
+		},
+	}
+}
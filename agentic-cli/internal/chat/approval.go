@@ -0,0 +1,40 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chzyer/readline"
+	"github.com/manifoldco/promptui"
+	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/terminal"
+	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/terminal/color"
+	"github.com/vivesm/GOSS-CLI/agentic-cli/mcp"
+)
+
+// newApprover returns an mcp.Approver that asks the user on the terminal.
+// Without a terminal every request is declined.
+func newApprover(io *terminal.IO) mcp.Approver {
+	return func(ctx context.Context, req mcp.ApprovalRequest) (bool, error) {
+		if !readline.DefaultIsTerminal() {
+			return false, nil
+		}
+
+		// Keep the spinner from drawing over the prompt
+		if io.Spinner.Running() {
+			io.Spinner.Stop()
+			defer io.Spinner.Start()
+		}
+
+		io.Write(fmt.Sprintf("\n%s%s wants to %s %s\n", io.Prompt.System,
+			req.Tool, req.Action, color.Yellow("("+req.Reason+")")))
+		prompt := promptui.Prompt{
+			Label:     "Allow? (y/N)",
+			IsConfirm: true,
+		}
+		result, err := prompt.Run()
+		if err != nil || result != "y" {
+			return false, nil
+		}
+		return true, nil
+	}
+}
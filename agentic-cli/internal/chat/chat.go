@@ -3,7 +3,9 @@ package chat
 import (
 	"fmt"
 	"os"
+	"strings"
 	
+	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/config"
 	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/handler"
 	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/terminal"
 	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/terminal/color"
@@ -11,7 +13,9 @@ import (
 
 // Chat handles the interactive exchange of messages between user and model.
 type Chat struct {
-	io *terminal.IO
+	io     *terminal.IO
+	config *config.Store
+	opts   *Opts
 
 	gossHandler   handler.MessageHandler
 	systemHandler handler.MessageHandler
@@ -37,6 +41,12 @@ func (c *Chat) Start() {
 		}
 	}()
 
+	if c.opts.WatchConfig {
+		if watcher := c.watchConfig(); watcher != nil {
+			defer watcher.Close()
+		}
+	}
+
 	c.showWelcomeMessage()
 
 	for {
@@ -62,6 +72,46 @@ func (c *Chat) Start() {
 	}
 }
 
+// watchConfig starts reloading the configuration whenever its file changes.
+// It returns nil if the file cannot be watched.
+func (c *Chat) watchConfig() *config.Watcher {
+	watcher, err := config.NewWatcher(c.config.Get().FilePath(), config.DefaultPollInterval, c.reloadConfig)
+	if err != nil {
+		if os.Getenv("GOSS_DEBUG") != "" {
+			fmt.Printf("[DEBUG] Config watcher disabled: %v\n", err)
+		}
+		return nil
+	}
+	watcher.Start()
+	return watcher
+}
+
+// reloadConfig swaps in a configuration changed on disk and reports what changed.
+func (c *Chat) reloadConfig(cfg *config.Config, changes []string, err error) {
+	if err != nil {
+		c.io.Write(fmt.Sprintf("\n%s%s\n", c.io.Prompt.System,
+			terminal.Error(fmt.Sprintf("Configuration reload failed, keeping current settings:\n%v", err))))
+		return
+	}
+
+	c.config.Swap(cfg)
+	if len(changes) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%sConfiguration reloaded:\n", c.io.Prompt.System)
+	for _, change := range changes {
+		fmt.Fprintf(&b, "  • %s", change)
+		if strings.HasPrefix(change, "Tools") || strings.HasPrefix(change, "Providers") ||
+			strings.HasPrefix(change, "Input") {
+			b.WriteString(color.Gray(" (takes effect after restart)"))
+		}
+		b.WriteString("\n")
+	}
+	c.io.Write(b.String())
+}
+
 // printResponse prints a response to the terminal
 func (c *Chat) printResponse(response handler.Response) {
 	c.io.Write(response.String())
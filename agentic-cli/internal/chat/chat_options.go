@@ -9,6 +9,7 @@ type Opts struct {
 	LineTerminator  string
 	StylePath       string
 	WordWrap        int
+	WatchConfig     bool
 }
 
 func (o *Opts) rendererOptions() handler.RendererOptions {
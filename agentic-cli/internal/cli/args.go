@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Command is a parsed system command line such as `!h load "my session"`.
+type Command struct {
+	Name  string            // Command name without the prefix, e.g. "h"
+	Args  []string          // Positional arguments in order
+	Flags map[string]string // Flags given as --name, --name=value or -n
+}
+
+// Subcommands lists the subcommands each system command accepts.
+// A command that is missing from the map takes no subcommands.
+var Subcommands = map[string][]string{
+	SystemCmdHelp:            {},
+	SystemCmdSelectPrompt:    {"list", "use", "show"},
+	SystemCmdSelectInputMode: {"single", "multi"},
+	SystemCmdModel:           {"list", "set", "info", "tools"},
+	SystemCmdHistory:         {"list", "save", "load", "delete", "clear"},
+	SystemCmdTemperature:     {"show", "set", "reset"},
+	SystemCmdStream:          {"on", "off", "toggle"},
+	SystemCmdThinking:        {"off", "low", "med", "high", "last"},
+	SystemCmdShowThinking:    {"on", "off", "toggle"},
+	SystemCmdTools:           {"list", "enable", "disable", "profile"},
+	SystemCmdCache:           {"stats", "clear"},
+}
+
+// Usage holds the argument grammar of each system command.
+var Usage = map[string]string{
+	SystemCmdHelp:            "!help",
+	SystemCmdQuit:            "!q",
+	SystemCmdSelectPrompt:    "!p [list | use <name> | show <name>]",
+	SystemCmdSelectInputMode: "!i [single | multi]",
+	SystemCmdModel:           "!m [list | set <model> | info | tools]",
+	SystemCmdHistory:         "!h [list | save [name] [--force] | load <id> | delete <id> | delete --all [--yes] | clear]",
+	SystemCmdTemperature:     "!t [show | set <value> | reset]",
+	SystemCmdStream:          "!stream [on | off | toggle]",
+	SystemCmdThinking:        "!thinking [off | low | med | high | last]",
+	SystemCmdShowThinking:    "!show-thinking [on | off | toggle]",
+	SystemCmdTools:           "!tools [list | enable <tool|profile>... | disable <tool|profile>... | profile [name]]",
+	SystemCmdCache:           "!cache [stats | clear]",
+	SystemCmdLimits:          "!limits",
+}
+
+// ParseSystemCommand splits a system command line into its name, positional
+// arguments and flags. Arguments may be quoted with single or double quotes,
+// and a backslash escapes the next character outside single quotes.
+// A bare "--" ends flag parsing.
+func ParseSystemCommand(input string) (*Command, error) {
+	input = strings.TrimSpace(input)
+	if !strings.HasPrefix(input, SystemCmdPrefix) {
+		return nil, ErrInvalidSystemCommand
+	}
+
+	words, err := SplitArgs(strings.TrimPrefix(input, SystemCmdPrefix))
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, ErrInvalidSystemCommand
+	}
+
+	cmd := &Command{Name: words[0], Flags: make(map[string]string)}
+	flagsDone := false
+	for _, word := range words[1:] {
+		switch {
+		case flagsDone:
+			cmd.Args = append(cmd.Args, word)
+		case word == "--":
+			flagsDone = true
+		case strings.HasPrefix(word, "--") && len(word) > 2:
+			name, value, _ := strings.Cut(word[2:], "=")
+			cmd.Flags[name] = value
+		case len(word) == 2 && word[0] == '-' && isLetter(word[1]):
+			cmd.Flags[word[1:]] = ""
+		default:
+			cmd.Args = append(cmd.Args, word)
+		}
+	}
+	return cmd, nil
+}
+
+// Subcommand returns the first positional argument in lower case, or an
+// empty string if there is none.
+func (c *Command) Subcommand() string {
+	return strings.ToLower(c.Arg(0))
+}
+
+// Arg returns the positional argument at index i, or an empty string.
+func (c *Command) Arg(i int) string {
+	if i < 0 || i >= len(c.Args) {
+		return ""
+	}
+	return c.Args[i]
+}
+
+// Tail joins the positional arguments starting at index i with spaces,
+// so unquoted multi-word names still work.
+func (c *Command) Tail(i int) string {
+	if i >= len(c.Args) {
+		return ""
+	}
+	return strings.Join(c.Args[i:], " ")
+}
+
+// HasFlag reports whether any of the given flag names was set.
+func (c *Command) HasFlag(names ...string) bool {
+	for _, name := range names {
+		if _, ok := c.Flags[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// UsageError returns an error describing the expected grammar of the command.
+func (c *Command) UsageError() error {
+	if usage, ok := Usage[c.Name]; ok {
+		return fmt.Errorf("usage: %s", usage)
+	}
+	return ErrInvalidSystemCommand
+}
+
+// ErrUnterminatedQuote is returned when a quoted argument is not closed.
+var ErrUnterminatedQuote = errors.New("unterminated quote in command")
+
+// SplitArgs splits s into words the way a POSIX shell would for simple
+// quoting: whitespace separates words, quotes group them, and a backslash
+// escapes the following character except inside single quotes.
+func SplitArgs(s string) ([]string, error) {
+	var (
+		words   []string
+		current strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, ErrUnterminatedQuote
+	}
+	if inWord {
+		words = append(words, current.String())
+	}
+	return words, nil
+}
+
+func isLetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseSystemCommand(t *testing.T) {
+	tests := []struct {
+		input string
+		name  string
+		args  []string
+		flags map[string]string
+	}{
+		{input: "!m", name: "m"},
+		{input: "!m set qwen2.5", name: "m", args: []string{"set", "qwen2.5"}},
+		{input: "  !h   load   session_1 ", name: "h", args: []string{"load", "session_1"}},
+		{input: `!h load "my session"`, name: "h", args: []string{"load", "my session"}},
+		{input: `!p use 'Senior Dev'`, name: "p", args: []string{"use", "Senior Dev"}},
+		{input: `!h save it\'s`, name: "h", args: []string{"save", "it's"}},
+		{
+			input: "!h delete --all -y",
+			name:  "h",
+			args:  []string{"delete"},
+			flags: map[string]string{"all": "", "y": ""},
+		},
+		{
+			input: "!h save --name=foo",
+			name:  "h",
+			args:  []string{"save"},
+			flags: map[string]string{"name": "foo"},
+		},
+		{input: "!t set -0.5", name: "t", args: []string{"set", "-0.5"}},
+		{input: "!h save -- --force", name: "h", args: []string{"save", "--force"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			cmd, err := ParseSystemCommand(tt.input)
+			if err != nil {
+				t.Fatalf("ParseSystemCommand failed: %v", err)
+			}
+			if cmd.Name != tt.name {
+				t.Errorf("Expected name %q, got %q", tt.name, cmd.Name)
+			}
+			if !reflect.DeepEqual(cmd.Args, tt.args) {
+				t.Errorf("Expected args %q, got %q", tt.args, cmd.Args)
+			}
+			if tt.flags == nil {
+				tt.flags = map[string]string{}
+			}
+			if !reflect.DeepEqual(cmd.Flags, tt.flags) {
+				t.Errorf("Expected flags %v, got %v", tt.flags, cmd.Flags)
+			}
+		})
+	}
+}
+
+func TestParseSystemCommandErrors(t *testing.T) {
+	if _, err := ParseSystemCommand("m set"); !errors.Is(err, ErrInvalidSystemCommand) {
+		t.Errorf("Expected ErrInvalidSystemCommand without prefix, got %v", err)
+	}
+	if _, err := ParseSystemCommand("!   "); !errors.Is(err, ErrInvalidSystemCommand) {
+		t.Errorf("Expected ErrInvalidSystemCommand for empty command, got %v", err)
+	}
+	if _, err := ParseSystemCommand(`!h load "unterminated`); !errors.Is(err, ErrUnterminatedQuote) {
+		t.Errorf("Expected ErrUnterminatedQuote, got %v", err)
+	}
+}
+
+func TestCommandAccessors(t *testing.T) {
+	cmd, err := ParseSystemCommand("!p USE Senior Developer")
+	if err != nil {
+		t.Fatalf("ParseSystemCommand failed: %v", err)
+	}
+
+	if cmd.Subcommand() != "use" {
+		t.Errorf("Expected lower-cased subcommand 'use', got %q", cmd.Subcommand())
+	}
+	if cmd.Tail(1) != "Senior Developer" {
+		t.Errorf("Expected tail 'Senior Developer', got %q", cmd.Tail(1))
+	}
+	if cmd.Arg(5) != "" || cmd.Tail(5) != "" {
+		t.Error("Out-of-range accessors should return empty strings")
+	}
+	if cmd.UsageError() == nil {
+		t.Error("Expected usage error for known command")
+	}
+}
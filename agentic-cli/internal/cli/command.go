@@ -2,7 +2,6 @@ package cli
 
 import (
 	"errors"
-	"strings"
 )
 
 const (
@@ -17,24 +16,35 @@ const (
 	SystemCmdStream          = "stream"
 	SystemCmdThinking        = "thinking"
 	SystemCmdShowThinking    = "show-thinking"
+	SystemCmdTools           = "tools"
+	SystemCmdCache           = "cache"
+	SystemCmdLimits          = "limits"
 )
 
+// SystemCommands lists every system command name in help order.
+var SystemCommands = []string{
+	SystemCmdHelp,
+	SystemCmdSelectPrompt,
+	SystemCmdModel,
+	SystemCmdHistory,
+	SystemCmdTemperature,
+	SystemCmdStream,
+	SystemCmdThinking,
+	SystemCmdShowThinking,
+	SystemCmdTools,
+	SystemCmdCache,
+	SystemCmdLimits,
+	SystemCmdSelectInputMode,
+	SystemCmdQuit,
+}
+
 var ErrInvalidSystemCommand = errors.New("invalid system command")
 
 // ExtractSystemCommandName extracts the command name from a system command input
 func ExtractSystemCommandName(input string) (string, error) {
-	if !strings.HasPrefix(input, SystemCmdPrefix) {
-		return "", ErrInvalidSystemCommand
-	}
-
-	command := strings.TrimPrefix(input, SystemCmdPrefix)
-	command = strings.TrimSpace(command)
-
-	// Extract just the command name (first word)
-	parts := strings.Fields(command)
-	if len(parts) == 0 {
-		return "", ErrInvalidSystemCommand
+	cmd, err := ParseSystemCommand(input)
+	if err != nil {
+		return "", err
 	}
-
-	return parts[0], nil
+	return cmd.Name, nil
 }
@@ -1,38 +1,122 @@
 package config
 
 import (
+	"bytes"
+	_ "embed"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
+	"regexp"
+
+	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/schema"
 )
 
+//go:embed schema.json
+var schemaJSON []byte
+
+// configSchema is the parsed form of schemaJSON used for validation.
+var configSchema = mustParseSchema(schemaJSON)
+
 // Config contains the application configuration data and methods.
 // This consolidates the previous Configuration and ApplicationData structs.
 type Config struct {
-	filePath      string                 // Path to the configuration file
-	SystemPrompts map[string]string      `json:"SystemPrompts"`
-	History       map[string]interface{} `json:"History"`
-	Streaming     StreamingConfig        `json:"Streaming"`
+	filePath      string                    // Path to the configuration file
+	Schema        string                    `json:"$schema,omitempty"`
+	SystemPrompts map[string]string         `json:"SystemPrompts"`
+	History       map[string]interface{}    `json:"History"`
+	Streaming     StreamingConfig           `json:"Streaming"`
+	Providers     map[string]ProviderConfig `json:"Providers,omitempty"`
+	Tools         ToolsConfig               `json:"Tools"`
+	Policies      PoliciesConfig            `json:"Policies"`
+	Input         InputConfig               `json:"Input"`
+	Search        SearchConfig              `json:"Search"`
 }
 
 // StreamingConfig holds streaming and thinking-related settings
 type StreamingConfig struct {
-	Enabled      bool   `json:"enabled"`      // Whether streaming responses are enabled
-	ShowThinking bool   `json:"showThinking"` // Whether to display thinking tokens
+	Enabled       bool   `json:"enabled"`       // Whether streaming responses are enabled
+	ShowThinking  bool   `json:"showThinking"`  // Whether to display thinking tokens
 	ThinkingLevel string `json:"thinkingLevel"` // Thinking level: "off", "low", "med", "high"
 }
 
-// NewConfig returns a new Config from a JSON file.
-// If the file doesn't exist, it creates a default configuration.
+// ProviderConfig describes a named OpenAI-compatible endpoint.
+type ProviderConfig struct {
+	BaseURL   string `json:"baseURL"`             // API base URL, e.g. http://localhost:1234/v1
+	APIKeyEnv string `json:"apiKeyEnv,omitempty"` // Environment variable holding the API key
+	Model     string `json:"model,omitempty"`     // Default model for this provider
+	Reasoning string `json:"reasoning,omitempty"` // How the thinking level is sent: effort, budget, openrouter or none (default)
+}
+
+// ToolsConfig holds tool availability settings.
+type ToolsConfig struct {
+	Profile  string                     `json:"profile,omitempty"`  // Tools enabled at start: profile and tool names, comma-separated
+	Profiles map[string][]string        `json:"profiles,omitempty"` // Custom profiles: tool names by profile name
+	Disabled []string                   `json:"disabled,omitempty"` // Tools that are never registered
+	Limits   map[string]ToolLimitConfig `json:"limits,omitempty"`   // Rate limits and quotas by tool name
+	// Model name patterns, such as "gemma-*", of models without native tool
+	// calls; their tools are described in the system prompt instead
+	PromptModels []string `json:"promptModels,omitempty"`
+}
+
+// ToolLimitConfig limits how often a tool may be called. Zero fields are
+// unlimited.
+type ToolLimitConfig struct {
+	RatePerMinute float64 `json:"ratePerMinute,omitempty"` // Sustained calls per minute
+	Burst         int     `json:"burst,omitempty"`         // Calls allowed back to back; defaults to ratePerMinute
+	SessionQuota  int     `json:"sessionQuota,omitempty"`  // Calls per session
+	DailyQuota    int     `json:"dailyQuota,omitempty"`    // Calls per day across sessions
+}
+
+// PoliciesConfig holds limits enforced by the filesystem tools.
+// Zero values fall back to the built-in defaults.
+type PoliciesConfig struct {
+	MaxFileSize     int64          `json:"maxFileSize,omitempty"`     // Largest readable/writable file in bytes
+	AllowedRoots    []string       `json:"allowedRoots,omitempty"`    // Directories the tools may access; defaults to the working directory
+	RestrictedPaths []string       `json:"restrictedPaths,omitempty"` // Deny globs for paths the tools refuse to touch
+	Commands        CommandsConfig `json:"commands,omitempty"`        // Limits for the run_command tool
+	// ConfirmMutations asks before tools write, move, copy or delete files
+	ConfirmMutations bool `json:"confirmMutations,omitempty"`
+	// FetchPrivateNetworks lets fetch_url reach loopback, link-local and
+	// private addresses, such as a local documentation server
+	FetchPrivateNetworks bool `json:"fetchPrivateNetworks,omitempty"`
+}
+
+// CommandsConfig controls the run_command tool. Unset fields fall back to
+// the built-in defaults.
+type CommandsConfig struct {
+	Allow          []string `json:"allow,omitempty"`          // Command prefixes, and "plugin:<name>" entries, that run without approval
+	Deny           []string `json:"deny,omitempty"`           // Commands that are never run
+	TimeoutSeconds int      `json:"timeoutSeconds,omitempty"` // Default time limit per command
+	MaxOutputBytes int      `json:"maxOutputBytes,omitempty"` // Bytes kept from each output stream
+}
+
+// InputConfig holds settings for the persistent input history.
+type InputConfig struct {
+	HistorySize   int    `json:"historySize,omitempty"`   // Entries kept per project
+	HistoryIgnore string `json:"historyIgnore,omitempty"` // Regex of input never recorded; empty uses the built-in secret filter
+}
+
+// SearchConfig selects the web search providers, tried in order.
+type SearchConfig struct {
+	Providers       []SearchProviderConfig `json:"providers,omitempty"`       // Empty uses Brave (with an API key) then DuckDuckGo
+	CacheTTLMinutes int                    `json:"cacheTTLMinutes,omitempty"` // How long search results are cached; defaults to a day
+}
+
+// SearchProviderConfig configures one web search provider.
+type SearchProviderConfig struct {
+	Type           string   `json:"type"`                     // brave, searxng, tavily, bing, duckduckgo or local
+	Name           string   `json:"name,omitempty"`           // Display name; defaults to the type
+	BaseURL        string   `json:"baseURL,omitempty"`        // Endpoint override; required for searxng
+	APIKeyEnv      string   `json:"apiKeyEnv,omitempty"`      // Environment variable holding the API key
+	Paths          []string `json:"paths,omitempty"`          // Directories searched by the local provider
+	TimeoutSeconds int      `json:"timeoutSeconds,omitempty"` // Time limit per request
+}
+
+// NewConfig returns a new Config from a JSON, YAML or TOML file, chosen by
+// the file extension. If the file doesn't exist, it creates a default configuration.
 func NewConfig(filePath string) (*Config, error) {
-	config := &Config{
-		filePath:      filePath,
-		SystemPrompts: getDefaultSystemPrompts(),
-		History:       make(map[string]interface{}),
-		Streaming:     getDefaultStreamingConfig(),
-	}
+	config := newDefaultConfig(filePath)
 
 	// Try to load existing configuration
 	if err := config.Load(); err != nil {
@@ -46,34 +130,134 @@ func NewConfig(filePath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// Validate the loaded configuration
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
-	}
+	return config, nil
+}
 
+// LoadConfig reads an existing configuration file without creating it.
+func LoadConfig(filePath string) (*Config, error) {
+	config := newDefaultConfig(filePath)
+	if err := config.Load(); err != nil {
+		return nil, err
+	}
 	return config, nil
 }
 
-// Load reads and parses the configuration from the file.
+// newDefaultConfig returns a Config populated with the built-in defaults.
+func newDefaultConfig(filePath string) *Config {
+	return &Config{
+		filePath:      filePath,
+		SystemPrompts: getDefaultSystemPrompts(),
+		History:       make(map[string]interface{}),
+		Streaming:     getDefaultStreamingConfig(),
+	}
+}
+
+// Schema returns the JSON Schema describing the configuration file.
+func Schema() []byte {
+	return schemaJSON
+}
+
+// FilePath returns the path the configuration is loaded from and saved to.
+func (c *Config) FilePath() string {
+	return c.filePath
+}
+
+// Format returns the encoding used for the configuration file.
+func (c *Config) Format() Format {
+	return FormatFor(c.filePath)
+}
+
+// Load reads, validates and parses the configuration from the file.
+// Validation failures are returned as ValidationErrors carrying the line and
+// column of each offending value.
 func (c *Config) Load() error {
-	file, err := os.Open(c.filePath)
+	data, err := os.ReadFile(c.filePath)
+	if err != nil {
+		return err
+	}
+	return c.parse(data)
+}
+
+// parse validates data against the schema and decodes it into the receiver.
+func (c *Config) parse(data []byte) error {
+	format := c.Format()
+	doc, err := format.decode(c.filePath, data)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	decoder := json.NewDecoder(file)
-	return decoder.Decode(c)
+	if errs := schema.Validate(configSchema, doc); len(errs) > 0 {
+		verrs := make(ValidationErrors, 0, len(errs))
+		for _, e := range errs {
+			line, col := format.locate(data, e.Path)
+			verrs = append(verrs, &ValidationError{
+				File:    c.filePath,
+				Line:    line,
+				Column:  col,
+				Path:    e.Path,
+				Message: e.Message,
+			})
+		}
+		return verrs
+	}
+
+	// Round-trip through JSON so every format shares the struct tags above
+	normalized, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to normalize configuration: %w", err)
+	}
+	if err := json.Unmarshal(normalized, c); err != nil {
+		return fmt.Errorf("failed to decode configuration: %w", err)
+	}
+
+	if c.History == nil {
+		c.History = make(map[string]interface{})
+	}
+	if err := c.loadState(); err != nil {
+		return err
+	}
+
+	if c.Input.HistoryIgnore != "" {
+		if _, err := regexp.Compile(c.Input.HistoryIgnore); err != nil {
+			pointer := schema.Pointer("Input", "historyIgnore")
+			line, col := format.locate(data, pointer)
+			return ValidationErrors{{
+				File:    c.filePath,
+				Line:    line,
+				Column:  col,
+				Path:    pointer,
+				Message: fmt.Sprintf("invalid regular expression: %v", err),
+			}}
+		}
+	}
+
+	return nil
 }
 
-// Save writes the configuration to the file atomically.
+// Save writes the configuration to the file atomically, using the format
+// implied by the file extension. An existing YAML or TOML file is left as
+// written; only the runtime state (histories and streaming settings) is
+// saved, to a state file under the data directory.
 // This replaces the previous Flush/Write methods.
 func (c *Config) Save() error {
+	if c.keepsState() {
+		return c.saveState()
+	}
+
 	// Ensure parent directory exists
 	if err := os.MkdirAll(filepath.Dir(c.filePath), 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
+	doc, err := c.document()
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	data, err := c.Format().encode(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+
 	// Write to temporary file first (atomic operation)
 	tempFile := c.filePath + ".tmp"
 	file, err := os.Create(tempFile)
@@ -85,11 +269,8 @@ func (c *Config) Save() error {
 		os.Remove(tempFile) // Clean up temp file on error
 	}()
 
-	// Encode JSON with formatting
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(c); err != nil {
-		return fmt.Errorf("failed to encode configuration: %w", err)
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write configuration: %w", err)
 	}
 
 	// Sync to disk and close
@@ -103,6 +284,9 @@ func (c *Config) Save() error {
 		return fmt.Errorf("failed to save config file: %w", err)
 	}
 
+	// Let watchers recognize this write as our own
+	recordSave(c.filePath, data)
+
 	return nil
 }
 
@@ -121,74 +305,61 @@ func (c *Config) Backup() error {
 	return copyFile(c.filePath, backupPath)
 }
 
-// Validate checks the configuration for validity.
+// Validate checks the in-memory configuration against the schema.
 func (c *Config) Validate() error {
-	if err := c.ValidateSystemPrompts(); err != nil {
-		return err
-	}
-	if err := c.ValidateHistory(); err != nil {
+	doc, err := c.document()
+	if err != nil {
 		return err
 	}
-	return c.ValidateStreaming()
-}
-
-// ValidateSystemPrompts ensures system prompts are valid.
-func (c *Config) ValidateSystemPrompts() error {
-	if c.SystemPrompts == nil {
-		return fmt.Errorf("SystemPrompts cannot be nil")
-	}
-
-	if len(c.SystemPrompts) == 0 {
-		return fmt.Errorf("at least one system prompt is required")
-	}
 
-	for name, prompt := range c.SystemPrompts {
-		if strings.TrimSpace(name) == "" {
-			return fmt.Errorf("system prompt name cannot be empty")
-		}
-		if strings.TrimSpace(prompt) == "" {
-			return fmt.Errorf("system prompt '%s' cannot be empty", name)
+	if errs := schema.Validate(configSchema, doc); len(errs) > 0 {
+		verrs := make(ValidationErrors, 0, len(errs))
+		for _, e := range errs {
+			verrs = append(verrs, &ValidationError{Path: e.Path, Message: e.Message})
 		}
+		return verrs
 	}
-
 	return nil
 }
 
-// ValidateHistory ensures history data is valid.
-func (c *Config) ValidateHistory() error {
-	if c.History == nil {
-		c.History = make(map[string]interface{}) // Auto-fix nil history
-		return nil
+// document converts the configuration into a generic map keyed by the JSON
+// field names, the common representation for validation and encoding.
+func (c *Config) document() (map[string]interface{}, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
 	}
 
-	for key, value := range c.History {
-		if strings.TrimSpace(key) == "" {
-			return fmt.Errorf("history key cannot be empty")
-		}
-
-		// Ensure history values can be marshaled to JSON
-		if _, err := json.Marshal(value); err != nil {
-			return fmt.Errorf("invalid history entry '%s': %w", key, err)
-		}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	doc := make(map[string]interface{})
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
 	}
-
-	return nil
+	return normalizeNumbers(doc).(map[string]interface{}), nil
 }
 
-// ValidateStreaming ensures streaming configuration is valid.
-func (c *Config) ValidateStreaming() error {
-	validThinkingLevels := map[string]bool{
-		"off":  true,
-		"low":  true,
-		"med":  true,
-		"high": true,
-	}
-	
-	if !validThinkingLevels[c.Streaming.ThinkingLevel] {
-		return fmt.Errorf("invalid thinking level '%s': must be one of [off, low, med, high]", c.Streaming.ThinkingLevel)
+// normalizeNumbers replaces json.Number values with int64 or float64 so the
+// YAML and TOML encoders emit numbers rather than quoted strings.
+func normalizeNumbers(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = normalizeNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = normalizeNumbers(item)
+		}
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return n
+		}
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
 	}
-	
-	return nil
+	return v
 }
 
 // getDefaultSystemPrompts returns the default system prompts.
@@ -204,9 +375,9 @@ func getDefaultSystemPrompts() map[string]string {
 // getDefaultStreamingConfig returns the default streaming configuration.
 func getDefaultStreamingConfig() StreamingConfig {
 	return StreamingConfig{
-		Enabled:       true,   // Enable streaming by default for better UX
-		ShowThinking:  false,  // Hide thinking by default to avoid noise
-		ThinkingLevel: "med",  // Medium thinking level by default
+		Enabled:       true,  // Enable streaming by default for better UX
+		ShowThinking:  false, // Hide thinking by default to avoid noise
+		ThinkingLevel: "med", // Medium thinking level by default
 	}
 }
 
@@ -0,0 +1,311 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain keeps runtime state written by the tests out of the user's data
+// directory.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "goss-data")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv(DataDirEnv, dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestNewConfigCreatesDefault(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "goss_config.json")
+
+	cfg, err := NewConfig(path)
+	if err != nil {
+		t.Fatalf("NewConfig failed: %v", err)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected default config file to be created: %v", err)
+	}
+	if cfg.Streaming.ThinkingLevel != "med" {
+		t.Errorf("Expected default thinking level 'med', got %q", cfg.Streaming.ThinkingLevel)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("default config should be valid: %v", err)
+	}
+}
+
+func TestLoadFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{
+			name: "json",
+			file: "goss.json",
+			content: `{
+  "SystemPrompts": {"Coder": "Write Go."},
+  "Streaming": {"enabled": false, "thinkingLevel": "high"},
+  "Providers": {"local": {"baseURL": "http://localhost:1234/v1", "model": "qwen2.5"}},
+  "Search": {"providers": [{"type": "searxng", "baseURL": "http://localhost:8888"}, {"type": "duckduckgo"}]}
+}`,
+		},
+		{
+			name: "yaml",
+			file: "goss.yaml",
+			content: `SystemPrompts:
+  Coder: |
+    Write Go.
+Streaming:
+  enabled: false
+  thinkingLevel: high
+Providers:
+  local:
+    baseURL: http://localhost:1234/v1
+    model: qwen2.5
+Search:
+  providers:
+    - type: searxng
+      baseURL: http://localhost:8888
+    - type: duckduckgo
+`,
+		},
+		{
+			name: "toml",
+			file: "goss.toml",
+			content: `[SystemPrompts]
+Coder = """
+Write Go."""
+
+[Streaming]
+enabled = false
+thinkingLevel = "high"
+
+[Providers.local]
+baseURL = "http://localhost:1234/v1"
+model = "qwen2.5"
+
+[[Search.providers]]
+type = "searxng"
+baseURL = "http://localhost:8888"
+
+[[Search.providers]]
+type = "duckduckgo"
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := NewConfig(writeConfig(t, tt.file, tt.content))
+			if err != nil {
+				t.Fatalf("NewConfig failed: %v", err)
+			}
+
+			if got := strings.TrimSpace(cfg.SystemPrompts["Coder"]); got != "Write Go." {
+				t.Errorf("Expected prompt 'Write Go.', got %q", got)
+			}
+			if cfg.Streaming.Enabled {
+				t.Error("Expected streaming to be disabled")
+			}
+			if cfg.Streaming.ThinkingLevel != "high" {
+				t.Errorf("Expected thinking level 'high', got %q", cfg.Streaming.ThinkingLevel)
+			}
+			if cfg.Providers["local"].Model != "qwen2.5" {
+				t.Errorf("Expected provider model 'qwen2.5', got %q", cfg.Providers["local"].Model)
+			}
+			if providers := cfg.Search.Providers; len(providers) != 2 || providers[1].Type != "duckduckgo" {
+				t.Errorf("Expected two search providers, got %+v", providers)
+			}
+		})
+	}
+}
+
+func TestSaveRoundTrip(t *testing.T) {
+	for _, name := range []string{"goss.json", "goss.yaml", "goss.toml"} {
+		t.Run(name, func(t *testing.T) {
+			// A new file is written in full, whatever its format
+			path := filepath.Join(t.TempDir(), name)
+			cfg := newDefaultConfig(path)
+			cfg.SystemPrompts["Multi"] = "line one\nline two"
+			cfg.History["session_1"] = `[{"role":"user","content":"hi"}]`
+			cfg.Policies.MaxFileSize = 1024
+			if err := cfg.Save(); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+
+			reloaded, err := NewConfig(path)
+			if err != nil {
+				t.Fatalf("reload failed: %v", err)
+			}
+			if reloaded.SystemPrompts["Multi"] != "line one\nline two" {
+				t.Errorf("multi-line prompt not preserved: %q", reloaded.SystemPrompts["Multi"])
+			}
+			if reloaded.History["session_1"] != cfg.History["session_1"] {
+				t.Errorf("history not preserved: %v", reloaded.History["session_1"])
+			}
+			if reloaded.Policies.MaxFileSize != 1024 {
+				t.Errorf("Expected maxFileSize 1024, got %d", reloaded.Policies.MaxFileSize)
+			}
+		})
+	}
+}
+
+func TestSaveKeepsHandWrittenFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"goss.yaml", "# Local models\nStreaming:\n  enabled: true # fast enough\n  thinkingLevel: low\nHistory:\n  old: \"[]\"\n"},
+		{"goss.toml", "# Local models\n[Streaming]\nenabled = true # fast enough\nthinkingLevel = \"low\"\n\n[History]\nold = \"[]\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, tt.name, tt.content)
+			cfg, err := NewConfig(path)
+			if err != nil {
+				t.Fatalf("NewConfig failed: %v", err)
+			}
+
+			cfg.Streaming.Enabled = false
+			cfg.Streaming.ThinkingLevel = "high"
+			delete(cfg.History, "old")
+			cfg.History["session_1"] = "[]"
+			if err := cfg.Save(); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.content {
+				t.Errorf("configuration file was rewritten:\n%s", data)
+			}
+
+			reloaded, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("reload failed: %v", err)
+			}
+			if reloaded.Streaming.Enabled || reloaded.Streaming.ThinkingLevel != "high" {
+				t.Errorf("streaming settings not restored: %+v", reloaded.Streaming)
+			}
+			if _, ok := reloaded.History["old"]; ok {
+				t.Error("deleted history came back from the file")
+			}
+			if reloaded.History["session_1"] != "[]" {
+				t.Errorf("history not restored: %v", reloaded.History)
+			}
+		})
+	}
+}
+
+func TestValidationErrorPositions(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		line    int
+		column  int
+		path    string
+	}{
+		{
+			name: "json enum",
+			file: "goss.json",
+			content: `{
+  "SystemPrompts": {"A": "x"},
+  "Streaming": {
+    "thinkingLevel": "extreme"
+  }
+}`,
+			line:   4,
+			column: 22,
+			path:   "/Streaming/thinkingLevel",
+		},
+		{
+			name: "yaml empty prompt",
+			file: "goss.yaml",
+			content: `SystemPrompts:
+  A: x
+  Blank: "  "
+`,
+			line:   3,
+			column: 3,
+			path:   "/SystemPrompts/Blank",
+		},
+		{
+			name: "toml wrong type",
+			file: "goss.toml",
+			content: `[SystemPrompts]
+A = "x"
+
+[Policies]
+maxFileSize = "big"
+`,
+			line:   5,
+			column: 1,
+			path:   "/Policies/maxFileSize",
+		},
+		{
+			name:    "unknown section",
+			file:    "goss.json",
+			content: "{\n  \"SystemPrompts\": {\"A\": \"x\"},\n  \"Extra\": true\n}",
+			line:    3,
+			column:  12,
+			path:    "/Extra",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewConfig(writeConfig(t, tt.file, tt.content))
+			if err == nil {
+				t.Fatal("expected validation error")
+			}
+
+			var verrs ValidationErrors
+			if !errors.As(err, &verrs) {
+				t.Fatalf("expected ValidationErrors, got %T: %v", err, err)
+			}
+			if len(verrs) != 1 {
+				t.Fatalf("expected 1 error, got %d: %v", len(verrs), verrs)
+			}
+
+			got := verrs[0]
+			if got.Path != tt.path {
+				t.Errorf("Expected path %s, got %s", tt.path, got.Path)
+			}
+			if got.Line != tt.line || got.Column != tt.column {
+				t.Errorf("Expected position %d:%d, got %d:%d (%v)", tt.line, tt.column, got.Line, got.Column, got)
+			}
+		})
+	}
+}
+
+func TestSyntaxErrorPosition(t *testing.T) {
+	_, err := NewConfig(writeConfig(t, "goss.json", "{\n  \"SystemPrompts\": {\n    \"A\": \"x\",\n  }\n}"))
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError, got %T: %v", err, err)
+	}
+	if verr.Line != 4 {
+		t.Errorf("Expected syntax error on line 4, got %d (%v)", verr.Line, verr)
+	}
+}
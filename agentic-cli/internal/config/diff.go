@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Diff returns a human-readable description of every setting that differs
+// between old and new. Saved history is summarized rather than listed.
+func Diff(old, new *Config) []string {
+	var changes []string
+
+	changes = append(changes, diffKeys("SystemPrompts", promptKeys(old.SystemPrompts), promptKeys(new.SystemPrompts))...)
+
+	if old.Streaming.Enabled != new.Streaming.Enabled {
+		changes = append(changes, fmt.Sprintf("Streaming.enabled: %t → %t", old.Streaming.Enabled, new.Streaming.Enabled))
+	}
+	if old.Streaming.ShowThinking != new.Streaming.ShowThinking {
+		changes = append(changes, fmt.Sprintf("Streaming.showThinking: %t → %t", old.Streaming.ShowThinking, new.Streaming.ShowThinking))
+	}
+	if old.Streaming.ThinkingLevel != new.Streaming.ThinkingLevel {
+		changes = append(changes, fmt.Sprintf("Streaming.thinkingLevel: %s → %s", old.Streaming.ThinkingLevel, new.Streaming.ThinkingLevel))
+	}
+
+	oldProviders := make(map[string]interface{}, len(old.Providers))
+	for k, v := range old.Providers {
+		oldProviders[k] = v
+	}
+	newProviders := make(map[string]interface{}, len(new.Providers))
+	for k, v := range new.Providers {
+		newProviders[k] = v
+	}
+	changes = append(changes, diffKeys("Providers", oldProviders, newProviders)...)
+
+	if old.Tools.Profile != new.Tools.Profile {
+		changes = append(changes, fmt.Sprintf("Tools.profile: %s → %s", old.Tools.Profile, new.Tools.Profile))
+	}
+	if !reflect.DeepEqual(old.Tools.Profiles, new.Tools.Profiles) {
+		changes = append(changes, "Tools.profiles: updated")
+	}
+	if !reflect.DeepEqual(old.Tools.Disabled, new.Tools.Disabled) {
+		changes = append(changes, fmt.Sprintf("Tools.disabled: [%s] → [%s]",
+			strings.Join(old.Tools.Disabled, ", "), strings.Join(new.Tools.Disabled, ", ")))
+	}
+	if !reflect.DeepEqual(old.Tools.Limits, new.Tools.Limits) {
+		changes = append(changes, "Tools.limits: updated")
+	}
+	if !reflect.DeepEqual(old.Tools.PromptModels, new.Tools.PromptModels) {
+		changes = append(changes, fmt.Sprintf("Tools.promptModels: [%s] → [%s]",
+			strings.Join(old.Tools.PromptModels, ", "), strings.Join(new.Tools.PromptModels, ", ")))
+	}
+	if old.Policies.MaxFileSize != new.Policies.MaxFileSize {
+		changes = append(changes, fmt.Sprintf("Policies.maxFileSize: %d → %d", old.Policies.MaxFileSize, new.Policies.MaxFileSize))
+	}
+	if !reflect.DeepEqual(old.Policies.AllowedRoots, new.Policies.AllowedRoots) {
+		changes = append(changes, fmt.Sprintf("Policies.allowedRoots: [%s] → [%s]",
+			strings.Join(old.Policies.AllowedRoots, ", "), strings.Join(new.Policies.AllowedRoots, ", ")))
+	}
+	if !reflect.DeepEqual(old.Policies.RestrictedPaths, new.Policies.RestrictedPaths) {
+		changes = append(changes, fmt.Sprintf("Policies.restrictedPaths: [%s] → [%s]",
+			strings.Join(old.Policies.RestrictedPaths, ", "), strings.Join(new.Policies.RestrictedPaths, ", ")))
+	}
+
+	if old.Policies.ConfirmMutations != new.Policies.ConfirmMutations {
+		changes = append(changes, fmt.Sprintf("Policies.confirmMutations: %t → %t", old.Policies.ConfirmMutations, new.Policies.ConfirmMutations))
+	}
+	if old.Policies.FetchPrivateNetworks != new.Policies.FetchPrivateNetworks {
+		changes = append(changes, fmt.Sprintf("Policies.fetchPrivateNetworks: %t → %t", old.Policies.FetchPrivateNetworks, new.Policies.FetchPrivateNetworks))
+	}
+	if !reflect.DeepEqual(old.Policies.Commands, new.Policies.Commands) {
+		changes = append(changes, "Policies.commands: updated")
+	}
+	if old.Input.HistorySize != new.Input.HistorySize {
+		changes = append(changes, fmt.Sprintf("Input.historySize: %d → %d", old.Input.HistorySize, new.Input.HistorySize))
+	}
+	if old.Input.HistoryIgnore != new.Input.HistoryIgnore {
+		changes = append(changes, fmt.Sprintf("Input.historyIgnore: %q → %q", old.Input.HistoryIgnore, new.Input.HistoryIgnore))
+	}
+
+	if !reflect.DeepEqual(old.Search.Providers, new.Search.Providers) {
+		changes = append(changes, fmt.Sprintf("Search.providers: [%s] → [%s]",
+			strings.Join(searchProviderNames(old.Search), ", "), strings.Join(searchProviderNames(new.Search), ", ")))
+	}
+
+	if old.Search.CacheTTLMinutes != new.Search.CacheTTLMinutes {
+		changes = append(changes, fmt.Sprintf("Search.cacheTTLMinutes: %d → %d", old.Search.CacheTTLMinutes, new.Search.CacheTTLMinutes))
+	}
+
+	if len(old.History) != len(new.History) {
+		changes = append(changes, fmt.Sprintf("History: %d → %d saved sessions", len(old.History), len(new.History)))
+	}
+
+	return changes
+}
+
+func searchProviderNames(search SearchConfig) []string {
+	names := make([]string, len(search.Providers))
+	for i, p := range search.Providers {
+		names[i] = p.Type
+		if p.Name != "" {
+			names[i] = p.Name
+		}
+	}
+	return names
+}
+
+func promptKeys(prompts map[string]string) map[string]interface{} {
+	out := make(map[string]interface{}, len(prompts))
+	for k, v := range prompts {
+		out[k] = v
+	}
+	return out
+}
+
+// diffKeys reports added, removed and modified entries of a named map section.
+func diffKeys(section string, old, new map[string]interface{}) []string {
+	var added, removed, modified []string
+	for k, v := range new {
+		prev, ok := old[k]
+		switch {
+		case !ok:
+			added = append(added, k)
+		case !reflect.DeepEqual(prev, v):
+			modified = append(modified, k)
+		}
+	}
+	for k := range old {
+		if _, ok := new[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+
+	var changes []string
+	for _, group := range []struct {
+		verb  string
+		names []string
+	}{{"added", added}, {"removed", removed}, {"changed", modified}} {
+		if len(group.names) == 0 {
+			continue
+		}
+		sort.Strings(group.names)
+		changes = append(changes, fmt.Sprintf("%s: %s %s", section, group.verb, strings.Join(group.names, ", ")))
+	}
+	return changes
+}
@@ -0,0 +1,364 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// Format identifies the on-disk encoding of a configuration file.
+type Format int
+
+const (
+	FormatJSON Format = iota
+	FormatYAML
+	FormatTOML
+)
+
+// String returns the conventional name of the format.
+func (f Format) String() string {
+	switch f {
+	case FormatYAML:
+		return "yaml"
+	case FormatTOML:
+		return "toml"
+	default:
+		return "json"
+	}
+}
+
+// FormatFor picks the configuration format from the file extension.
+// Unknown extensions are treated as JSON.
+func FormatFor(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatJSON
+	}
+}
+
+// decode parses data into a generic document suitable for schema validation.
+// Syntax errors are reported as *ValidationError with a source position.
+func (f Format) decode(file string, data []byte) (map[string]interface{}, error) {
+	doc := make(map[string]interface{})
+	switch f {
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, yamlSyntaxError(file, err)
+		}
+		doc = normalize(doc).(map[string]interface{})
+	case FormatTOML:
+		if _, err := toml.Decode(string(data), &doc); err != nil {
+			return nil, tomlSyntaxError(file, err)
+		}
+		doc = normalize(doc).(map[string]interface{})
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, jsonSyntaxError(file, data, err)
+		}
+	}
+	return doc, nil
+}
+
+// normalize converts the containers YAML and TOML decoders produce, such as
+// the []map[string]interface{} of a TOML array of tables, to the
+// map[string]interface{} and []interface{} that JSON and the schema use.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = normalize(value)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[fmt.Sprint(key)] = normalize(value)
+		}
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalize(value)
+		}
+		return v
+	case []map[string]interface{}:
+		list := make([]interface{}, len(v))
+		for i, value := range v {
+			list[i] = normalize(value)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+// encode serializes a generic document in the receiver format.
+func (f Format) encode(doc map[string]interface{}) ([]byte, error) {
+	switch f {
+	case FormatYAML:
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(doc); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case FormatTOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(doc); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+// locate returns the 1-based line and column of the value addressed by the
+// JSON pointer, falling back to the closest enclosing value that exists.
+func (f Format) locate(data []byte, pointer string) (int, int) {
+	segments := schema.SplitPointer(pointer)
+	switch f {
+	case FormatYAML:
+		return locateYAML(data, segments)
+	case FormatTOML:
+		return locateTOML(data, segments)
+	default:
+		return locateJSON(data, segments)
+	}
+}
+
+func locateYAML(data []byte, segments []string) (int, int) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil || len(root.Content) == 0 {
+		return 1, 1
+	}
+	node := root.Content[0]
+	line, col := node.Line, node.Column
+	for _, seg := range segments {
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == seg {
+					next = node.Content[i+1]
+					line, col = node.Content[i].Line, node.Content[i].Column
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if idx, err := strconv.Atoi(seg); err == nil && idx < len(node.Content) {
+				next = node.Content[idx]
+				line, col = next.Line, next.Column
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return line, col
+}
+
+var (
+	tomlTablePattern = regexp.MustCompile(`^\s*\[\[?\s*([^\]]+?)\s*\]\]?`)
+	tomlKeyPattern   = regexp.MustCompile(`^(\s*)("[^"]*"|'[^']*'|[A-Za-z0-9_.-]+)\s*=`)
+)
+
+// locateTOML scans table headers and key assignments line by line. It does
+// not understand every TOML construct, but covers the tables and dotted keys
+// a configuration file realistically contains.
+func locateTOML(data []byte, segments []string) (int, int) {
+	if len(segments) == 0 {
+		return 1, 1
+	}
+	bestLine, bestCol, bestDepth := 1, 1, 0
+	var table []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		text := scanner.Text()
+		if m := tomlTablePattern.FindStringSubmatch(text); m != nil {
+			table = splitTOMLKey(m[1])
+			if depth := matchDepth(table, segments); depth == len(table) && depth > bestDepth {
+				bestLine, bestCol, bestDepth = lineNo, strings.Index(text, "[")+1, depth
+			}
+			continue
+		}
+		if m := tomlKeyPattern.FindStringSubmatch(text); m != nil {
+			key := append(append([]string{}, table...), splitTOMLKey(m[2])...)
+			if depth := matchDepth(key, segments); depth == len(key) && depth > bestDepth {
+				bestLine, bestCol, bestDepth = lineNo, len(m[1])+1, depth
+			}
+		}
+	}
+	return bestLine, bestCol
+}
+
+func splitTOMLKey(key string) []string {
+	var parts []string
+	for _, p := range strings.Split(key, ".") {
+		parts = append(parts, strings.Trim(strings.TrimSpace(p), `"'`))
+	}
+	return parts
+}
+
+func matchDepth(key, segments []string) int {
+	n := 0
+	for n < len(key) && n < len(segments) && key[n] == segments[n] {
+		n++
+	}
+	return n
+}
+
+// locateJSON walks the token stream until it reaches the addressed value.
+func locateJSON(data []byte, segments []string) (int, int) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	offset := 0
+	for depth := 0; ; depth++ {
+		start := valueStart(data, int(decoder.InputOffset()))
+		tok, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		offset = start
+		if depth == len(segments) {
+			break
+		}
+		delim, ok := tok.(json.Delim)
+		if !ok {
+			break
+		}
+		if !seekJSONChild(decoder, delim, segments[depth]) {
+			break
+		}
+	}
+	return lineCol(data, offset)
+}
+
+// seekJSONChild advances the decoder to just before the value of the named
+// object key or array index. It reports whether the child was found.
+func seekJSONChild(decoder *json.Decoder, delim json.Delim, segment string) bool {
+	switch delim {
+	case '{':
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return false
+			}
+			if key == segment {
+				return true
+			}
+			if skipJSONValue(decoder) != nil {
+				return false
+			}
+		}
+	case '[':
+		idx, err := strconv.Atoi(segment)
+		if err != nil {
+			return false
+		}
+		for i := 0; decoder.More(); i++ {
+			if i == idx {
+				return true
+			}
+			if skipJSONValue(decoder) != nil {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+func skipJSONValue(decoder *json.Decoder) error {
+	var raw json.RawMessage
+	return decoder.Decode(&raw)
+}
+
+// valueStart skips whitespace and separators to find where the next value begins.
+func valueStart(data []byte, offset int) int {
+	for offset < len(data) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ':', ',':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+func lineCol(data []byte, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	line := 1 + bytes.Count(data[:offset], []byte("\n"))
+	col := offset - bytes.LastIndexByte(data[:offset], '\n')
+	return line, col
+}
+
+func jsonSyntaxError(file string, data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, col := lineCol(data, int(syntaxErr.Offset))
+		return &ValidationError{File: file, Line: line, Column: col, Message: syntaxErr.Error()}
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		line, col := lineCol(data, int(typeErr.Offset))
+		return &ValidationError{File: file, Line: line, Column: col, Message: "top-level value must be an object"}
+	}
+	if errors.Is(err, io.EOF) {
+		return &ValidationError{File: file, Line: 1, Column: 1, Message: "file is empty"}
+	}
+	return err
+}
+
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+func yamlSyntaxError(file string, err error) error {
+	line := 1
+	if m := yamlLinePattern.FindStringSubmatch(err.Error()); m != nil {
+		line, _ = strconv.Atoi(m[1])
+	}
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	return &ValidationError{File: file, Line: line, Column: 1, Message: msg}
+}
+
+func tomlSyntaxError(file string, err error) error {
+	var parseErr toml.ParseError
+	if errors.As(err, &parseErr) {
+		return &ValidationError{
+			File:    file,
+			Line:    parseErr.Position.Line,
+			Column:  parseErr.Position.Col,
+			Message: parseErr.Message,
+		}
+	}
+	return fmt.Errorf("%s: %w", file, err)
+}
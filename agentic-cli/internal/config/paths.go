@@ -0,0 +1,50 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// DataDirEnv overrides the directory where goss keeps runtime data.
+const DataDirEnv = "GOSS_DATA_DIR"
+
+// DataDir returns the directory for runtime data such as input history and
+// caches: $GOSS_DATA_DIR, else $XDG_DATA_HOME/goss, else ~/.local/share/goss.
+// The directory is created if it does not exist.
+func DataDir() (string, error) {
+	dir := os.Getenv(DataDirEnv)
+	if dir == "" {
+		if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+			dir = filepath.Join(xdg, "goss")
+		} else {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", fmt.Errorf("failed to locate home directory: %w", err)
+			}
+			dir = filepath.Join(home, ".local", "share", "goss")
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create data directory: %w", err)
+	}
+	return dir, nil
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// ProjectKey returns a stable, file-name-safe identifier for the project
+// rooted at dir, combining its base name with a hash of the absolute path.
+func ProjectKey(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		abs = dir
+	}
+	sum := sha256.Sum256([]byte(abs))
+	base := unsafeNameChars.ReplaceAllString(filepath.Base(abs), "_")
+	return fmt.Sprintf("%s-%s", base, hex.EncodeToString(sum[:])[:12])
+}
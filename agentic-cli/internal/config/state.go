@@ -0,0 +1,96 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// runtimeState is the part of the configuration changed at runtime: saved
+// histories and the streaming toggles. For YAML and TOML files it is kept in
+// a state file under the data directory, so saving it never rewrites the
+// comments and layout of a hand-written configuration.
+type runtimeState struct {
+	History   map[string]interface{} `json:"History"`
+	Streaming *StreamingConfig       `json:"Streaming,omitempty"`
+}
+
+// keepsState reports whether runtime state is saved outside the
+// configuration file: true for YAML and TOML files that already exist.
+func (c *Config) keepsState() bool {
+	if c.Format() == FormatJSON {
+		return false
+	}
+	_, err := os.Stat(c.filePath)
+	return err == nil
+}
+
+// statePath returns the state file for the configuration, named after the
+// configuration file so each one keeps its own state.
+func (c *Config) statePath() (string, error) {
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "state", ProjectKey(c.filePath)+".json"), nil
+}
+
+// loadState applies the saved runtime state, if any, over the values read
+// from a YAML or TOML file.
+func (c *Config) loadState() error {
+	if c.Format() == FormatJSON {
+		return nil
+	}
+	path, err := c.statePath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read runtime state: %w", err)
+	}
+
+	var state runtimeState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to decode runtime state %s: %w", path, err)
+	}
+	// The state holds every history, including those from the file, so
+	// deletions stick
+	if state.History != nil {
+		c.History = state.History
+	}
+	if state.Streaming != nil {
+		c.Streaming = *state.Streaming
+	}
+	return nil
+}
+
+// saveState writes the runtime state to the state file atomically.
+func (c *Config) saveState() error {
+	path, err := c.statePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(runtimeState{History: c.History, Streaming: &c.Streaming}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode runtime state: %w", err)
+	}
+
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to write runtime state: %w", err)
+	}
+	if err := os.Rename(tempFile, path); err != nil {
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to save runtime state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Store holds the active configuration so it can be replaced atomically
+// while handlers keep running. Handlers should call Get for every use
+// instead of caching the returned pointer.
+type Store struct {
+	current atomic.Pointer[Config]
+
+	mu        sync.Mutex
+	listeners []func(*Config)
+}
+
+// NewStore returns a Store holding the given configuration.
+func NewStore(c *Config) *Store {
+	s := &Store{}
+	s.current.Store(c)
+	return s
+}
+
+// Get returns the active configuration.
+func (s *Store) Get() *Config {
+	return s.current.Load()
+}
+
+// Swap makes c the active configuration, notifies listeners and returns
+// the previous configuration.
+func (s *Store) Swap(c *Config) *Config {
+	old := s.current.Swap(c)
+
+	s.mu.Lock()
+	listeners := append([]func(*Config){}, s.listeners...)
+	s.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(c)
+	}
+	return old
+}
+
+// OnSwap registers fn to be called with every configuration passed to Swap.
+func (s *Store) OnSwap(fn func(*Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ValidationError describes a configuration value that violates the schema.
+// Line and Column are 1-based and zero when the error did not come from a file.
+type ValidationError struct {
+	File    string
+	Line    int
+	Column  int
+	Path    string // JSON pointer to the offending value
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		fmt.Fprintf(&b, "%s:%d:%d: ", e.File, e.Line, e.Column)
+	}
+	if e.Path != "" {
+		fmt.Fprintf(&b, "%s: ", e.Path)
+	}
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+// ValidationErrors collects every schema violation found in a configuration.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func mustParseSchema(data []byte) map[string]interface{} {
+	var s map[string]interface{}
+	if err := json.Unmarshal(data, &s); err != nil {
+		panic(fmt.Sprintf("config: invalid embedded schema: %v", err))
+	}
+	return s
+}
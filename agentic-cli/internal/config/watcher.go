@@ -0,0 +1,221 @@
+package config
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// DefaultPollInterval is used when file system notifications are unavailable
+	DefaultPollInterval = 2 * time.Second
+	// reloadDebounce coalesces the burst of events produced by a single save
+	reloadDebounce = 150 * time.Millisecond
+)
+
+// ReloadFunc receives a freshly loaded configuration together with a
+// human-readable list of what changed. On failure cfg is nil and err
+// describes why the file could not be loaded or validated.
+type ReloadFunc func(cfg *Config, changes []string, err error)
+
+// Watcher monitors a configuration file and reloads it when it changes.
+// It prefers file system notifications and falls back to polling.
+type Watcher struct {
+	path     string
+	interval time.Duration
+	onReload ReloadFunc
+
+	last    *Config
+	lastSum [sha256.Size]byte
+
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// NewWatcher returns a Watcher for the configuration at path. The current
+// file contents become the baseline against which changes are reported.
+func NewWatcher(path string, interval time.Duration, onReload ReloadFunc) (*Watcher, error) {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	last := newDefaultConfig(path)
+	if err := last.parse(data); err != nil {
+		return nil, err
+	}
+
+	return &Watcher{
+		path:     path,
+		interval: interval,
+		onReload: onReload,
+		last:     last,
+		lastSum:  sha256.Sum256(data),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Start begins watching in a background goroutine. Notifications are set
+// up before Start returns so that no subsequent change is missed.
+func (w *Watcher) Start() {
+	fsw, absPath, err := w.newNotifier()
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		if err != nil {
+			w.poll()
+			return
+		}
+		defer fsw.Close()
+		w.watchEvents(fsw, absPath)
+	}()
+}
+
+// Close stops the watcher and waits for it to exit.
+func (w *Watcher) Close() error {
+	w.once.Do(func() { close(w.done) })
+	w.wg.Wait()
+	return nil
+}
+
+// newNotifier watches the parent directory so that atomic renames, which
+// replace the watched inode, are still observed.
+func (w *Watcher) newNotifier() (*fsnotify.Watcher, string, error) {
+	absPath, err := filepath.Abs(w.path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, "", err
+	}
+	if err := fsw.Add(filepath.Dir(absPath)); err != nil {
+		fsw.Close()
+		return nil, "", err
+	}
+	return fsw, absPath, nil
+}
+
+// watchEvents reloads the file after a burst of events touching it settles.
+func (w *Watcher) watchEvents(fsw *fsnotify.Watcher, absPath string) {
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-fsw.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) == absPath &&
+				event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				debounce = time.After(reloadDebounce)
+			}
+		case _, ok := <-fsw.Errors:
+			if !ok {
+				return
+			}
+		case <-debounce:
+			debounce = nil
+			w.check()
+		}
+	}
+}
+
+// poll compares the file modification time and size at a fixed interval.
+func (w *Watcher) poll() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	var lastMod time.Time
+	var lastSize int64 = -1
+	if info, err := os.Stat(w.path); err == nil {
+		lastMod, lastSize = info.ModTime(), info.Size()
+	}
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(w.path)
+			if err != nil {
+				continue
+			}
+			if info.ModTime().Equal(lastMod) && info.Size() == lastSize {
+				continue
+			}
+			lastMod, lastSize = info.ModTime(), info.Size()
+			w.check()
+		}
+	}
+}
+
+// check reloads the file if its contents changed and reports the result.
+func (w *Watcher) check() {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			w.onReload(nil, nil, err)
+		}
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	if sum == w.lastSum {
+		return
+	}
+
+	fresh := newDefaultConfig(w.path)
+	if err := fresh.parse(data); err != nil {
+		w.onReload(nil, nil, err)
+		return
+	}
+
+	changes := Diff(w.last, fresh)
+	w.last, w.lastSum = fresh, sum
+
+	// Writes made by this process (e.g. !stream) are already in effect
+	if savedBySelf(w.path, sum) {
+		return
+	}
+	w.onReload(fresh, changes, nil)
+}
+
+var (
+	savesMu sync.Mutex
+	saves   = make(map[string][sha256.Size]byte)
+)
+
+// recordSave remembers the contents most recently written to path.
+func recordSave(path string, data []byte) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	savesMu.Lock()
+	defer savesMu.Unlock()
+	saves[abs] = sha256.Sum256(data)
+}
+
+// savedBySelf reports whether sum matches the last contents this process saved to path.
+func savedBySelf(path string, sum [sha256.Size]byte) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	savesMu.Lock()
+	defer savesMu.Unlock()
+	saved, ok := saves[abs]
+	return ok && saved == sum
+}
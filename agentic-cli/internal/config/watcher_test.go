@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type reload struct {
+	cfg     *Config
+	changes []string
+	err     error
+}
+
+func startWatcher(t *testing.T, path string) <-chan reload {
+	t.Helper()
+	reloads := make(chan reload, 10)
+	w, err := NewWatcher(path, 20*time.Millisecond, func(cfg *Config, changes []string, err error) {
+		reloads <- reload{cfg, changes, err}
+	})
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	w.Start()
+	t.Cleanup(func() { w.Close() })
+	return reloads
+}
+
+func waitReload(t *testing.T, reloads <-chan reload) reload {
+	t.Helper()
+	select {
+	case r := <-reloads:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for reload")
+	}
+	return reload{}
+}
+
+func TestWatcherReportsExternalChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "goss.yaml")
+	if _, err := NewConfig(path); err != nil {
+		t.Fatalf("NewConfig failed: %v", err)
+	}
+	reloads := startWatcher(t, path)
+
+	edited := "SystemPrompts:\n  Reviewer: Review code.\nStreaming:\n  enabled: true\n  thinkingLevel: high\n"
+	if err := os.WriteFile(path, []byte(edited), 0644); err != nil {
+		t.Fatalf("failed to edit config: %v", err)
+	}
+
+	r := waitReload(t, reloads)
+	if r.err != nil {
+		t.Fatalf("unexpected reload error: %v", r.err)
+	}
+	if r.cfg.Streaming.ThinkingLevel != "high" {
+		t.Errorf("Expected thinking level 'high', got %q", r.cfg.Streaming.ThinkingLevel)
+	}
+
+	joined := strings.Join(r.changes, "\n")
+	for _, want := range []string{"Streaming.thinkingLevel: med → high", "SystemPrompts: added Reviewer"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("Expected change %q in:\n%s", want, joined)
+		}
+	}
+}
+
+func TestWatcherIgnoresOwnSaves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "goss.json")
+	cfg, err := NewConfig(path)
+	if err != nil {
+		t.Fatalf("NewConfig failed: %v", err)
+	}
+	reloads := startWatcher(t, path)
+
+	cfg.Streaming.Enabled = false
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	select {
+	case r := <-reloads:
+		t.Fatalf("unexpected reload for own save: %+v", r)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestWatcherReportsInvalidConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "goss.json")
+	if _, err := NewConfig(path); err != nil {
+		t.Fatalf("NewConfig failed: %v", err)
+	}
+	reloads := startWatcher(t, path)
+
+	invalid := `{"SystemPrompts": {"A": "x"}, "Streaming": {"thinkingLevel": "max"}}`
+	if err := os.WriteFile(path, []byte(invalid), 0644); err != nil {
+		t.Fatalf("failed to edit config: %v", err)
+	}
+
+	r := waitReload(t, reloads)
+	if r.err == nil || r.cfg != nil {
+		t.Fatalf("expected validation error, got cfg=%v err=%v", r.cfg, r.err)
+	}
+	if !strings.Contains(r.err.Error(), "/Streaming/thinkingLevel") {
+		t.Errorf("error should name the offending setting: %v", r.err)
+	}
+}
+
+func TestStoreSwapNotifiesListeners(t *testing.T) {
+	first := newDefaultConfig("a.json")
+	second := newDefaultConfig("b.json")
+	store := NewStore(first)
+
+	var notified *Config
+	store.OnSwap(func(c *Config) { notified = c })
+
+	if old := store.Swap(second); old != first {
+		t.Error("Swap should return the previous configuration")
+	}
+	if store.Get() != second || notified != second {
+		t.Error("Swap should install and announce the new configuration")
+	}
+}
@@ -1,5 +1,12 @@
 package handler
 
+import (
+	"fmt"
+
+	"github.com/chzyer/readline"
+	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/cli"
+)
+
 const (
 	empty            = "Empty"
 	unchangedMessage = "The selection is unchanged."
@@ -14,3 +21,19 @@ type MessageHandler interface {
 	// TerminalPrompt returns the terminal prompt for the handler.
 	TerminalPrompt() string
 }
+
+// canPrompt reports whether interactive promptui menus can be displayed.
+func canPrompt() bool {
+	return readline.DefaultIsTerminal()
+}
+
+// menuUnavailable is returned instead of opening a menu when the input is
+// not a terminal, pointing at the scriptable form of the command.
+func menuUnavailable(cmd *cli.Command) Response {
+	return newErrorResponse(fmt.Errorf("interactive menu requires a terminal; %w", cmd.UsageError()))
+}
+
+// unknownSubcommand reports a subcommand the command does not accept.
+func unknownSubcommand(cmd *cli.Command) Response {
+	return newErrorResponse(fmt.Errorf("unknown subcommand %q; %w", cmd.Arg(0), cmd.UsageError()))
+}
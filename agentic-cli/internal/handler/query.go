@@ -3,10 +3,13 @@ package handler
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/charmbracelet/glamour"
 	"github.com/vivesm/GOSS-CLI/agentic-cli/agentic"
 	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/config"
+	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/terminal/color"
+	"github.com/vivesm/GOSS-CLI/agentic-cli/mcp"
 )
 
 // AgenticQuery processes queries to agentic models with MCP tools.
@@ -15,13 +18,13 @@ type AgenticQuery struct {
 	*IO
 	session  *agentic.ChatSession
 	renderer *glamour.TermRenderer
-	config   *config.Config
+	config   *config.Store
 }
 
 var _ MessageHandler = (*AgenticQuery)(nil)
 
 // NewAgenticQuery returns a new AgenticQuery message handler.
-func NewAgenticQuery(io *IO, session *agentic.ChatSession, config *config.Config, opts RendererOptions) (*AgenticQuery, error) {
+func NewAgenticQuery(io *IO, session *agentic.ChatSession, config *config.Store, opts RendererOptions) (*AgenticQuery, error) {
 	renderer, err := opts.newTermRenderer()
 	if err != nil {
 		return nil, fmt.Errorf("failed to instantiate terminal renderer: %w", err)
@@ -37,8 +40,13 @@ func NewAgenticQuery(io *IO, session *agentic.ChatSession, config *config.Config
 
 // Handle processes the chat message with agentic capabilities
 func (h *AgenticQuery) Handle(message string) (Response, bool) {
+	message, attachments := mcp.ExpandReferences(message)
+	if len(attachments) > 0 {
+		h.terminal.Write(attachmentSummary(attachments))
+	}
+
 	// Check if streaming is enabled
-	if h.config.Streaming.Enabled {
+	if h.config.Get().Streaming.Enabled {
 		return h.handleStreaming(message)
 	}
 	return h.handleNonStreaming(message)
@@ -63,7 +71,7 @@ func (h *AgenticQuery) handleStreaming(message string) (Response, bool) {
 		if debugMode := os.Getenv("GOSS_DEBUG"); debugMode != "" {
 			fmt.Fprintf(os.Stderr, "[DEBUG] Stream callback: isThinking=%v, content=%.50s...\n", isThinking, content)
 		}
-		if isThinking && h.config.Streaming.ShowThinking {
+		if isThinking && h.config.Get().Streaming.ShowThinking {
 			// Show thinking tokens in a different color/style
 			h.terminal.Write("\033[90m" + content + "\033[0m") // Gray text for thinking
 		} else if !isThinking {
@@ -77,7 +85,8 @@ func (h *AgenticQuery) handleStreaming(message string) (Response, bool) {
 		fmt.Fprintf(os.Stderr, "[DEBUG] About to call SendMessageStream...\n")
 	}
 	
-	_, err := h.session.SendMessageStream(message, h.config.Streaming.ThinkingLevel, h.config.Streaming.ShowThinking, streamCallback)
+	streaming := h.config.Get().Streaming
+	_, err := h.session.SendMessageStream(message, streaming.ThinkingLevel, streaming.ShowThinking, streamCallback)
 	if err != nil {
 		if debugMode := os.Getenv("GOSS_DEBUG"); debugMode != "" {
 			fmt.Fprintf(os.Stderr, "[DEBUG] SendMessageStream returned error: %v\n", err)
@@ -102,7 +111,7 @@ func (h *AgenticQuery) handleNonStreaming(message string) (Response, bool) {
 	h.terminal.Spinner.Start()
 	defer h.terminal.Spinner.Stop()
 
-	response, err := h.session.SendMessage(message)
+	response, err := h.session.SendMessage(message, h.config.Get().Streaming.ThinkingLevel)
 	if err != nil {
 		return newErrorResponse(err), false
 	}
@@ -118,3 +127,34 @@ func (h *AgenticQuery) handleNonStreaming(message string) (Response, bool) {
 
 	return dataResponse(rendered), false
 }
+
+// attachmentSummary lists the files attached from @path references
+func attachmentSummary(attachments []mcp.Attachment) string {
+	var attached int
+	var total int64
+	for _, a := range attachments {
+		if a.Skipped == "" {
+			attached++
+			total += a.Size
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📎 Attached %d file(s), %s:\n", attached, formatSize(total))
+	for _, a := range attachments {
+		if a.Skipped != "" {
+			fmt.Fprintf(&b, "  • %s %s\n", a.Path, color.Yellow("skipped: "+a.Skipped))
+			continue
+		}
+		fmt.Fprintf(&b, "  • %s %s\n", a.Path, color.Gray(fmt.Sprintf("(%d lines, %s)", a.Lines, formatSize(a.Size))))
+	}
+	return b.String()
+}
+
+// formatSize renders a byte count for display
+func formatSize(size int64) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f KB", float64(size)/1024)
+}
@@ -3,12 +3,14 @@ package handler
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/manifoldco/promptui"
 	"github.com/vivesm/GOSS-CLI/agentic-cli/agentic"
+	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/cli"
 	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/config"
 	"github.com/vivesm/GOSS-CLI/agentic-cli/openai"
 )
@@ -36,7 +38,36 @@ func NewModelCommand(io *IO, session *agentic.ChatSession, modelName string) *Mo
 }
 
 // Handle processes model-related commands
-func (m *ModelCommand) Handle(_ string) (Response, bool) {
+func (m *ModelCommand) Handle(message string) (Response, bool) {
+	cmd, err := cli.ParseSystemCommand(message)
+	if err != nil {
+		return newErrorResponse(err), false
+	}
+
+	switch cmd.Subcommand() {
+	case "":
+		if !canPrompt() {
+			return menuUnavailable(cmd), false
+		}
+		return m.showMenu(), false
+	case "list":
+		return m.listModels(), false
+	case "set":
+		model := cmd.Tail(1)
+		if model == "" {
+			return newErrorResponse(cmd.UsageError()), false
+		}
+		return m.setModel(model), false
+	case "info":
+		return m.showModelInfo(), false
+	case "tools":
+		return m.listTools(), false
+	default:
+		return unknownSubcommand(cmd), false
+	}
+}
+
+func (m *ModelCommand) showMenu() Response {
 	items := []string{
 		"Select model",
 		"Show model information",
@@ -50,19 +81,54 @@ func (m *ModelCommand) Handle(_ string) (Response, bool) {
 
 	index, _, err := prompt.Run()
 	if err != nil {
-		return newErrorResponse(err), false
+		return newErrorResponse(err)
 	}
 
 	switch index {
 	case 0:
-		return m.selectModel(), false
+		return m.selectModel()
 	case 1:
-		return m.showModelInfo(), false
+		return m.showModelInfo()
 	case 2:
-		return m.listTools(), false
+		return m.listTools()
 	default:
-		return newErrorResponse(fmt.Errorf("invalid selection")), false
+		return newErrorResponse(fmt.Errorf("invalid selection"))
+	}
+}
+
+func (m *ModelCommand) listModels() Response {
+	models, err := m.session.ListModels()
+	if err != nil {
+		return newErrorResponse(fmt.Errorf("failed to list models: %w", err))
+	}
+
+	if len(models) == 0 {
+		return dataResponse("No models available")
+	}
+
+	current := m.session.GetModel()
+	var b strings.Builder
+	b.WriteString("Models:\n")
+	for _, model := range models {
+		marker := " "
+		if model == current {
+			marker = "*"
+		}
+		fmt.Fprintf(&b, "%s %s\n", marker, model)
+	}
+	return dataResponse(strings.TrimSuffix(b.String(), "\n"))
+}
+
+// setModel switches to the named model. Models that the server does not
+// advertise are accepted, since many servers load models on demand.
+func (m *ModelCommand) setModel(model string) Response {
+	if model == m.session.GetModel() {
+		return dataResponse(unchangedMessage)
 	}
+
+	m.session.SetModel(model)
+	m.modelName = model
+	return dataResponse(fmt.Sprintf("Selected model: %s", model))
 }
 
 func (m *ModelCommand) selectModel() Response {
@@ -99,18 +165,12 @@ func (m *ModelCommand) selectModel() Response {
 		CursorPos: selectedIndex,
 	}
 
-	index, result, err := prompt.Run()
+	_, result, err := prompt.Run()
 	if err != nil {
 		return newErrorResponse(err)
 	}
 
-	if index == selectedIndex {
-		return dataResponse(unchangedMessage)
-	}
-
-	m.session.SetModel(result)
-	m.modelName = result
-	return dataResponse(fmt.Sprintf("Selected model: %s", result))
+	return m.setModel(result)
 }
 
 func (m *ModelCommand) showModelInfo() Response {
@@ -154,23 +214,24 @@ func NewTemperatureCommand(io *IO, session *agentic.ChatSession) *TemperatureCom
 
 // Handle processes the temperature control command.
 func (tc *TemperatureCommand) Handle(message string) (Response, bool) {
-	parts := strings.Fields(message)
-	if len(parts) < 2 {
-		return tc.showTemperatureHelp(), false
+	cmd, err := cli.ParseSystemCommand(message)
+	if err != nil {
+		return newErrorResponse(err), false
 	}
 
-	subcommand := parts[1]
+	switch cmd.Subcommand() {
+	case "":
+		return tc.showTemperatureHelp(), false
 
-	switch subcommand {
 	case "show", "get":
 		return tc.showTemperature(), false
 
 	case "set":
-		if len(parts) < 3 {
+		tempStr := cmd.Arg(1)
+		if tempStr == "" {
 			return dataResponse("❌ Usage: !t set <value>\nExample: !t set 0.7"), false
 		}
 
-		tempStr := parts[2]
 		temp, err := strconv.ParseFloat(tempStr, 64)
 		if err != nil {
 			return dataResponse(fmt.Sprintf("❌ Invalid temperature value: %s\nTemperature must be a number between 0.0 and 2.0", tempStr)), false
@@ -245,13 +306,13 @@ func (tc *TemperatureCommand) getTemperatureDescription(temp float64) string {
 type HistoryCommand struct {
 	BaseCommand
 	session *agentic.ChatSession
-	config  *config.Config
+	config  *config.Store
 }
 
 var _ MessageHandler = (*HistoryCommand)(nil)
 
 // NewHistoryCommand returns a new HistoryCommand
-func NewHistoryCommand(io *IO, session *agentic.ChatSession, config *config.Config) *HistoryCommand {
+func NewHistoryCommand(io *IO, session *agentic.ChatSession, config *config.Store) *HistoryCommand {
 	return &HistoryCommand{
 		BaseCommand: NewBaseCommand(io),
 		session:     session,
@@ -260,7 +321,45 @@ func NewHistoryCommand(io *IO, session *agentic.ChatSession, config *config.Conf
 }
 
 // Handle processes history-related commands
-func (h *HistoryCommand) Handle(_ string) (Response, bool) {
+func (h *HistoryCommand) Handle(message string) (Response, bool) {
+	cmd, err := cli.ParseSystemCommand(message)
+	if err != nil {
+		return newErrorResponse(err), false
+	}
+
+	switch cmd.Subcommand() {
+	case "":
+		if !canPrompt() {
+			return menuUnavailable(cmd), false
+		}
+		return h.showMenu(), false
+	case "list":
+		return h.listHistory(), false
+	case "save":
+		return h.saveHistory(cmd.Tail(1), cmd.HasFlag("force", "f")), false
+	case "load":
+		id := cmd.Tail(1)
+		if id == "" {
+			return newErrorResponse(cmd.UsageError()), false
+		}
+		return h.loadHistoryByID(id), false
+	case "delete":
+		if cmd.HasFlag("all") {
+			return h.deleteAllHistory(cmd.HasFlag("yes", "y")), false
+		}
+		id := cmd.Tail(1)
+		if id == "" {
+			return newErrorResponse(cmd.UsageError()), false
+		}
+		return h.deleteHistory(id), false
+	case "clear":
+		return h.clearHistory(), false
+	default:
+		return unknownSubcommand(cmd), false
+	}
+}
+
+func (h *HistoryCommand) showMenu() Response {
 	items := []string{
 		"Clear history",
 		"Save history",
@@ -275,37 +374,69 @@ func (h *HistoryCommand) Handle(_ string) (Response, bool) {
 
 	index, _, err := prompt.Run()
 	if err != nil {
-		return newErrorResponse(err), false
+		return newErrorResponse(err)
 	}
 
 	switch index {
 	case 0:
-		return h.clearHistory(), false
+		return h.clearHistory()
 	case 1:
-		return h.saveHistory(), false
+		return h.saveHistory("", false)
 	case 2:
-		return h.loadHistory(), false
+		return h.loadHistory()
 	case 3:
-		return h.deleteAllHistory(), false
+		return h.deleteAllHistory(false)
 	default:
-		return newErrorResponse(fmt.Errorf("invalid selection")), false
+		return newErrorResponse(fmt.Errorf("invalid selection"))
 	}
 }
 
+// historyIDs returns the saved history ids in sorted order.
+func (h *HistoryCommand) historyIDs() []string {
+	var ids []string
+	for id := range h.config.Get().History {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (h *HistoryCommand) listHistory() Response {
+	ids := h.historyIDs()
+	if len(ids) == 0 {
+		return dataResponse("No saved history available")
+	}
+
+	var b strings.Builder
+	b.WriteString("Saved history:\n")
+	for _, id := range ids {
+		fmt.Fprintf(&b, "• %s\n", id)
+	}
+	return dataResponse(strings.TrimSuffix(b.String(), "\n"))
+}
+
 func (h *HistoryCommand) clearHistory() Response {
 	h.session.ClearHistory()
 	return dataResponse("History cleared")
 }
 
-func (h *HistoryCommand) saveHistory() Response {
+// saveHistory stores the conversation under name, or under a timestamp-based
+// key when name is empty. Existing entries are only replaced when force is set.
+func (h *HistoryCommand) saveHistory(name string, force bool) Response {
 	history := h.session.GetHistory()
 	if len(history) == 0 {
 		return dataResponse("No history to save")
 	}
 
-	// Generate a timestamp-based key
-	timestamp := time.Now().Format("2006-01-02_15-04-05")
-	key := fmt.Sprintf("session_%s", timestamp)
+	key := name
+	if key == "" {
+		// Generate a timestamp-based key
+		timestamp := time.Now().Format("2006-01-02_15-04-05")
+		key = fmt.Sprintf("session_%s", timestamp)
+	}
+	if _, exists := h.config.Get().History[key]; exists && !force {
+		return newErrorResponse(fmt.Errorf("history %q already exists; use --force to overwrite", key))
+	}
 
 	// Convert to JSON
 	historyJSON, err := json.Marshal(history)
@@ -314,13 +445,14 @@ func (h *HistoryCommand) saveHistory() Response {
 	}
 
 	// Save to configuration
-	if h.config.History == nil {
-		h.config.History = make(map[string]interface{})
+	cfg := h.config.Get()
+	if cfg.History == nil {
+		cfg.History = make(map[string]interface{})
 	}
-	h.config.History[key] = string(historyJSON)
+	cfg.History[key] = string(historyJSON)
 
 	// Write configuration
-	if err := h.config.Save(); err != nil {
+	if err := cfg.Save(); err != nil {
 		return newErrorResponse(fmt.Errorf("failed to save history: %w", err))
 	}
 
@@ -328,19 +460,14 @@ func (h *HistoryCommand) saveHistory() Response {
 }
 
 func (h *HistoryCommand) loadHistory() Response {
-	if h.config.History == nil || len(h.config.History) == 0 {
+	ids := h.historyIDs()
+	if len(ids) == 0 {
 		return dataResponse("No saved history available")
 	}
 
-	// Get list of saved histories
-	var keys []string
-	for key := range h.config.History {
-		keys = append(keys, key)
-	}
-
 	prompt := promptui.Select{
 		Label: "Select history to load",
-		Items: keys,
+		Items: ids,
 	}
 
 	_, selectedKey, err := prompt.Run()
@@ -348,10 +475,14 @@ func (h *HistoryCommand) loadHistory() Response {
 		return newErrorResponse(err)
 	}
 
+	return h.loadHistoryByID(selectedKey)
+}
+
+func (h *HistoryCommand) loadHistoryByID(id string) Response {
 	// Load the selected history
-	historyData, exists := h.config.History[selectedKey]
+	historyData, exists := h.config.Get().History[id]
 	if !exists {
-		return newErrorResponse(fmt.Errorf("history not found: %s", selectedKey))
+		return newErrorResponse(fmt.Errorf("history not found: %s", id))
 	}
 
 	historyJSON, ok := historyData.(string)
@@ -367,32 +498,55 @@ func (h *HistoryCommand) loadHistory() Response {
 	// Set the loaded history
 	h.session.SetHistory(history)
 
-	return dataResponse(fmt.Sprintf("History loaded: %s (%d messages)", selectedKey, len(history)))
+	return dataResponse(fmt.Sprintf("History loaded: %s (%d messages)", id, len(history)))
 }
 
-func (h *HistoryCommand) deleteAllHistory() Response {
-	if h.config.History == nil || len(h.config.History) == 0 {
-		return dataResponse("No history records to delete")
+func (h *HistoryCommand) deleteHistory(id string) Response {
+	cfg := h.config.Get()
+	if _, exists := cfg.History[id]; !exists {
+		return newErrorResponse(fmt.Errorf("history not found: %s", id))
 	}
 
-	count := len(h.config.History)
+	delete(cfg.History, id)
+	if err := cfg.Save(); err != nil {
+		return newErrorResponse(fmt.Errorf("failed to delete history: %w", err))
+	}
+
+	return dataResponse(fmt.Sprintf("Deleted history: %s", id))
+}
 
-	// Confirm deletion
-	prompt := promptui.Prompt{
-		Label:     fmt.Sprintf("Delete all %d history records? (y/N)", count),
-		IsConfirm: true,
+// deleteAllHistory removes every saved history record. Without confirmed
+// it asks for confirmation, which requires a terminal.
+func (h *HistoryCommand) deleteAllHistory(confirmed bool) Response {
+	cfg := h.config.Get()
+	if cfg.History == nil || len(cfg.History) == 0 {
+		return dataResponse("No history records to delete")
 	}
 
-	result, err := prompt.Run()
-	if err != nil || result != "y" {
-		return dataResponse("Deletion cancelled")
+	count := len(cfg.History)
+
+	if !confirmed {
+		if !canPrompt() {
+			return newErrorResponse(fmt.Errorf("refusing to delete %d history records without confirmation; use !h delete --all --yes", count))
+		}
+
+		// Confirm deletion
+		prompt := promptui.Prompt{
+			Label:     fmt.Sprintf("Delete all %d history records? (y/N)", count),
+			IsConfirm: true,
+		}
+
+		result, err := prompt.Run()
+		if err != nil || result != "y" {
+			return dataResponse("Deletion cancelled")
+		}
 	}
 
 	// Clear history
-	h.config.History = make(map[string]interface{})
+	cfg.History = make(map[string]interface{})
 
 	// Write configuration
-	if err := h.config.Save(); err != nil {
+	if err := cfg.Save(); err != nil {
 		return newErrorResponse(fmt.Errorf("failed to delete history: %w", err))
 	}
 
@@ -406,13 +560,13 @@ func (h *HistoryCommand) deleteAllHistory() Response {
 // StreamCommand handles streaming toggle operations
 type StreamCommand struct {
 	BaseCommand
-	config *config.Config
+	config *config.Store
 }
 
 var _ MessageHandler = (*StreamCommand)(nil)
 
 // NewStreamCommand returns a new StreamCommand
-func NewStreamCommand(io *IO, config *config.Config) *StreamCommand {
+func NewStreamCommand(io *IO, config *config.Store) *StreamCommand {
 	return &StreamCommand{
 		BaseCommand: NewBaseCommand(io),
 		config:      config,
@@ -420,17 +574,26 @@ func NewStreamCommand(io *IO, config *config.Config) *StreamCommand {
 }
 
 // Handle processes the streaming toggle command
-func (s *StreamCommand) Handle(_ string) (Response, bool) {
-	// Toggle streaming
-	s.config.Streaming.Enabled = !s.config.Streaming.Enabled
+func (s *StreamCommand) Handle(message string) (Response, bool) {
+	cmd, err := cli.ParseSystemCommand(message)
+	if err != nil {
+		return newErrorResponse(err), false
+	}
+
+	cfg := s.config.Get()
+	enabled, err := parseSwitch(cmd, cfg.Streaming.Enabled)
+	if err != nil {
+		return newErrorResponse(err), false
+	}
+	cfg.Streaming.Enabled = enabled
 	
 	// Save configuration
-	if err := s.config.Save(); err != nil {
+	if err := cfg.Save(); err != nil {
 		return newErrorResponse(fmt.Errorf("failed to save streaming setting: %w", err)), false
 	}
 	
 	status := "disabled"
-	if s.config.Streaming.Enabled {
+	if cfg.Streaming.Enabled {
 		status = "enabled"
 	}
 	
@@ -440,44 +603,63 @@ func (s *StreamCommand) Handle(_ string) (Response, bool) {
 // ThinkingCommand handles thinking level operations
 type ThinkingCommand struct {
 	BaseCommand
-	config *config.Config
+	session *agentic.ChatSession
+	config  *config.Store
 }
 
 var _ MessageHandler = (*ThinkingCommand)(nil)
 
 // NewThinkingCommand returns a new ThinkingCommand
-func NewThinkingCommand(io *IO, config *config.Config) *ThinkingCommand {
+func NewThinkingCommand(io *IO, session *agentic.ChatSession, config *config.Store) *ThinkingCommand {
 	return &ThinkingCommand{
 		BaseCommand: NewBaseCommand(io),
+		session:     session,
 		config:      config,
 	}
 }
 
 // Handle processes the thinking level command
 func (t *ThinkingCommand) Handle(input string) (Response, bool) {
-	// Parse input to extract level argument
-	parts := strings.Fields(strings.TrimPrefix(input, "!thinking"))
-	
-	if len(parts) == 0 {
+	cmd, err := cli.ParseSystemCommand(input)
+	if err != nil {
+		return newErrorResponse(err), false
+	}
+
+	level := cmd.Subcommand()
+	if level == "" {
 		// No argument provided, show interactive menu
+		if !canPrompt() {
+			return menuUnavailable(cmd), false
+		}
 		return t.showThinkingMenu(), false
 	}
+	if level == "last" {
+		return t.showLastReasoning(), false
+	}
 	
 	// Argument provided, set directly
-	level := strings.ToLower(parts[0])
 	return t.setThinkingLevel(level), false
 }
 
+// showLastReasoning prints the reasoning stored with the last reply, which
+// is kept even when thinking tokens are hidden
+func (t *ThinkingCommand) showLastReasoning() Response {
+	reasoning := t.session.LastReasoning()
+	if reasoning == "" {
+		return dataResponse("The last reply has no reasoning")
+	}
+	return dataResponse(reasoning)
+}
+
 func (t *ThinkingCommand) showThinkingMenu() Response {
-	items := []string{
-		"off - No thinking tokens",
-		"low - Minimal thinking (~50 tokens)",
-		"med - Standard thinking (~200 tokens)",
-		"high - Detailed thinking (~500 tokens)",
+	levels := []string{"off", "low", "med", "high"}
+	items := make([]string, len(levels))
+	for i, level := range levels {
+		items[i] = level + " - " + describeThinkingLevel(level)
 	}
 	
 	prompt := promptui.Select{
-		Label: fmt.Sprintf("Current thinking level: %s. Select new level", t.config.Streaming.ThinkingLevel),
+		Label: fmt.Sprintf("Current thinking level: %s. Select new level", t.config.Get().Streaming.ThinkingLevel),
 		Items: items,
 	}
 	
@@ -486,7 +668,6 @@ func (t *ThinkingCommand) showThinkingMenu() Response {
 		return newErrorResponse(err)
 	}
 	
-	levels := []string{"off", "low", "med", "high"}
 	return t.setThinkingLevel(levels[index])
 }
 
@@ -498,33 +679,43 @@ func (t *ThinkingCommand) setThinkingLevel(level string) Response {
 	}
 	
 	// Set level
-	t.config.Streaming.ThinkingLevel = level
+	cfg := t.config.Get()
+	cfg.Streaming.ThinkingLevel = level
 	
 	// Save configuration
-	if err := t.config.Save(); err != nil {
+	if err := cfg.Save(); err != nil {
 		return newErrorResponse(fmt.Errorf("failed to save thinking level: %w", err))
 	}
 	
-	descriptions := map[string]string{
-		"off":  "No thinking tokens",
-		"low":  "Minimal thinking (~50 tokens)",
-		"med":  "Standard thinking (~200 tokens)",
-		"high": "Detailed thinking (~500 tokens)",
+	return dataResponse(fmt.Sprintf("Thinking level: %s - %s", level, describeThinkingLevel(level)))
+}
+
+// thinkingDescriptions describes each thinking level
+var thinkingDescriptions = map[string]string{
+	"off":  "No thinking tokens",
+	"low":  "Minimal thinking",
+	"med":  "Standard thinking",
+	"high": "Detailed thinking",
+}
+
+// describeThinkingLevel describes a thinking level with its token budget
+func describeThinkingLevel(level string) string {
+	if level == "off" {
+		return thinkingDescriptions[level]
 	}
-	
-	return dataResponse(fmt.Sprintf("Thinking level: %s - %s", level, descriptions[level]))
+	return fmt.Sprintf("%s (~%d tokens)", thinkingDescriptions[level], openai.GetThinkingBudget(level))
 }
 
 // ShowThinkingCommand handles thinking visibility toggle
 type ShowThinkingCommand struct {
 	BaseCommand
-	config *config.Config
+	config *config.Store
 }
 
 var _ MessageHandler = (*ShowThinkingCommand)(nil)
 
 // NewShowThinkingCommand returns a new ShowThinkingCommand
-func NewShowThinkingCommand(io *IO, config *config.Config) *ShowThinkingCommand {
+func NewShowThinkingCommand(io *IO, config *config.Store) *ShowThinkingCommand {
 	return &ShowThinkingCommand{
 		BaseCommand: NewBaseCommand(io),
 		config:      config,
@@ -532,19 +723,43 @@ func NewShowThinkingCommand(io *IO, config *config.Config) *ShowThinkingCommand
 }
 
 // Handle processes the show thinking toggle command
-func (s *ShowThinkingCommand) Handle(_ string) (Response, bool) {
-	// Toggle show thinking
-	s.config.Streaming.ShowThinking = !s.config.Streaming.ShowThinking
+func (s *ShowThinkingCommand) Handle(message string) (Response, bool) {
+	cmd, err := cli.ParseSystemCommand(message)
+	if err != nil {
+		return newErrorResponse(err), false
+	}
+
+	cfg := s.config.Get()
+	show, err := parseSwitch(cmd, cfg.Streaming.ShowThinking)
+	if err != nil {
+		return newErrorResponse(err), false
+	}
+	cfg.Streaming.ShowThinking = show
 	
 	// Save configuration
-	if err := s.config.Save(); err != nil {
+	if err := cfg.Save(); err != nil {
 		return newErrorResponse(fmt.Errorf("failed to save show thinking setting: %w", err)), false
 	}
 	
 	status := "hidden"
-	if s.config.Streaming.ShowThinking {
+	if cfg.Streaming.ShowThinking {
 		status = "visible"
 	}
 	
 	return dataResponse(fmt.Sprintf("Thinking tokens: %s", status)), false
 }
+
+// parseSwitch interprets the on/off/toggle subcommand of a boolean setting.
+// No subcommand toggles the current value.
+func parseSwitch(cmd *cli.Command, current bool) (bool, error) {
+	switch cmd.Subcommand() {
+	case "", "toggle":
+		return !current, nil
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	default:
+		return false, fmt.Errorf("unknown subcommand %q; %w", cmd.Arg(0), cmd.UsageError())
+	}
+}
@@ -16,7 +16,7 @@ type System struct {
 var _ MessageHandler = (*System)(nil)
 
 // NewSystem returns a new System command handler.
-func NewSystem(io *IO, session *agentic.ChatSession, configuration *config.Config,
+func NewSystem(io *IO, session *agentic.ChatSession, configuration *config.Store,
 	modelName string, rendererOptions RendererOptions) (*System, error) {
 	helpCommandHandler, err := NewHelpCommand(io, rendererOptions)
 	if err != nil {
@@ -32,8 +32,11 @@ func NewSystem(io *IO, session *agentic.ChatSession, configuration *config.Confi
 		cli.SystemCmdHistory:         NewHistoryCommand(io, session, configuration),
 		cli.SystemCmdTemperature:     NewTemperatureCommand(io, session),
 		cli.SystemCmdStream:          NewStreamCommand(io, configuration),
-		cli.SystemCmdThinking:        NewThinkingCommand(io, configuration),
+		cli.SystemCmdThinking:        NewThinkingCommand(io, session, configuration),
 		cli.SystemCmdShowThinking:    NewShowThinkingCommand(io, configuration),
+		cli.SystemCmdTools:           NewToolsCommand(io, session, configuration),
+		cli.SystemCmdCache:           NewCacheCommand(io),
+		cli.SystemCmdLimits:          NewLimitsCommand(io),
 	}
 
 	return &System{
@@ -44,11 +47,11 @@ func NewSystem(io *IO, session *agentic.ChatSession, configuration *config.Confi
 
 // Handle processes the chat system command.
 func (s *System) Handle(message string) (Response, bool) {
-	commandName, err := cli.ExtractSystemCommandName(message)
+	cmd, err := cli.ParseSystemCommand(message)
 	if err != nil {
 		return newErrorResponse(err), false
 	}
-	handler, found := s.handlers[commandName]
+	handler, found := s.handlers[cmd.Name]
 	if !found {
 		return newErrorResponse(cli.ErrInvalidSystemCommand), false
 	}
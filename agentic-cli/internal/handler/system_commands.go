@@ -38,15 +38,20 @@ func (h *HelpCommand) Handle(_ string) (Response, bool) {
 	var b strings.Builder
 	b.WriteString("# System commands\n")
 	b.WriteString("Use a command prefixed with an exclamation mark (e.g., `!h`).\n")
-	fmt.Fprintf(&b, "* `%s` - Select the generative model system prompt.\n", cli.SystemCmdSelectPrompt)
-	fmt.Fprintf(&b, "* `%s` - Select from a list of generative model operations.\n", cli.SystemCmdModel)
-	fmt.Fprintf(&b, "* `%s` - Select from a list of chat history operations.\n", cli.SystemCmdHistory)
-	fmt.Fprintf(&b, "* `%s` - Control temperature settings (focus vs creativity).\n", cli.SystemCmdTemperature)
-	fmt.Fprintf(&b, "* `%s` - Toggle streaming responses on/off.\n", cli.SystemCmdStream)
-	fmt.Fprintf(&b, "* `%s [level]` - Set thinking level (off/low/med/high).\n", cli.SystemCmdThinking)
-	fmt.Fprintf(&b, "* `%s` - Toggle thinking token visibility.\n", cli.SystemCmdShowThinking)
-	fmt.Fprintf(&b, "* `%s` - Toggle the input mode.\n", cli.SystemCmdSelectInputMode)
-	fmt.Fprintf(&b, "* `%s` - Exit the application.\n", cli.SystemCmdQuit)
+	b.WriteString("Commands that open a menu also accept arguments, so they work in scripts and pipes.\n")
+	fmt.Fprintf(&b, "* `%s` - Select the generative model system prompt.\n", cli.Usage[cli.SystemCmdSelectPrompt])
+	fmt.Fprintf(&b, "* `%s` - Select from a list of generative model operations.\n", cli.Usage[cli.SystemCmdModel])
+	fmt.Fprintf(&b, "* `%s` - Select from a list of chat history operations.\n", cli.Usage[cli.SystemCmdHistory])
+	fmt.Fprintf(&b, "* `%s` - Control temperature settings (focus vs creativity).\n", cli.Usage[cli.SystemCmdTemperature])
+	fmt.Fprintf(&b, "* `%s` - Toggle streaming responses on/off.\n", cli.Usage[cli.SystemCmdStream])
+	fmt.Fprintf(&b, "* `%s` - Set thinking level, or show the reasoning behind the last reply.\n", cli.Usage[cli.SystemCmdThinking])
+	fmt.Fprintf(&b, "* `%s` - Toggle thinking token visibility.\n", cli.Usage[cli.SystemCmdShowThinking])
+	fmt.Fprintf(&b, "* `%s` - List, enable and disable tools, or switch to a tool profile.\n", cli.Usage[cli.SystemCmdTools])
+	fmt.Fprintf(&b, "* `%s` - Show or clear the web search cache.\n", cli.Usage[cli.SystemCmdCache])
+	fmt.Fprintf(&b, "* `%s` - Show tool rate limits and quota usage.\n", cli.Usage[cli.SystemCmdLimits])
+	fmt.Fprintf(&b, "* `%s` - Toggle the input mode.\n", cli.Usage[cli.SystemCmdSelectInputMode])
+	fmt.Fprintf(&b, "* `%s` - Exit the application.\n", cli.Usage[cli.SystemCmdQuit])
+	b.WriteString("\nQuote arguments containing spaces, e.g. `!h load \"my session\"`.\n")
 
 	rendered, err := h.renderer.Render(b.String())
 	if err != nil {
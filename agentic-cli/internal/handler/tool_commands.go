@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vivesm/GOSS-CLI/agentic-cli/agentic"
+	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/cli"
+	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/config"
+	"github.com/vivesm/GOSS-CLI/agentic-cli/mcp"
+)
+
+// =============================================================================
+// TOOLS COMMAND
+// =============================================================================
+
+// ToolsCommand lists the session's tools, enables and disables them, and
+// switches between tool profiles
+type ToolsCommand struct {
+	BaseCommand
+	session       *agentic.ChatSession
+	configuration *config.Store
+}
+
+var _ MessageHandler = (*ToolsCommand)(nil)
+
+// NewToolsCommand returns a new ToolsCommand
+func NewToolsCommand(io *IO, session *agentic.ChatSession, configuration *config.Store) *ToolsCommand {
+	return &ToolsCommand{
+		BaseCommand:   NewBaseCommand(io),
+		session:       session,
+		configuration: configuration,
+	}
+}
+
+// Handle processes the tools command
+func (t *ToolsCommand) Handle(message string) (Response, bool) {
+	cmd, err := cli.ParseSystemCommand(message)
+	if err != nil {
+		return newErrorResponse(err), false
+	}
+
+	switch cmd.Subcommand() {
+	case "", "list":
+		return t.listTools(), false
+	case "enable", "disable":
+		if len(cmd.Args) < 2 {
+			return newErrorResponse(cmd.UsageError()), false
+		}
+		names, err := mcp.ResolveTools(strings.Join(cmd.Args[1:], ","), t.configuration.Get().Tools.Profiles)
+		if err != nil {
+			return newErrorResponse(err), false
+		}
+		enable := cmd.Subcommand() == "enable"
+		if err := t.session.EnableTools(enable, names...); err != nil {
+			return newErrorResponse(err), false
+		}
+		verb := "Disabled"
+		if enable {
+			verb = "Enabled"
+		}
+		return dataResponse(fmt.Sprintf("%s %s", verb, strings.Join(names, ", "))), false
+	case "profile":
+		if cmd.Arg(1) == "" {
+			return t.listProfiles(), false
+		}
+		names, err := mcp.ResolveTools(cmd.Tail(1), t.configuration.Get().Tools.Profiles)
+		if err != nil {
+			return newErrorResponse(err), false
+		}
+		if err := t.session.UseTools(names); err != nil {
+			return newErrorResponse(err), false
+		}
+		return dataResponse(fmt.Sprintf("Switched to %s: %d tools enabled", cmd.Tail(1), len(names))), false
+	default:
+		return unknownSubcommand(cmd), false
+	}
+}
+
+func (t *ToolsCommand) listTools() Response {
+	tools := t.session.Tools()
+	enabled := 0
+	var b strings.Builder
+	b.WriteString("Tools:")
+	for _, tool := range tools {
+		mark := "[ ]"
+		if tool.Enabled {
+			mark = "[x]"
+			enabled++
+		}
+		fmt.Fprintf(&b, "\n  %s %s", mark, tool.Name)
+		if tool.ReadOnly {
+			b.WriteString(" (read-only)")
+		}
+	}
+	fmt.Fprintf(&b, "\n%d of %d tools enabled. Use !tools enable|disable <tool> or !tools profile <name> to change them.",
+		enabled, len(tools))
+	return dataResponse(b.String())
+}
+
+func (t *ToolsCommand) listProfiles() Response {
+	profiles := mcp.ToolProfiles(t.configuration.Get().Tools.Profiles)
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("Tool profiles:")
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n  %s: %s", name, strings.Join(profiles[name], ", "))
+	}
+	return dataResponse(b.String())
+}
+
+// =============================================================================
+// CACHE COMMAND
+// =============================================================================
+
+// CacheCommand shows and clears the web search cache
+type CacheCommand struct {
+	BaseCommand
+}
+
+var _ MessageHandler = (*CacheCommand)(nil)
+
+// NewCacheCommand returns a new CacheCommand
+func NewCacheCommand(io *IO) *CacheCommand {
+	return &CacheCommand{
+		BaseCommand: NewBaseCommand(io),
+	}
+}
+
+// Handle processes the cache command
+func (c *CacheCommand) Handle(message string) (Response, bool) {
+	cmd, err := cli.ParseSystemCommand(message)
+	if err != nil {
+		return newErrorResponse(err), false
+	}
+
+	cache := mcp.CurrentSearchCache()
+	if cache == nil {
+		return dataResponse("The web search cache is disabled (no data directory is available)."), false
+	}
+
+	switch cmd.Subcommand() {
+	case "", "stats":
+		return c.showStats(cache), false
+	case "clear":
+		removed, err := cache.Clear()
+		if err != nil {
+			return newErrorResponse(err), false
+		}
+		return dataResponse(fmt.Sprintf("Removed %d cached searches", removed)), false
+	default:
+		return unknownSubcommand(cmd), false
+	}
+}
+
+func (c *CacheCommand) showStats(cache *mcp.SearchCache) Response {
+	stats, err := cache.Stats()
+	if err != nil {
+		return newErrorResponse(err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Search cache: %s\n", stats.Dir)
+	fmt.Fprintf(&b, "Entries: %d (%d expired, %.1f KB)\n", stats.Entries, stats.Expired, float64(stats.Bytes)/1024)
+	fmt.Fprintf(&b, "TTL: %s\n", stats.TTL)
+	fmt.Fprintf(&b, "This session: %d hits, %d misses\n", stats.Hits, stats.Misses)
+	if mcp.Offline() {
+		b.WriteString("Offline mode: web_search answers only from the cache")
+	} else {
+		b.WriteString("Offline mode: off (start goss with --offline to use only cached results)")
+	}
+	return dataResponse(b.String())
+}
+
+// =============================================================================
+// LIMITS COMMAND
+// =============================================================================
+
+// LimitsCommand shows the tool rate limits and quota usage
+type LimitsCommand struct {
+	BaseCommand
+}
+
+var _ MessageHandler = (*LimitsCommand)(nil)
+
+// NewLimitsCommand returns a new LimitsCommand
+func NewLimitsCommand(io *IO) *LimitsCommand {
+	return &LimitsCommand{
+		BaseCommand: NewBaseCommand(io),
+	}
+}
+
+// Handle processes the limits command
+func (l *LimitsCommand) Handle(message string) (Response, bool) {
+	cmd, err := cli.ParseSystemCommand(message)
+	if err != nil {
+		return newErrorResponse(err), false
+	}
+	if cmd.Subcommand() != "" {
+		return unknownSubcommand(cmd), false
+	}
+
+	statuses := mcp.CurrentToolLimiter().Status()
+	if len(statuses) == 0 {
+		return dataResponse("No tool limits configured. Add them to Tools.limits in your configuration file."), false
+	}
+
+	var b strings.Builder
+	b.WriteString("Tool limits:")
+	for _, status := range statuses {
+		var parts []string
+		if status.Limit.RatePerMinute > 0 {
+			parts = append(parts, fmt.Sprintf("%g/min (%d available now)", status.Limit.RatePerMinute, status.Available))
+		}
+		if status.Limit.SessionQuota > 0 {
+			parts = append(parts, fmt.Sprintf("session %d/%d", status.SessionCalls, status.Limit.SessionQuota))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d calls this session", status.SessionCalls))
+		}
+		if status.Limit.DailyQuota > 0 {
+			parts = append(parts, fmt.Sprintf("today %d/%d", status.DailyCalls, status.Limit.DailyQuota))
+		}
+		fmt.Fprintf(&b, "\n  %s: %s", status.Tool, strings.Join(parts, ", "))
+	}
+	return dataResponse(b.String()), false
+}
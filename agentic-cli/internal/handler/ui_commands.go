@@ -2,9 +2,12 @@ package handler
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/manifoldco/promptui"
 	"github.com/vivesm/GOSS-CLI/agentic-cli/agentic"
+	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/cli"
 	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/config"
 )
 
@@ -21,13 +24,13 @@ var inputModeOptions = []string{
 type PromptCommand struct {
 	BaseCommand
 	session *agentic.ChatSession
-	config  *config.Config
+	config  *config.Store
 }
 
 var _ MessageHandler = (*PromptCommand)(nil)
 
 // NewPromptCommand returns a new PromptCommand
-func NewPromptCommand(io *IO, session *agentic.ChatSession, config *config.Config) *PromptCommand {
+func NewPromptCommand(io *IO, session *agentic.ChatSession, config *config.Store) *PromptCommand {
 	return &PromptCommand{
 		BaseCommand: NewBaseCommand(io),
 		session:     session,
@@ -36,30 +39,95 @@ func NewPromptCommand(io *IO, session *agentic.ChatSession, config *config.Confi
 }
 
 // Handle processes system prompt commands
-func (p *PromptCommand) Handle(_ string) (Response, bool) {
-	if p.config.SystemPrompts == nil || len(p.config.SystemPrompts) == 0 {
+func (p *PromptCommand) Handle(message string) (Response, bool) {
+	cmd, err := cli.ParseSystemCommand(message)
+	if err != nil {
+		return newErrorResponse(err), false
+	}
+
+	cfg := p.config.Get()
+	if cfg.SystemPrompts == nil || len(cfg.SystemPrompts) == 0 {
 		return dataResponse("No system prompts configured. Add them to your configuration file."), false
 	}
 
-	var prompts []string
-	var promptMap = make(map[string]string)
+	switch cmd.Subcommand() {
+	case "":
+		if !canPrompt() {
+			return menuUnavailable(cmd), false
+		}
+		return p.selectPrompt(), false
+	case "list":
+		return p.listPrompts(), false
+	case "use":
+		name := cmd.Tail(1)
+		if name == "" {
+			return newErrorResponse(cmd.UsageError()), false
+		}
+		return p.usePrompt(name), false
+	case "show":
+		name := cmd.Tail(1)
+		if name == "" {
+			return newErrorResponse(cmd.UsageError()), false
+		}
+		key, prompt, ok := p.lookupPrompt(name)
+		if !ok {
+			return newErrorResponse(fmt.Errorf("unknown system prompt %q", name)), false
+		}
+		return dataResponse(fmt.Sprintf("%s:\n\n%s", key, prompt)), false
+	default:
+		return unknownSubcommand(cmd), false
+	}
+}
+
+// promptNames returns the configured system prompt names in sorted order.
+func (p *PromptCommand) promptNames() []string {
+	var names []string
+	for name := range p.config.Get().SystemPrompts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupPrompt finds a system prompt by name, ignoring case, and returns
+// its configured name along with the prompt text.
+func (p *PromptCommand) lookupPrompt(name string) (string, string, bool) {
+	for key, prompt := range p.config.Get().SystemPrompts {
+		if strings.EqualFold(key, name) {
+			return key, prompt, true
+		}
+	}
+	return "", "", false
+}
 
-	for name, prompt := range p.config.SystemPrompts {
-		prompts = append(prompts, name)
-		promptMap[name] = prompt
+func (p *PromptCommand) listPrompts() Response {
+	var b strings.Builder
+	b.WriteString("System prompts:\n")
+	for _, name := range p.promptNames() {
+		fmt.Fprintf(&b, "• %s\n", name)
 	}
+	return dataResponse(strings.TrimSuffix(b.String(), "\n"))
+}
 
+func (p *PromptCommand) selectPrompt() Response {
 	prompt := promptui.Select{
 		Label: "Select system prompt",
-		Items: prompts,
+		Items: p.promptNames(),
 	}
 
 	_, result, err := prompt.Run()
 	if err != nil {
-		return newErrorResponse(err), false
+		return newErrorResponse(err)
 	}
 
-	selectedPrompt := promptMap[result]
+	return p.usePrompt(result)
+}
+
+func (p *PromptCommand) usePrompt(name string) Response {
+	key, selectedPrompt, ok := p.lookupPrompt(name)
+	if !ok {
+		return newErrorResponse(fmt.Errorf("unknown system prompt %q", name))
+	}
 
 	// For agentic sessions, we add the system prompt as a system message
 	// Clear history and start with system prompt
@@ -67,7 +135,7 @@ func (p *PromptCommand) Handle(_ string) (Response, bool) {
 
 	// Note: In a real implementation, you might want to implement system message support
 	// For now, we'll just inform the user that the prompt was selected
-	return dataResponse(fmt.Sprintf("System prompt selected: %s\n\nPrompt: %s\n\nNote: System prompts are informational only in this implementation.", result, selectedPrompt)), false
+	return dataResponse(fmt.Sprintf("System prompt selected: %s\n\nPrompt: %s\n\nNote: System prompts are informational only in this implementation.", key, selectedPrompt))
 }
 
 // =============================================================================
@@ -90,13 +158,31 @@ func NewInputModeCommand(io *IO) *InputModeCommand {
 }
 
 // Handle processes the chat input mode system command.
-func (h *InputModeCommand) Handle(_ string) (Response, bool) {
-	defer h.IO.terminal.Write(h.IO.terminalPrompt)
-	multiline, err := h.selectInputMode()
+func (h *InputModeCommand) Handle(message string) (Response, bool) {
+	cmd, err := cli.ParseSystemCommand(message)
 	if err != nil {
 		return newErrorResponse(err), false
 	}
 
+	var multiline bool
+	switch cmd.Subcommand() {
+	case "":
+		if !canPrompt() {
+			return menuUnavailable(cmd), false
+		}
+		defer h.IO.terminal.Write(h.IO.terminalPrompt)
+		multiline, err = h.selectInputMode()
+		if err != nil {
+			return newErrorResponse(err), false
+		}
+	case "single":
+		multiline = false
+	case "multi":
+		multiline = true
+	default:
+		return unknownSubcommand(cmd), false
+	}
+
 	if h.IO.terminal.Config.Multiline == multiline {
 		// the same input mode is selected
 		return dataResponse(unchangedMessage), false
@@ -104,13 +190,6 @@ func (h *InputModeCommand) Handle(_ string) (Response, bool) {
 
 	h.IO.terminal.Config.Multiline = multiline
 	h.IO.terminal.SetUserPrompt()
-	if h.IO.terminal.Config.Multiline {
-		// disable history for multi-line messages since it is
-		// unusable for future requests
-		h.IO.terminal.Reader.HistoryDisable()
-	} else {
-		h.IO.terminal.Reader.HistoryEnable()
-	}
 
 	mode := inputModeOptions[modeIndex(h.IO.terminal.Config.Multiline)]
 	return dataResponse(fmt.Sprintf("Switched to %q input mode.", mode)), false
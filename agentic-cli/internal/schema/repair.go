@@ -0,0 +1,140 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Repair fixes values that a model commonly sends with the wrong type but
+// an unambiguous meaning: numbers and booleans quoted as strings, numbers
+// for strings, JSON encoded inside a string, a single value where an array
+// is expected and null for optional properties. It returns the repaired
+// instance, which may share structure with the input, and one note per
+// change. Values that cannot be repaired are left for Validate to report.
+func Repair(schema map[string]interface{}, instance interface{}) (interface{}, []string) {
+	r := &repairer{validator: validator{root: schema}}
+	return r.repair(schema, instance, ""), r.notes
+}
+
+type repairer struct {
+	validator
+	notes []string
+}
+
+func (r *repairer) note(path, format string, args ...interface{}) {
+	if path == "" {
+		path = "/"
+	}
+	r.notes = append(r.notes, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (r *repairer) repair(s map[string]interface{}, inst interface{}, path string) interface{} {
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := r.resolve(ref)
+		if err != nil {
+			return inst
+		}
+		s = target
+	}
+
+	if t, ok := s["type"]; ok && !matchesType(t, inst) {
+		for _, name := range toStrings(t) {
+			if fixed, ok := convert(name, inst); ok && isType(name, fixed) {
+				r.note(path, "converted %s %s to %s", typeOf(inst), brief(inst), name)
+				inst = fixed
+				break
+			}
+		}
+	}
+
+	switch val := inst.(type) {
+	case map[string]interface{}:
+		props, _ := s["properties"].(map[string]interface{})
+		required := make(map[string]bool)
+		for _, name := range toStrings(s["required"]) {
+			required[name] = true
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			child := val[key]
+			ps, ok := props[key].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			childPath := path + Pointer(key)
+			if t, typed := ps["type"]; child == nil && typed && !required[key] && !matchesType(t, nil) {
+				r.note(childPath, "dropped null for an optional property")
+				delete(val, key)
+				continue
+			}
+			val[key] = r.repair(ps, child, childPath)
+		}
+	case []interface{}:
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			for i, item := range val {
+				val[i] = r.repair(items, item, fmt.Sprintf("%s/%d", path, i))
+			}
+		}
+	}
+	return inst
+}
+
+// convert returns inst as the named type when the conversion loses nothing
+func convert(name string, inst interface{}) (interface{}, bool) {
+	str, isString := inst.(string)
+	trimmed := strings.TrimSpace(str)
+	switch name {
+	case "number", "integer":
+		if isString {
+			n, err := strconv.ParseFloat(trimmed, 64)
+			return n, err == nil
+		}
+	case "boolean":
+		if isString {
+			b, err := strconv.ParseBool(strings.ToLower(trimmed))
+			return b, err == nil
+		}
+	case "string":
+		switch v := inst.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		case bool:
+			return strconv.FormatBool(v), true
+		}
+	case "object":
+		if isString && strings.HasPrefix(trimmed, "{") {
+			var obj map[string]interface{}
+			err := json.Unmarshal([]byte(trimmed), &obj)
+			return obj, err == nil
+		}
+	case "array":
+		if isString && strings.HasPrefix(trimmed, "[") {
+			var arr []interface{}
+			err := json.Unmarshal([]byte(trimmed), &arr)
+			return arr, err == nil
+		}
+		if inst != nil {
+			return []interface{}{inst}, true
+		}
+	}
+	return nil, false
+}
+
+// brief formats a value for a repair note
+func brief(inst interface{}) string {
+	if s, ok := inst.(string); ok {
+		if len(s) > 40 {
+			s = s[:37] + "..."
+		}
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(inst)
+}
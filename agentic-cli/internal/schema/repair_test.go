@@ -0,0 +1,50 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRepair(t *testing.T) {
+	s := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"count":     map[string]interface{}{"type": "integer"},
+			"recursive": map[string]interface{}{"type": "boolean"},
+			"query":     map[string]interface{}{"type": "string"},
+			"include":   map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"options":   map[string]interface{}{"type": "object"},
+			"limit":     map[string]interface{}{"type": "number"},
+			"ratio":     map[string]interface{}{"type": "integer"},
+		},
+		"required": []string{"query"},
+	}
+	args := map[string]interface{}{
+		"count":     "5",
+		"recursive": "True",
+		"query":     float64(2024),
+		"include":   "*.go",
+		"options":   `{"deep": true}`,
+		"limit":     nil,
+		"ratio":     "0.5",
+	}
+
+	repaired, notes := Repair(s, args)
+	want := map[string]interface{}{
+		"count":     float64(5),
+		"recursive": true,
+		"query":     "2024",
+		"include":   []interface{}{"*.go"},
+		"options":   map[string]interface{}{"deep": true},
+		"ratio":     "0.5",
+	}
+	if !reflect.DeepEqual(repaired, want) {
+		t.Errorf("Unexpected repair result %#v", repaired)
+	}
+	if len(notes) != 6 || notes[0] != `/count: converted string "5" to integer` {
+		t.Errorf("Unexpected notes %q", notes)
+	}
+	if errs := Validate(s, repaired); len(errs) != 1 || errs[0].Path != "/ratio" {
+		t.Errorf("Expected only the fractional integer to remain invalid, got %v", errs)
+	}
+}
@@ -0,0 +1,350 @@
+// Package schema implements the subset of JSON Schema used by goss for
+// configuration files and tool parameters.
+//
+// Supported keywords: type, properties, required, additionalProperties,
+// propertyNames, minProperties, items, minItems, maxItems, enum, minimum,
+// maximum, minLength, maxLength, pattern and local $ref into $defs.
+// Unknown keywords (description, default, title, ...) are ignored.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Error describes a single validation failure.
+type Error struct {
+	// Path is a JSON pointer to the offending value, e.g. "/Streaming/thinkingLevel".
+	// The document root is the empty string.
+	Path    string
+	Message string
+}
+
+func (e Error) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Validate checks instance against schema and returns every failure found.
+// The instance is expected to be a generic decoded document: maps with string
+// keys, slices, strings, bools, nil and any Go numeric type or json.Number.
+func Validate(schema map[string]interface{}, instance interface{}) []Error {
+	v := &validator{root: schema}
+	v.validate(schema, instance, "")
+	return v.errs
+}
+
+// Pointer joins path segments into a JSON pointer, escaping as needed.
+func Pointer(segments ...string) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteByte('/')
+		s = strings.ReplaceAll(s, "~", "~0")
+		b.WriteString(strings.ReplaceAll(s, "/", "~1"))
+	}
+	return b.String()
+}
+
+// SplitPointer splits a JSON pointer into its unescaped segments.
+func SplitPointer(pointer string) []string {
+	if pointer == "" {
+		return nil
+	}
+	parts := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, p := range parts {
+		p = strings.ReplaceAll(p, "~1", "/")
+		parts[i] = strings.ReplaceAll(p, "~0", "~")
+	}
+	return parts
+}
+
+type validator struct {
+	root map[string]interface{}
+	errs []Error
+}
+
+func (v *validator) fail(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, Error{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(s map[string]interface{}, inst interface{}, path string) {
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := v.resolve(ref)
+		if err != nil {
+			v.fail(path, "%v", err)
+			return
+		}
+		s = target
+	}
+
+	if t, ok := s["type"]; ok && !matchesType(t, inst) {
+		v.fail(path, "expected %s, got %s", typeList(t), typeOf(inst))
+		return
+	}
+
+	if enum, ok := s["enum"]; ok {
+		v.checkEnum(enum, inst, path)
+	}
+
+	switch val := inst.(type) {
+	case map[string]interface{}:
+		v.validateObject(s, val, path)
+	case []interface{}:
+		v.validateArray(s, val, path)
+	case string:
+		v.validateString(s, val, path)
+	default:
+		if n, ok := toFloat(inst); ok {
+			v.validateNumber(s, n, path)
+		}
+	}
+}
+
+func (v *validator) resolve(ref string) (map[string]interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported schema reference %q", ref)
+	}
+	var node interface{} = v.root
+	for _, seg := range SplitPointer(strings.TrimPrefix(ref, "#")) {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolvable schema reference %q", ref)
+		}
+		node = m[seg]
+	}
+	target, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unresolvable schema reference %q", ref)
+	}
+	return target, nil
+}
+
+func (v *validator) validateObject(s map[string]interface{}, obj map[string]interface{}, path string) {
+	for _, name := range toStrings(s["required"]) {
+		if _, ok := obj[name]; !ok {
+			v.fail(path, "missing required property %q", name)
+		}
+	}
+
+	if n, ok := toFloat(s["minProperties"]); ok && float64(len(obj)) < n {
+		v.fail(path, "must have at least %v properties", n)
+	}
+
+	props, _ := s["properties"].(map[string]interface{})
+	names, _ := s["propertyNames"].(map[string]interface{})
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		childPath := path + Pointer(key)
+		if names != nil {
+			before := len(v.errs)
+			v.validate(names, key, childPath)
+			for i := before; i < len(v.errs); i++ {
+				v.errs[i].Message = "property name " + v.errs[i].Message
+			}
+		}
+		if ps, ok := props[key].(map[string]interface{}); ok {
+			v.validate(ps, obj[key], childPath)
+			continue
+		}
+		switch ap := s["additionalProperties"].(type) {
+		case bool:
+			if !ap {
+				v.fail(childPath, "unknown property %q", key)
+			}
+		case map[string]interface{}:
+			v.validate(ap, obj[key], childPath)
+		}
+	}
+}
+
+func (v *validator) validateArray(s map[string]interface{}, arr []interface{}, path string) {
+	if n, ok := toFloat(s["minItems"]); ok && float64(len(arr)) < n {
+		v.fail(path, "must have at least %v items", n)
+	}
+	if n, ok := toFloat(s["maxItems"]); ok && float64(len(arr)) > n {
+		v.fail(path, "must have at most %v items", n)
+	}
+	if items, ok := s["items"].(map[string]interface{}); ok {
+		for i, item := range arr {
+			v.validate(items, item, fmt.Sprintf("%s/%d", path, i))
+		}
+	}
+}
+
+func (v *validator) validateString(s map[string]interface{}, str string, path string) {
+	length := float64(len([]rune(str)))
+	if n, ok := toFloat(s["minLength"]); ok && length < n {
+		if n == 1 {
+			v.fail(path, "must not be empty")
+		} else {
+			v.fail(path, "must be at least %v characters", n)
+		}
+	}
+	if n, ok := toFloat(s["maxLength"]); ok && length > n {
+		v.fail(path, "must be at most %v characters", n)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			v.fail(path, "invalid schema pattern %q: %v", pattern, err)
+		} else if !re.MatchString(str) {
+			v.fail(path, "must match pattern %q", pattern)
+		}
+	}
+}
+
+func (v *validator) validateNumber(s map[string]interface{}, n float64, path string) {
+	if min, ok := toFloat(s["minimum"]); ok && n < min {
+		v.fail(path, "must be >= %v", min)
+	}
+	if max, ok := toFloat(s["maximum"]); ok && n > max {
+		v.fail(path, "must be <= %v", max)
+	}
+}
+
+func (v *validator) checkEnum(enum interface{}, inst interface{}, path string) {
+	rv := reflect.ValueOf(enum)
+	if rv.Kind() != reflect.Slice {
+		return
+	}
+	var allowed []string
+	for i := 0; i < rv.Len(); i++ {
+		candidate := rv.Index(i).Interface()
+		if equal(candidate, inst) {
+			return
+		}
+		allowed = append(allowed, fmt.Sprint(candidate))
+	}
+	v.fail(path, "must be one of [%s], got %v", strings.Join(allowed, ", "), inst)
+}
+
+func matchesType(t interface{}, inst interface{}) bool {
+	for _, name := range toStrings(t) {
+		if isType(name, inst) {
+			return true
+		}
+	}
+	return false
+}
+
+func isType(name string, inst interface{}) bool {
+	switch name {
+	case "object":
+		_, ok := inst.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := inst.([]interface{})
+		return ok
+	case "string":
+		_, ok := inst.(string)
+		return ok
+	case "boolean":
+		_, ok := inst.(bool)
+		return ok
+	case "null":
+		return inst == nil
+	case "number":
+		_, ok := toFloat(inst)
+		return ok
+	case "integer":
+		n, ok := toFloat(inst)
+		return ok && n == math.Trunc(n)
+	}
+	return false
+}
+
+func typeList(t interface{}) string {
+	return strings.Join(toStrings(t), " or ")
+}
+
+func typeOf(inst interface{}) string {
+	switch inst.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	if _, ok := toFloat(inst); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", inst)
+}
+
+func equal(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// toStrings accepts a string, []string or []interface{} of strings.
+func toStrings(v interface{}) []string {
+	switch val := v.(type) {
+	case string:
+		return []string{val}
+	case []string:
+		return val
+	case []interface{}:
+		out := make([]string, 0, len(val))
+		for _, item := range val {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,149 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chzyer/readline"
+	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/cli"
+)
+
+// FileRefPrefix marks a file or directory reference in user input, e.g. @main.go.
+const FileRefPrefix = "@"
+
+// CompletionSources supplies the dynamic candidates for tab completion.
+// Any source may be nil.
+type CompletionSources struct {
+	Models   func() []string // Model names offered by the provider
+	Sessions func() []string // Saved history ids
+	Prompts  func() []string // Configured system prompt names
+}
+
+// Completer completes system commands, their subcommands and arguments,
+// and @file references. It implements readline.AutoCompleter.
+type Completer struct {
+	sources CompletionSources
+}
+
+var _ readline.AutoCompleter = (*Completer)(nil)
+
+// NewCompleter returns a Completer backed by the given sources.
+func NewCompleter(sources CompletionSources) *Completer {
+	return &Completer{sources: sources}
+}
+
+// Do returns the suffixes that complete the word before pos, along with
+// the length of that word in runes.
+func (c *Completer) Do(line []rune, pos int) ([][]rune, int) {
+	input := string(line[:pos])
+
+	if strings.HasPrefix(input, cli.SystemCmdPrefix) {
+		if candidates, prefix, ok := c.completeCommand(input); ok {
+			return suffixes(candidates, prefix), len([]rune(prefix))
+		}
+		return nil, 0
+	}
+
+	word := input[strings.LastIndexAny(input, " \t\n")+1:]
+	if strings.HasPrefix(word, FileRefPrefix) {
+		prefix := strings.TrimPrefix(word, FileRefPrefix)
+		return suffixes(completePath(prefix), prefix), len([]rune(prefix))
+	}
+	return nil, 0
+}
+
+// completeCommand returns candidates for a system command line and the
+// already typed prefix they must start with.
+func (c *Completer) completeCommand(input string) ([]string, string, bool) {
+	rest := strings.TrimPrefix(input, cli.SystemCmdPrefix)
+
+	name, afterName, hasName := strings.Cut(rest, " ")
+	if !hasName {
+		return cli.SystemCommands, name, true
+	}
+
+	afterName = strings.TrimLeft(afterName, " ")
+	sub, argument, hasSub := strings.Cut(afterName, " ")
+	if !hasSub {
+		return cli.Subcommands[name], sub, true
+	}
+
+	// Arguments are joined by the handlers, so complete the whole remainder
+	argument = strings.TrimLeft(argument, " ")
+	source := c.argumentSource(name, strings.ToLower(sub))
+	if source == nil {
+		return nil, "", false
+	}
+	return source(), argument, true
+}
+
+// argumentSource returns the candidate source for a command's argument.
+func (c *Completer) argumentSource(name, sub string) func() []string {
+	switch {
+	case name == cli.SystemCmdModel && sub == "set":
+		return c.sources.Models
+	case name == cli.SystemCmdHistory && (sub == "load" || sub == "delete"):
+		return c.sources.Sessions
+	case name == cli.SystemCmdSelectPrompt && (sub == "use" || sub == "show"):
+		return c.sources.Prompts
+	}
+	return nil
+}
+
+// completePath lists entries matching prefix, relative to the working
+// directory. Directories end with a separator so completion can continue.
+// Hidden entries are only offered once the prefix starts with a dot.
+func completePath(prefix string) []string {
+	dir, base := filepath.Split(prefix)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+
+	var candidates []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		candidate := dir + name
+		if entry.IsDir() {
+			candidate += string(filepath.Separator)
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// suffixes returns the remainders of the candidates that start with prefix,
+// sorted and de-duplicated.
+func suffixes(candidates []string, prefix string) [][]rune {
+	seen := make(map[string]bool)
+	var matches []string
+	for _, candidate := range candidates {
+		if !strings.HasPrefix(candidate, prefix) || seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+		matches = append(matches, candidate)
+	}
+	sort.Strings(matches)
+
+	out := make([][]rune, 0, len(matches))
+	for _, match := range matches {
+		suffix := strings.TrimPrefix(match, prefix)
+		// Finish single, non-directory matches with a space
+		if len(matches) == 1 && !strings.HasSuffix(match, string(filepath.Separator)) {
+			suffix += " "
+		}
+		out = append(out, []rune(suffix))
+	}
+	return out
+}
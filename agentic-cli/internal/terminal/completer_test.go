@@ -0,0 +1,95 @@
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func complete(c *Completer, input string) ([]string, int) {
+	line := []rune(input)
+	candidates, length := c.Do(line, len(line))
+	out := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		out[i] = string(candidate)
+	}
+	return out, length
+}
+
+func TestCompleterSystemCommands(t *testing.T) {
+	c := NewCompleter(CompletionSources{
+		Models:   func() []string { return []string{"qwen2.5", "qwen3", "llama3"} },
+		Sessions: func() []string { return []string{"session_1", "work"} },
+		Prompts:  func() []string { return []string{"Developer", "Writer"} },
+	})
+
+	tests := []struct {
+		input  string
+		want   []string
+		length int
+	}{
+		{input: "!s", want: []string{"how-thinking", "tream"}, length: 1},
+		{input: "!thin", want: []string{"king "}, length: 4},
+		{input: "!h ", want: []string{"clear", "delete", "list", "load", "save"}, length: 0},
+		{input: "!h lo", want: []string{"ad "}, length: 2},
+		{input: "!m set qw", want: []string{"en2.5", "en3"}, length: 2},
+		{input: "!h load w", want: []string{"ork "}, length: 1},
+		{input: "!p use D", want: []string{"eveloper "}, length: 1},
+		{input: "!t set ", want: nil, length: 0},
+		{input: "plain text", want: nil, length: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, length := complete(c, tt.input)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) || length != tt.length {
+				t.Errorf("Expected %q (length %d), got %q (length %d)", tt.want, tt.length, got, length)
+			}
+		})
+	}
+}
+
+func TestCompleterFileReferences(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.go", "README.md", ".env"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "internal"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "internal", "query.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	c := NewCompleter(CompletionSources{})
+
+	got, length := complete(c, "explain @")
+	if want := []string{"README.md", "internal/", "main.go"}; !reflect.DeepEqual(got, want) || length != 0 {
+		t.Errorf("Expected %q, got %q (length %d)", want, got, length)
+	}
+
+	got, length = complete(c, "explain @internal/q")
+	if want := []string{"uery.go "}; !reflect.DeepEqual(got, want) || length != len("internal/q") {
+		t.Errorf("Expected %q, got %q (length %d)", want, got, length)
+	}
+
+	got, _ = complete(c, "read @.e")
+	if want := []string{"nv "}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected hidden file when prefix starts with a dot, got %q", got)
+	}
+}
@@ -0,0 +1,181 @@
+package terminal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DefaultHistorySize is the number of entries kept when no size is configured.
+const DefaultHistorySize = 1000
+
+// DefaultHistoryIgnore matches input that looks like it contains credentials.
+const DefaultHistoryIgnore = `(?i)(api[_-]?key|token|secret|passw(or)?d|bearer)\s*[:=]|\bsk-[A-Za-z0-9_-]{16,}|\bghp_[A-Za-z0-9]{20,}|\bAKIA[0-9A-Z]{16}\b`
+
+// History is a persistent, de-duplicated input history. Entries are stored
+// one JSON string per line so multi-line input survives intact.
+type History struct {
+	path    string
+	size    int
+	ignore  *regexp.Regexp
+	entries []string
+
+	mu sync.Mutex
+}
+
+// NewHistory loads the history stored at path. Entries matching ignore are
+// never recorded; a nil ignore records everything. The file is created on
+// the first Add.
+func NewHistory(path string, size int, ignore *regexp.Regexp) (*History, error) {
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+
+	h := &History{path: path, size: size, ignore: ignore}
+	if err := h.load(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Entries returns the recorded entries from oldest to newest.
+func (h *History) Entries() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.entries...)
+}
+
+// Add records entry as the newest item, dropping any older identical entry.
+// It reports whether the entry was recorded and whether earlier entries were
+// removed, in which case callers mirroring the history must rebuild it.
+func (h *History) Add(entry string) (added, rebuilt bool, err error) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" || (h.ignore != nil && h.ignore.MatchString(entry)) {
+		return false, false, nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if n := len(h.entries); n > 0 && h.entries[n-1] == entry {
+		return false, false, nil
+	}
+
+	kept := h.entries[:0]
+	for _, e := range h.entries {
+		if e != entry {
+			kept = append(kept, e)
+		}
+	}
+	rebuilt = len(kept) != len(h.entries)
+	h.entries = append(kept, entry)
+
+	if len(h.entries) > h.size {
+		h.entries = h.entries[len(h.entries)-h.size:]
+		rebuilt = true
+	}
+
+	if rebuilt {
+		return true, true, h.rewrite()
+	}
+	return true, false, h.append(entry)
+}
+
+func (h *History) load() error {
+	file, err := os.Open(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to open input history: %w", err)
+	}
+	defer file.Close()
+
+	seen := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry string
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry == "" {
+			continue // Skip corrupt lines rather than losing the whole history
+		}
+		if i, ok := seen[entry]; ok {
+			h.entries[i] = ""
+		}
+		seen[entry] = len(h.entries)
+		h.entries = append(h.entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read input history: %w", err)
+	}
+
+	compacted := h.entries[:0]
+	for _, e := range h.entries {
+		if e != "" {
+			compacted = append(compacted, e)
+		}
+	}
+	h.entries = compacted
+	if len(h.entries) > h.size {
+		h.entries = h.entries[len(h.entries)-h.size:]
+	}
+	return nil
+}
+
+// append adds a single entry to the end of the history file.
+func (h *History) append(entry string) error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0700); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open input history: %w", err)
+	}
+	defer file.Close()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// rewrite replaces the history file with the current entries.
+func (h *History) rewrite() error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0700); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	tempFile := h.path + ".tmp"
+	file, err := os.OpenFile(tempFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write input history: %w", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	for _, entry := range h.entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			file.Close()
+			os.Remove(tempFile)
+			return err
+		}
+		writer.Write(line)
+		writer.WriteByte('\n')
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		os.Remove(tempFile)
+		return fmt.Errorf("failed to write input history: %w", err)
+	}
+	file.Close()
+
+	return os.Rename(tempFile, h.path)
+}
@@ -0,0 +1,66 @@
+package terminal
+
+import (
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestHistoryPersistsAndDeduplicates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history", "project.jsonl")
+	ignore := regexp.MustCompile(DefaultHistoryIgnore)
+
+	h, err := NewHistory(path, 10, ignore)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, entry := range []string{
+		"first",
+		"multi\nline entry",
+		"first",
+		"export OPENAI_API_KEY=abc123",
+		"use key sk-abcdefghijklmnopqrstuvwxyz",
+		"  ",
+	} {
+		if _, _, err := h.Add(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"multi\nline entry", "first"}
+	if got := h.Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	reloaded, err := NewHistory(path, 10, ignore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q after reload, got %q", want, got)
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "project.jsonl")
+
+	h, err := NewHistory(path, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range []string{"a", "b", "c"} {
+		if _, _, err := h.Add(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reloaded, err := NewHistory(path, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := reloaded.Entries(), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
@@ -19,6 +19,8 @@ type IOConfig struct {
 	User           string
 	Multiline      bool
 	LineTerminator string
+	AutoComplete   readline.AutoCompleter
+	History        *History // Persistent input history; nil keeps it in memory only
 }
 
 // IO encapsulates input/output operations.
@@ -33,17 +35,31 @@ type IO struct {
 
 // NewIO returns a new IO based on the provided configuration.
 func NewIO(config *IOConfig) (*IO, error) {
-	reader, err := readline.NewEx(&readline.Config{})
+	historyLimit := DefaultHistorySize
+	if config.History != nil {
+		historyLimit = config.History.size
+	}
+
+	reader, err := readline.NewEx(&readline.Config{
+		AutoComplete:      config.AutoComplete,
+		HistoryLimit:      historyLimit,
+		HistorySearchFold: true,
+		// Entries are recorded by Read once complete, so that multi-line
+		// input is stored as a single entry rather than line by line
+		DisableAutoSaveHistory: true,
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	if config.History != nil {
+		for _, entry := range config.History.Entries() {
+			_ = reader.SaveHistory(entry)
+		}
+	}
+
 	terminalPrompt := NewPrompt(config.User)
 	reader.SetPrompt(terminalPrompt.User)
-	if config.Multiline {
-		// disable history for multiline input mode
-		reader.HistoryDisable()
-	}
 
 	return &IO{
 		Reader:  reader,
@@ -57,10 +73,43 @@ func NewIO(config *IOConfig) (*IO, error) {
 // Read reads input from the underlying source and returns it as a string.
 // If multiline is true, it reads all available lines; otherwise, it reads a single line.
 func (io *IO) Read() string {
+	var input string
 	if io.Config.Multiline {
-		return io.readMultiLine()
+		input = io.readMultiLine()
+	} else {
+		input = io.readLine()
+	}
+	io.recordHistory(input)
+	return input
+}
+
+// recordHistory adds input to the in-memory and persistent history.
+// System commands synthesized from interrupts are not recorded.
+func (io *IO) recordHistory(input string) {
+	if input == "" || input == cli.SystemCmdPrefix+cli.SystemCmdQuit {
+		return
 	}
-	return io.readLine()
+
+	if io.Config.History == nil {
+		_ = io.Reader.SaveHistory(input)
+		return
+	}
+
+	added, rebuilt, err := io.Config.History.Add(input)
+	if err != nil {
+		io.Write(fmt.Sprintf("%s%s\n", io.Prompt.System, Error("Failed to save input history: "+err.Error())))
+	}
+	if !added {
+		return
+	}
+	if rebuilt {
+		io.Reader.ResetHistory()
+		for _, entry := range io.Config.History.Entries() {
+			_ = io.Reader.SaveHistory(entry)
+		}
+		return
+	}
+	_ = io.Reader.SaveHistory(input)
 }
 
 // Write writes the given string data to the underlying data stream.
@@ -85,6 +134,11 @@ func (io *IO) readMultiLine() string {
 			return io.handleReadError(err, builder.Len()+len(input))
 		}
 
+		// A multi-line entry recalled from history is submitted as a whole
+		if builder.Len() == 0 && strings.Contains(input, "\n") {
+			return strings.TrimSpace(strings.TrimSuffix(input, io.Config.LineTerminator))
+		}
+
 		if strings.HasSuffix(input, io.Config.LineTerminator) ||
 			strings.HasPrefix(input, cli.SystemCmdPrefix) {
 			builder.WriteString(strings.TrimSuffix(input, io.Config.LineTerminator))
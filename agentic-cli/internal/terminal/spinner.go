@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"sync/atomic"
 	"time"
 )
 
@@ -22,6 +23,7 @@ type Spinner struct {
 
 	maxLength int
 	length    int
+	running   atomic.Bool
 }
 
 // NewSpinner returns a new Spinner.
@@ -36,6 +38,7 @@ func NewSpinner(w io.Writer, interval time.Duration, length int) *Spinner {
 
 //nolint:errcheck
 func (s *Spinner) Start() {
+	s.running.Store(true)
 	go func() {
 		ticker := time.NewTicker(s.interval)
 		defer ticker.Stop()
@@ -72,4 +75,10 @@ func (s *Spinner) Clear() {
 func (s *Spinner) Stop() {
 	s.signal <- struct{}{}
 	<-s.signal
+	s.running.Store(false)
+}
+
+// Running reports whether the spinner is currently animating.
+func (s *Spinner) Running() bool {
+	return s.running.Load()
 }
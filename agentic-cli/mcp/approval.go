@@ -0,0 +1,63 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ApprovalRequest describes a tool action that needs the user's consent
+type ApprovalRequest struct {
+	Tool   string // Name of the tool asking for approval
+	Action string // What will be done, phrased as a verb, e.g. "run `ls` in ."
+	Reason string // Why the action is not allowed automatically
+}
+
+// Approver asks the user whether an action may proceed
+type Approver func(ctx context.Context, req ApprovalRequest) (bool, error)
+
+var (
+	approverMu sync.RWMutex
+	approver   Approver
+)
+
+// SetApprover installs the function consulted for actions that require
+// approval. Without an approver such actions are refused.
+func SetApprover(a Approver) {
+	approverMu.Lock()
+	defer approverMu.Unlock()
+	approver = a
+}
+
+// requestApproval returns nil once the user has approved the action
+func requestApproval(ctx context.Context, req ApprovalRequest) error {
+	approverMu.RLock()
+	approve := approver
+	approverMu.RUnlock()
+
+	if approve == nil {
+		return fmt.Errorf("%s requires approval (%s) but no interactive approval is available", req.Action, req.Reason)
+	}
+
+	ok, err := approve(ctx, req)
+	if err != nil {
+		return fmt.Errorf("approval failed: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("the user declined to %s", req.Action)
+	}
+	return nil
+}
+
+// confirmMutation asks for approval of a file modification when the policy
+// requires it
+func confirmMutation(ctx context.Context, tool, action string) error {
+	if !currentPolicy().ConfirmMutations {
+		return nil
+	}
+	return requestApproval(ctx, ApprovalRequest{
+		Tool:   tool,
+		Action: action,
+		Reason: "file changes require confirmation",
+	})
+}
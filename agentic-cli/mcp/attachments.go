@@ -0,0 +1,196 @@
+package mcp
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// MaxAttachmentFiles limits how many files one message may attach
+	MaxAttachmentFiles = 50
+	// MaxAttachmentBytes limits the combined size of attached files
+	MaxAttachmentBytes = 256 * 1024
+	// binarySniffLength is how much of a file is inspected for NUL bytes
+	binarySniffLength = 8000
+)
+
+// Attachment describes a file pulled into a message by an @path reference
+type Attachment struct {
+	Path    string // Path relative to the working directory
+	Size    int64  // File size in bytes
+	Lines   int    // Number of lines attached
+	Skipped string // Why the file was not attached; empty when attached
+}
+
+// referencePattern matches @path tokens at the start of input or after whitespace
+var referencePattern = regexp.MustCompile(`(^|\s)@(\S+)`)
+
+// ExpandReferences attaches the content of every file or directory
+// referenced as @path in input. Paths go through the same validation as the
+// filesystem tools, ignored files are left out, and the total is capped by
+// MaxAttachmentFiles and MaxAttachmentBytes. References that don't name an
+// existing path are left untouched. It returns the message to send along
+// with every file that was considered.
+func ExpandReferences(input string) (string, []Attachment) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return input, nil
+	}
+
+	e := &expander{
+		ignore: newIgnoreMatcher(wd),
+		seen:   make(map[string]bool),
+	}
+	for _, match := range referencePattern.FindAllStringSubmatch(input, -1) {
+		e.expand(match[2])
+	}
+
+	if e.body.Len() == 0 {
+		return input, e.attachments
+	}
+	return input + "\n\nAttached files:\n" + e.body.String(), e.attachments
+}
+
+// expander accumulates attachments across the references of one message
+type expander struct {
+	ignore      *ignoreMatcher
+	seen        map[string]bool
+	attachments []Attachment
+	body        strings.Builder
+	files       int
+	total       int64
+}
+
+// expand attaches a single reference
+func (e *expander) expand(ref string) {
+	path, info, ok := resolveReference(ref)
+	if !ok {
+		if strings.ContainsAny(ref, "./") {
+			e.skip(ref, "not found")
+		}
+		return
+	}
+
+	resolved, err := validatePath(path)
+	if err != nil {
+		e.skip(path, err.Error())
+		return
+	}
+
+	if !info.IsDir() {
+		if e.ignore.ignored(resolved, false) {
+			e.skip(path, "ignored by .gitignore or .gossignore")
+			return
+		}
+		e.attach(path, resolved, info)
+		return
+	}
+
+	_ = filepath.WalkDir(resolved, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip unreadable entries
+		}
+		if p != resolved {
+			if _, err := validatePath(p); err != nil || e.ignore.ignored(p, d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		e.attach(displayPath(path, resolved, p), p, info)
+		return nil
+	})
+}
+
+// attach appends the content of the regular file at the resolved path,
+// shown as path, subject to the limits
+func (e *expander) attach(path, resolved string, info os.FileInfo) {
+	path = filepath.Clean(path)
+	if e.seen[resolved] {
+		return
+	}
+	e.seen[resolved] = true
+
+	if info.Size() > currentPolicy().MaxFileSize {
+		e.skip(path, fmt.Sprintf("larger than %d bytes", currentPolicy().MaxFileSize))
+		return
+	}
+	if e.files >= MaxAttachmentFiles || e.total+info.Size() > MaxAttachmentBytes {
+		e.skip(path, "attachment limit reached")
+		return
+	}
+
+	content, err := os.ReadFile(resolved)
+	if err != nil {
+		e.skip(path, err.Error())
+		return
+	}
+	if isBinary(content) {
+		e.skip(path, "binary file")
+		return
+	}
+
+	lines := strings.Count(string(content), "\n")
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		lines++
+	}
+
+	fmt.Fprintf(&e.body, "\n<file path=%q>\n%s", filepath.ToSlash(path), content)
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		e.body.WriteString("\n")
+	}
+	e.body.WriteString("</file>\n")
+
+	e.files++
+	e.total += info.Size()
+	e.attachments = append(e.attachments, Attachment{
+		Path:  filepath.ToSlash(path),
+		Size:  info.Size(),
+		Lines: lines,
+	})
+}
+
+// skip records a file that was referenced but not attached
+func (e *expander) skip(path, reason string) {
+	e.attachments = append(e.attachments, Attachment{
+		Path:    filepath.ToSlash(path),
+		Skipped: reason,
+	})
+}
+
+// resolveReference returns the existing path named by ref, retrying without
+// trailing punctuation so "see @main.go." still resolves.
+func resolveReference(ref string) (string, os.FileInfo, bool) {
+	for candidate := ref; candidate != ""; {
+		if info, err := os.Stat(candidate); err == nil {
+			return candidate, info, true
+		}
+		trimmed := strings.TrimRight(candidate, ".,;:!?)]}'\"")
+		if trimmed == candidate {
+			break
+		}
+		candidate = trimmed
+	}
+	return "", nil, false
+}
+
+// isBinary reports whether content looks like binary data
+func isBinary(content []byte) bool {
+	if len(content) > binarySniffLength {
+		content = content[:binarySniffLength]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
@@ -0,0 +1,212 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// chdirTemp switches to a fresh directory populated with files
+func chdirTemp(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+// callTool runs a built-in tool with decoded JSON arguments, skipping the
+// client's middleware
+func callTool(ctx context.Context, name string, args map[string]interface{}) (string, error) {
+	for _, tool := range CreateTools() {
+		if tool.Function.Name == name {
+			return tool.Function.Handler(ctx, args)
+		}
+	}
+	return "", fmt.Errorf("unknown tool %s", name)
+}
+
+func TestExpandReferences(t *testing.T) {
+	chdirTemp(t, map[string]string{
+		".gitignore":        "*.log\nbuild/\n",
+		"main.go":           "package main\n",
+		"pkg/a.go":          "package pkg\n\nfunc A() {}\n",
+		"pkg/debug.log":     "noise\n",
+		"pkg/image.bin":     "\x00\x01\x02",
+		"build/out.go":      "package build\n",
+		"node_modules/x.js": "x",
+	})
+
+	message, attachments := ExpandReferences("explain @main.go, then @pkg/ and @someone")
+
+	attached := make(map[string]Attachment)
+	for _, a := range attachments {
+		attached[a.Path] = a
+	}
+
+	if a, ok := attached["main.go"]; !ok || a.Skipped != "" || a.Lines != 1 {
+		t.Errorf("Expected main.go to be attached with 1 line, got %+v", a)
+	}
+	if a, ok := attached["pkg/a.go"]; !ok || a.Skipped != "" || a.Lines != 3 {
+		t.Errorf("Expected pkg/a.go to be attached with 3 lines, got %+v", a)
+	}
+	if a := attached["pkg/image.bin"]; a.Skipped != "binary file" {
+		t.Errorf("Expected binary file to be skipped, got %+v", a)
+	}
+	if _, ok := attached["pkg/debug.log"]; ok {
+		t.Error("Expected ignored file to be left out of a directory reference")
+	}
+	if _, ok := attached["someone"]; ok {
+		t.Error("Expected a reference to a missing path to be left alone")
+	}
+
+	if !strings.HasPrefix(message, "explain @main.go, then @pkg/ and @someone\n") {
+		t.Errorf("Expected the original text to be kept, got %q", message)
+	}
+	if !strings.Contains(message, "<file path=\"pkg/a.go\">\npackage pkg\n\nfunc A() {}\n</file>") {
+		t.Errorf("Expected file content in message, got %q", message)
+	}
+}
+
+func TestExpandReferencesRespectsPolicy(t *testing.T) {
+	chdirTemp(t, map[string]string{
+		".gitignore":        "build/\n",
+		"build/out.go":      "package build\n",
+		"node_modules/x.js": "x",
+	})
+
+	message, attachments := ExpandReferences("@build/out.go @node_modules/x.js")
+	if len(attachments) != 2 {
+		t.Fatalf("Expected 2 skipped references, got %+v", attachments)
+	}
+	for _, a := range attachments {
+		if a.Skipped == "" {
+			t.Errorf("Expected %s to be skipped", a.Path)
+		}
+	}
+	if strings.Contains(message, "<file") {
+		t.Errorf("Expected nothing to be attached, got %q", message)
+	}
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	dir := chdirTemp(t, map[string]string{
+		".gitignore":     "*.log\n!keep.log\n/dist\ndocs/**/draft.md\n",
+		"sub/.gitignore": "local/\n",
+	})
+	m := newIgnoreMatcher(dir)
+
+	tests := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"app.log", false, true},
+		{"sub/deep/app.log", false, true},
+		{"keep.log", false, false},
+		{"dist", true, true},
+		{"dist/app.js", false, true},
+		{"sub/dist", true, false},
+		{"docs/a/b/draft.md", false, true},
+		{"sub/local", true, true},
+		{"sub/local/file.go", false, true},
+		{"local", true, false},
+		{"main.go", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := m.ignored(filepath.Join(dir, tt.path), tt.isDir); got != tt.ignored {
+			t.Errorf("ignored(%q) = %t, expected %t", tt.path, got, tt.ignored)
+		}
+	}
+}
+
+func TestIgnoreFilesAcrossTools(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	if err := os.MkdirAll(filepath.Join(configDir, "goss"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "goss", "ignore"), []byte("*.tmp\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	chdirTemp(t, map[string]string{
+		".gitignore":        "build/\n",
+		"app/.gossignore":   "fixtures/\n",
+		"main.go":           "needle\n",
+		"build/out.go":      "needle\n",
+		"app/app.go":        "needle\n",
+		"app/fixtures/f.go": "needle\n",
+		"scratch.tmp":       "needle\n",
+	})
+	ctx := context.Background()
+
+	hidden := []string{"build", "fixtures", "scratch.tmp"}
+	tools := []struct {
+		name string
+		args map[string]interface{}
+	}{
+		{"grep_files", map[string]interface{}{"pattern": "needle"}},
+		{"search_files", map[string]interface{}{"path": ".", "pattern": "*"}},
+		{"directory_tree", map[string]interface{}{}},
+	}
+
+	for _, tool := range tools {
+		t.Run(tool.name, func(t *testing.T) {
+			result, err := callTool(ctx, tool.name, tool.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range hidden {
+				if strings.Contains(result, name) {
+					t.Errorf("Expected %s to be ignored:\n%s", name, result)
+				}
+			}
+			if !strings.Contains(result, "app.go") || !strings.Contains(result, "include_ignored") {
+				t.Errorf("Expected visible files and a note about hidden ones:\n%s", result)
+			}
+
+			args := map[string]interface{}{"include_ignored": true}
+			for k, v := range tool.args {
+				args[k] = v
+			}
+			result, err = callTool(ctx, tool.name, args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range hidden {
+				if !strings.Contains(result, name) {
+					t.Errorf("Expected %s with include_ignored:\n%s", name, result)
+				}
+			}
+		})
+	}
+
+	result, err := callTool(ctx, "list_directory", map[string]interface{}{"path": "."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(result, "build/") || !strings.Contains(result, "2 ignored entries hidden") {
+		t.Errorf("Expected build and scratch.tmp to be hidden from the listing:\n%s", result)
+	}
+}
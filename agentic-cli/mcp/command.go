@@ -0,0 +1,322 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/cli"
+	"github.com/vivesm/GOSS-CLI/agentic-cli/openai"
+)
+
+const (
+	// DefaultCommandTimeout is how long a command may run unless configured
+	DefaultCommandTimeout = 60 * time.Second
+	// MaxCommandTimeout caps the timeout a tool call may request
+	MaxCommandTimeout = 10 * time.Minute
+	// DefaultCommandOutput is how many bytes of each output stream are kept
+	DefaultCommandOutput = 32 * 1024
+)
+
+// shellSyntax matches characters that need a shell to interpret
+var shellSyntax = regexp.MustCompile("[|&;<>`$(){}*?~\n]")
+
+// unsafeFlags lists, by program, flags that run other programs or write or
+// delete things, so an allow-listed command using them still needs approval
+var unsafeFlags = map[string][]string{
+	"rg":  {"--pre"},
+	"go":  {"-w", "-u", "-exec", "-toolexec"},
+	"git": {"-D", "--delete", "--output", "--exec", "-c"},
+}
+
+// secretEnv matches environment variables that must not reach commands
+var secretEnv = regexp.MustCompile(`(?i)(KEY|TOKEN|SECRET|PASSW|CREDENTIAL|AUTH|COOKIE|SESSION)`)
+
+// CreateCommandTools returns the command execution MCP tools
+func CreateCommandTools() []openai.Tool {
+	return []openai.Tool{
+		openai.NewTool("run_command",
+			"Run a command such as `go test ./...` or `grep -rn foo .` in the project and "+
+				"return its exit code, stdout and stderr. Commands outside the allow list need user approval.",
+			runCommandHandler),
+	}
+}
+
+type runCommandArgs struct {
+	Command        string  `json:"command" description:"Command line to run"`
+	WorkingDir     string  `json:"working_dir,omitempty" description:"Directory to run in, relative to the project (default: project root)"`
+	TimeoutSeconds float64 `json:"timeout_seconds,omitempty" description:"Time limit in seconds (default: 60)"`
+}
+
+func runCommandHandler(ctx context.Context, args runCommandArgs) (string, error) {
+	command := args.Command
+	if strings.TrimSpace(command) == "" {
+		return "", fmt.Errorf("command must be a non-empty string")
+	}
+	command = strings.TrimSpace(command)
+
+	dir := "."
+	if args.WorkingDir != "" {
+		dir = args.WorkingDir
+	}
+	dir, err := validatePath(dir)
+	if err != nil {
+		return "", fmt.Errorf("security validation failed: %w", err)
+	}
+
+	policy := currentPolicy().Commands
+	timeout := policy.Timeout
+	if args.TimeoutSeconds > 0 {
+		timeout = time.Duration(args.TimeoutSeconds * float64(time.Second))
+	}
+	if timeout > MaxCommandTimeout {
+		timeout = MaxCommandTimeout
+	}
+
+	argv, err := parseCommand(command)
+	if err != nil {
+		return "", err
+	}
+
+	if denied := deniedBy(command, policy.Deny); denied != "" {
+		return "", fmt.Errorf("command %q is denied by policy (matches %q)", command, denied)
+	}
+	if reason := approvalReason(argv, policy.Allow); reason != "" {
+		err := requestApproval(ctx, ApprovalRequest{
+			Tool:   "run_command",
+			Action: fmt.Sprintf("run `%s` in %s", command, dir),
+			Reason: reason,
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return runCommand(ctx, command, argv, dir, timeout, policy.MaxOutput)
+}
+
+// parseCommand splits a simple command into arguments. Commands using
+// shell syntax yield a nil slice and are run through sh -c.
+func parseCommand(command string) ([]string, error) {
+	if shellSyntax.MatchString(command) {
+		return nil, nil
+	}
+	argv, err := cli.SplitArgs(command)
+	if err != nil {
+		return nil, fmt.Errorf("invalid command: %w", err)
+	}
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("command must be a non-empty string")
+	}
+	return argv, nil
+}
+
+// deniedBy returns the deny entry matching any word sequence of command
+func deniedBy(command string, deny []string) string {
+	words := strings.FieldsFunc(command, func(r rune) bool {
+		return strings.ContainsRune(" \t\n|&;<>()`$", r)
+	})
+	for i, word := range words {
+		words[i] = filepath.Base(word)
+	}
+
+	for _, entry := range deny {
+		entryWords := strings.Fields(entry)
+		if len(entryWords) == 0 {
+			continue
+		}
+		for i := 0; i+len(entryWords) <= len(words); i++ {
+			if equalWords(words[i:i+len(entryWords)], entryWords) {
+				return entry
+			}
+		}
+	}
+	return ""
+}
+
+// approvalReason explains why a command needs approval, or returns an
+// empty string when it may run automatically.
+func approvalReason(argv []string, allow []string) string {
+	if argv == nil {
+		return "uses shell syntax"
+	}
+
+	allowed := false
+	for _, entry := range allow {
+		entryWords := strings.Fields(entry)
+		if len(entryWords) > 0 && len(entryWords) <= len(argv) && equalWords(argv[:len(entryWords)], entryWords) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return "not in the allow list"
+	}
+	if flag := unsafeFlag(argv); flag != "" {
+		return fmt.Sprintf("flag %s can run other programs or change files", flag)
+	}
+
+	// Allow-listed commands must still stay inside the project, including
+	// paths given as flag values such as --output=x or -fx
+	for _, arg := range argv[1:] {
+		if value, ok := flagValue(arg); ok {
+			arg = value
+		}
+		if !looksLikePath(arg) {
+			continue
+		}
+		if _, err := validatePath(arg); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+// unsafeFlag returns the first argument that is one of the program's unsafe
+// flags, on its own, with an =value or with the value attached
+func unsafeFlag(argv []string) string {
+	flags := unsafeFlags[filepath.Base(argv[0])]
+	for _, arg := range argv[1:] {
+		for _, flag := range flags {
+			attached := !strings.HasPrefix(flag, "--") && len(flag) == 2 && strings.HasPrefix(arg, flag)
+			if arg == flag || strings.HasPrefix(arg, flag+"=") || attached {
+				return arg
+			}
+		}
+	}
+	return ""
+}
+
+// flagValue returns the value of a flag written as --flag=value, -f=value
+// or -fvalue. Other arguments, including plain flags, report false.
+func flagValue(arg string) (string, bool) {
+	if !strings.HasPrefix(arg, "-") {
+		return "", false
+	}
+	if _, value, ok := strings.Cut(arg, "="); ok {
+		return value, true
+	}
+	if !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+		return arg[2:], true
+	}
+	return "", false
+}
+
+// looksLikePath reports whether a command argument refers to a file
+func looksLikePath(arg string) bool {
+	return strings.ContainsRune(arg, filepath.Separator) || strings.HasPrefix(arg, ".")
+}
+
+func equalWords(a, b []string) bool {
+	for i := range b {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// runCommand executes the command and formats its outcome
+func runCommand(ctx context.Context, command string, argv []string, dir string, timeout time.Duration, maxOutput int) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if argv == nil {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	} else {
+		cmd = exec.CommandContext(ctx, argv[0], argv[1:]...)
+	}
+	cmd.Dir = dir
+	cmd.Env = scrubbedEnv(os.Environ())
+	cmd.WaitDelay = time.Second // Don't wait on pipes held open by orphans
+
+	stdout := &cappedBuffer{max: maxOutput}
+	stderr := &cappedBuffer{max: maxOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	duration := time.Since(start).Round(time.Millisecond)
+
+	exitCode := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			if ctx.Err() == nil {
+				return "", fmt.Errorf("failed to run command: %w", err)
+			}
+		}
+		exitCode = cmd.ProcessState.ExitCode()
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Command: %s\n", command))
+	result.WriteString(fmt.Sprintf("Exit code: %d\n", exitCode))
+	result.WriteString(fmt.Sprintf("Duration: %s\n", duration))
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.WriteString(fmt.Sprintf("Timed out: killed after %s\n", timeout))
+	}
+	result.WriteString("\nstdout:\n")
+	result.WriteString(stdout.String())
+	result.WriteString("\nstderr:\n")
+	result.WriteString(stderr.String())
+
+	return result.String(), nil
+}
+
+// scrubbedEnv drops variables that look like credentials
+func scrubbedEnv(environ []string) []string {
+	env := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		if secretEnv.MatchString(name) {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+// cappedBuffer keeps the first max bytes written and counts the rest
+type cappedBuffer struct {
+	max     int
+	buf     bytes.Buffer
+	dropped int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); room > 0 {
+		if len(p) <= room {
+			b.buf.Write(p)
+			return len(p), nil
+		}
+		b.buf.Write(p[:room])
+		b.dropped += len(p) - room
+		return len(p), nil
+	}
+	b.dropped += len(p)
+	return len(p), nil
+}
+
+func (b *cappedBuffer) String() string {
+	out := b.buf.String()
+	if out != "" && !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	if b.dropped > 0 {
+		out += fmt.Sprintf("... [truncated %d more bytes]\n", b.dropped)
+	}
+	if out == "" {
+		return "(empty)\n"
+	}
+	return out
+}
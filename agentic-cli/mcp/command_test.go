@@ -0,0 +1,136 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withApprover installs an approver for the duration of a test
+func withApprover(t *testing.T, approve bool) *[]ApprovalRequest {
+	t.Helper()
+	var requests []ApprovalRequest
+	SetApprover(func(ctx context.Context, req ApprovalRequest) (bool, error) {
+		requests = append(requests, req)
+		return approve, nil
+	})
+	t.Cleanup(func() { SetApprover(nil) })
+	return &requests
+}
+
+func TestCommandClassification(t *testing.T) {
+	chdirTemp(t, map[string]string{"main.go": "package main\n"})
+	policy := DefaultPolicy().Commands
+
+	tests := []struct {
+		command  string
+		denied   bool
+		approval bool
+	}{
+		{command: "go test ./...", approval: false},
+		{command: "grep -rn main .", approval: false},
+		{command: "grep -rn root /etc", approval: true},
+		{command: "grep -rn main ../other", approval: true},
+		{command: "python3 script.py", approval: true},
+		{command: "go test ./... | tail", approval: true},
+		{command: "sudo ls", denied: true},
+		{command: "ls && /usr/bin/sudo rm x", denied: true},
+		{command: "dd if=/dev/zero of=x", denied: true},
+		{command: "git diff", approval: false},
+		{command: "git branch -D main", approval: true},
+		{command: "go env -w GOFLAGS=-mod=mod", approval: true},
+		{command: "go test -exec ./evil ./...", approval: true},
+		{command: "rg --pre=./evil main", approval: true},
+		{command: "rg --pre ./evil main", approval: true},
+		{command: "rg -w main .", approval: false},
+		{command: "git diff --output=/etc/x", approval: true},
+		{command: "git diff -D", approval: true},
+		{command: "grep -o=/tmp/x main .", approval: true},
+		{command: "grep -f/home/u/.ssh/id_rsa .", approval: true},
+		{command: "grep -rn main ./main.go", approval: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if denied := deniedBy(tt.command, policy.Deny) != ""; denied != tt.denied {
+				t.Fatalf("denied = %t, expected %t", denied, tt.denied)
+			}
+			if tt.denied {
+				return
+			}
+			argv, err := parseCommand(tt.command)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reason := approvalReason(argv, policy.Allow); (reason != "") != tt.approval {
+				t.Errorf("approval reason %q, expected approval=%t", reason, tt.approval)
+			}
+		})
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	chdirTemp(t, map[string]string{"main.go": "package main\n"})
+	t.Setenv("GOSS_TEST_API_KEY", "sk-secret")
+
+	requests := withApprover(t, true)
+	result, err := callTool(context.Background(), "run_command", map[string]interface{}{
+		"command": `sh -c 'echo out; echo err >&2; env; exit 3'`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 1 || (*requests)[0].Tool != "run_command" {
+		t.Errorf("Expected one approval request, got %+v", *requests)
+	}
+	if !strings.Contains(result, "Exit code: 3") {
+		t.Errorf("Expected exit code 3, got %q", result)
+	}
+	if !strings.Contains(result, "stdout:\nout\n") || !strings.Contains(result, "stderr:\nerr\n") {
+		t.Errorf("Expected separate stdout and stderr, got %q", result)
+	}
+	if strings.Contains(result, "sk-secret") {
+		t.Error("Expected secrets to be scrubbed from the environment")
+	}
+}
+
+func TestRunCommandDeclined(t *testing.T) {
+	chdirTemp(t, nil)
+	withApprover(t, false)
+
+	_, err := callTool(context.Background(), "run_command", map[string]interface{}{"command": "touch x"})
+	if err == nil || !strings.Contains(err.Error(), "declined") {
+		t.Errorf("Expected declined error, got %v", err)
+	}
+
+	SetApprover(nil)
+	_, err = callTool(context.Background(), "run_command", map[string]interface{}{"command": "touch x"})
+	if err == nil || !strings.Contains(err.Error(), "requires approval") {
+		t.Errorf("Expected approval error without an approver, got %v", err)
+	}
+}
+
+func TestRunCommandTimeoutAndTruncation(t *testing.T) {
+	chdirTemp(t, nil)
+	withApprover(t, true)
+
+	start := time.Now()
+	result, err := callTool(context.Background(), "run_command", map[string]interface{}{
+		"command":         "sleep 5",
+		"timeout_seconds": 0.2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) > 3*time.Second || !strings.Contains(result, "Timed out") {
+		t.Errorf("Expected the command to be killed, got %q", result)
+	}
+
+	buf := &cappedBuffer{max: 4}
+	buf.Write([]byte("abcdef"))
+	buf.Write([]byte("gh"))
+	if got := buf.String(); got != "abcd\n... [truncated 4 more bytes]\n" {
+		t.Errorf("Unexpected truncated output %q", got)
+	}
+}
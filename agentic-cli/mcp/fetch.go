@@ -0,0 +1,446 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	// DefaultFetchChars is how much page content fetch_url returns per call
+	DefaultFetchChars = 20000
+	// MaxFetchChars caps the max_chars argument of fetch_url
+	MaxFetchChars = 100000
+	// MaxFetchBytes limits how much of a response body is downloaded
+	MaxFetchBytes = 5 * 1024 * 1024
+	// FetchTimeout bounds a page download, including redirects
+	FetchTimeout = 20 * time.Second
+	// FetchCacheTTL is how long fetched pages are served from the cache
+	FetchCacheTTL = 15 * time.Minute
+	// maxFetchCacheEntries bounds the number of cached pages and robots files
+	maxFetchCacheEntries = 64
+	// maxFetchRedirects limits redirect chains
+	maxFetchRedirects = 5
+	// fetchUserAgent identifies goss to web servers and robots.txt rules
+	fetchUserAgent = "goss-cli/1.0"
+)
+
+// fetchedPage is a downloaded page converted to text
+type fetchedPage struct {
+	URL       string // Final URL after redirects
+	Title     string
+	Content   string // Markdown for HTML pages, the body for other text
+	Truncated bool   // The body exceeded MaxFetchBytes
+}
+
+type fetchURLArgs struct {
+	URL        string `json:"url" description:"The http or https URL to fetch"`
+	MaxChars   int    `json:"max_chars,omitempty" description:"Maximum number of characters to return (default: 20000, max: 100000)" schema:"minimum=1,maximum=100000"`
+	StartIndex int    `json:"start_index,omitempty" description:"Character offset to start from, for continuing a long page (default: 0)" schema:"minimum=0"`
+}
+
+func fetchURLHandler(ctx context.Context, args fetchURLArgs) (string, error) {
+	target, err := parseFetchURL(args.URL)
+	if err != nil {
+		return "", err
+	}
+
+	maxChars := DefaultFetchChars
+	if args.MaxChars >= 1 {
+		maxChars = min(args.MaxChars, MaxFetchChars)
+	}
+	start := 0
+	if args.StartIndex > 0 {
+		start = args.StartIndex
+	}
+
+	page, cached, err := fetchPage(ctx, target)
+	if err != nil {
+		return "", err
+	}
+	return formatFetchedPage(page, cached, start, maxChars), nil
+}
+
+// parseFetchURL accepts absolute http and https URLs, defaulting to https
+// when the scheme is missing
+func parseFetchURL(rawURL string) (*url.URL, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return nil, fmt.Errorf("url cannot be empty")
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported url scheme %q; only http and https can be fetched", target.Scheme)
+	}
+	if target.Host == "" {
+		return nil, fmt.Errorf("invalid url: missing host")
+	}
+	target.Fragment = ""
+	return target, nil
+}
+
+// fetchPage downloads and converts target, serving recent pages from the
+// cache. It reports whether the page came from the cache.
+func fetchPage(ctx context.Context, target *url.URL) (*fetchedPage, bool, error) {
+	key := target.String()
+	if page, ok := fetchCache.page(key); ok {
+		return page, true, nil
+	}
+	if Offline() {
+		return nil, false, fmt.Errorf("offline mode: %s has not been fetched in this session", key)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, FetchTimeout)
+	defer cancel()
+
+	if err := checkRobots(ctx, target); err != nil {
+		return nil, false, err
+	}
+
+	client := &http.Client{
+		Transport: fetchTransport(),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return checkRobots(req.Context(), req.URL)
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", key, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("User-Agent", fetchUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.5")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, false, fmt.Errorf("fetching %s timed out after %s", key, FetchTimeout)
+		}
+		return nil, false, fmt.Errorf("failed to fetch %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, false, fmt.Errorf("failed to fetch %s: server returned %s", key, resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxFetchBytes+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	page := &fetchedPage{URL: resp.Request.URL.String()}
+	if len(body) > MaxFetchBytes {
+		body = body[:MaxFetchBytes]
+		page.Truncated = true
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+	text := strings.ToValidUTF8(string(body), "�")
+
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		doc, err := html.Parse(strings.NewReader(text))
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to parse %s: %w", key, err)
+		}
+		page.Title, page.Content = htmlToMarkdown(doc, resp.Request.URL)
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" ||
+		mediaType == "application/xml" || strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml"):
+		page.Content = text
+	default:
+		return nil, false, fmt.Errorf("cannot convert %s: unsupported content type %s", key, mediaType)
+	}
+
+	fetchCache.storePage(key, page)
+	return page, false, nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, also used for cloud
+// metadata services
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// fetchTransport returns the transport for fetch_url. Unless the policy
+// allows private networks, it refuses to connect to loopback, link-local,
+// private and other non-public addresses. The check runs on every
+// connection, after name resolution, so redirects and host names that
+// resolve to such addresses are caught too. Proxies are not used, since
+// they would hide the address being reached.
+func fetchTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	if !currentPolicy().FetchPrivateNetworks {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: checkFetchAddress}
+		transport.DialContext = dialer.DialContext
+	}
+	return transport
+}
+
+// checkFetchAddress is a net.Dialer Control function that rejects
+// connections to addresses outside the public internet
+func checkFetchAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("refusing to connect to %s: %w", address, err)
+	}
+	addr := addrPort.Addr().Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() || sharedAddressSpace.Contains(addr) {
+		return fmt.Errorf("refusing to connect to non-public address %s (set Policies.fetchPrivateNetworks to allow it)", addr)
+	}
+	return nil
+}
+
+// formatFetchedPage returns up to maxChars characters of the page content
+// starting at start, with a marker telling the model how to continue.
+func formatFetchedPage(page *fetchedPage, cached bool, start, maxChars int) string {
+	var result strings.Builder
+	if page.Title != "" {
+		result.WriteString(fmt.Sprintf("Title: %s\n", page.Title))
+	}
+	result.WriteString(fmt.Sprintf("URL: %s\n", page.URL))
+	if cached {
+		result.WriteString("(served from cache)\n")
+	}
+	if page.Truncated {
+		result.WriteString(fmt.Sprintf("(page larger than %d bytes; only the beginning was downloaded)\n", MaxFetchBytes))
+	}
+	result.WriteString("\n")
+
+	content := []rune(page.Content)
+	total := len(content)
+	if strings.TrimSpace(page.Content) == "" {
+		result.WriteString("The page has no readable text content.\n")
+		return result.String()
+	}
+	if start >= total {
+		result.WriteString(fmt.Sprintf("The page has %d characters; start_index %d is past the end.\n", total, start))
+		return result.String()
+	}
+
+	end := min(start+maxChars, total)
+	result.WriteString(string(content[start:end]))
+	if end < total {
+		result.WriteString(fmt.Sprintf("\n\n... [more available: showing characters %d-%d of %d; call fetch_url with start_index=%d to continue]\n",
+			start, end, total, end))
+	}
+	return result.String()
+}
+
+// fetchCache holds recently fetched pages and robots.txt rules
+var fetchCache = &pageCache{entries: make(map[string]pageCacheEntry)}
+
+// pageCache is a small in-memory cache with per-entry expiry
+type pageCache struct {
+	mu      sync.Mutex
+	entries map[string]pageCacheEntry
+}
+
+type pageCacheEntry struct {
+	page    *fetchedPage
+	robots  *robotsRules
+	expires time.Time
+}
+
+func (c *pageCache) get(key string) (pageCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return pageCacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *pageCache) put(key string, entry pageCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry.expires = time.Now().Add(FetchCacheTTL)
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxFetchCacheEntries {
+		// Evict the entry closest to expiring
+		oldest := ""
+		for k, e := range c.entries {
+			if oldest == "" || e.expires.Before(c.entries[oldest].expires) {
+				oldest = k
+			}
+		}
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = entry
+}
+
+func (c *pageCache) page(key string) (*fetchedPage, bool) {
+	entry, ok := c.get("page:" + key)
+	return entry.page, ok
+}
+
+func (c *pageCache) storePage(key string, page *fetchedPage) {
+	c.put("page:"+key, pageCacheEntry{page: page})
+}
+
+// checkRobots refuses URLs that the site's robots.txt disallows for goss
+func checkRobots(ctx context.Context, target *url.URL) error {
+	origin := target.Scheme + "://" + target.Host
+	entry, ok := fetchCache.get("robots:" + origin)
+	if !ok {
+		entry = pageCacheEntry{robots: loadRobots(ctx, origin)}
+		fetchCache.put("robots:"+origin, entry)
+	}
+
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+	if !entry.robots.allowed(path) {
+		return fmt.Errorf("%s disallows fetching %s in its robots.txt", origin, path)
+	}
+	return nil
+}
+
+// loadRobots downloads and parses origin/robots.txt. A missing or
+// unreachable file allows everything.
+func loadRobots(ctx context.Context, origin string) *robotsRules {
+	req, err := http.NewRequestWithContext(ctx, "GET", origin+"/robots.txt", nil)
+	if err != nil {
+		return &robotsRules{}
+	}
+	req.Header.Set("User-Agent", fetchUserAgent)
+
+	client := &http.Client{Transport: fetchTransport(), Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return &robotsRules{}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &robotsRules{}
+	}
+	return parseRobots(io.LimitReader(resp.Body, 512*1024), "goss")
+}
+
+// robotsRules are the Allow and Disallow lines that apply to one agent
+type robotsRules struct {
+	rules []robotsRule
+}
+
+type robotsRule struct {
+	path    string // The pattern as written, used for precedence
+	pattern *regexp.Regexp
+	allow   bool
+}
+
+// parseRobots reads the group for agent (lower case), falling back to the "*" group.
+// Consecutive User-agent lines share the rules that follow them.
+func parseRobots(r io.Reader, agent string) *robotsRules {
+	var specific, wildcard []robotsRule
+	var agents []string
+	inRules := false
+	matchedSpecific := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		field = strings.ToLower(strings.TrimSpace(field))
+		value = strings.TrimSpace(value)
+
+		switch field {
+		case "user-agent":
+			if inRules {
+				agents, inRules = nil, false
+			}
+			agents = append(agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue // An empty Disallow allows everything
+			}
+			rule := robotsRule{path: value, pattern: robotsPattern(value), allow: field == "allow"}
+			for _, a := range agents {
+				switch {
+				case a == "*":
+					wildcard = append(wildcard, rule)
+				case strings.HasPrefix(a, agent):
+					specific = append(specific, rule)
+					matchedSpecific = true
+				}
+			}
+		}
+	}
+
+	if matchedSpecific {
+		return &robotsRules{rules: specific}
+	}
+	return &robotsRules{rules: wildcard}
+}
+
+// robotsPattern converts a robots.txt path with "*" and "$" to a regexp
+func robotsPattern(path string) *regexp.Regexp {
+	anchored := strings.HasSuffix(path, "$")
+	path = strings.TrimSuffix(path, "$")
+
+	parts := strings.Split(path, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// allowed applies the most specific matching rule; Allow wins ties
+func (r *robotsRules) allowed(path string) bool {
+	allow, length := true, -1
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		n := utf8.RuneCountInString(rule.path)
+		if n > length || n == length && rule.allow {
+			allow, length = rule.allow, n
+		}
+	}
+	return allow
+}
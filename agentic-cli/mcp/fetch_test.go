@@ -0,0 +1,293 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const samplePage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>Release notes &amp; more</title>
+  <style>body { color: red; }</style>
+  <script>trackVisitor();</script>
+</head>
+<body>
+  <header><a href="/">Home</a> | <a href="/blog">Blog</a></header>
+  <nav><ul><li><a href="/about">About</a></li></ul></nav>
+  <article>
+    <h1>Version 2.0</h1>
+    <p>This release adds <strong>streaming</strong> and <em>faster</em> startup.
+       See the <a href="/docs/install">install guide</a> for details.</p>
+    <h2>Changes</h2>
+    <ul>
+      <li>New <code>--json</code> flag</li>
+      <li>Fixed bugs
+        <ol><li>crash on exit</li><li>slow search</li></ol>
+      </li>
+    </ul>
+    <pre><code class="language-go">func main() {
+	fmt.Println("hi")
+}</code></pre>
+    <blockquote><p>Upgrading is recommended.</p></blockquote>
+    <table>
+      <tr><th>OS</th><th>Status</th></tr>
+      <tr><td>Linux</td><td>supported</td></tr>
+    </table>
+    <img src="/img/chart.png" alt="Benchmark chart">
+  </article>
+  <aside>Subscribe to our newsletter</aside>
+  <footer>Copyright 2024</footer>
+</body>
+</html>`
+
+func TestHTMLToMarkdown(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(samplePage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := parseFetchURL("https://example.com/blog/v2")
+
+	title, markdown := htmlToMarkdown(doc, base)
+	if title != "Release notes & more" {
+		t.Errorf("Unexpected title %q", title)
+	}
+
+	expected := "# Version 2.0\n\n" +
+		"This release adds **streaming** and *faster* startup. See the [install guide](https://example.com/docs/install) for details.\n\n" +
+		"## Changes\n\n" +
+		"- New `--json` flag\n" +
+		"- Fixed bugs\n" +
+		"  1. crash on exit\n" +
+		"  2. slow search\n\n" +
+		"```go\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n```\n\n" +
+		"> Upgrading is recommended.\n\n" +
+		"| OS | Status |\n| --- | --- |\n| Linux | supported |\n\n" +
+		"![Benchmark chart](https://example.com/img/chart.png)\n"
+	if markdown != expected {
+		t.Errorf("Unexpected markdown:\n%s\nexpected:\n%s", markdown, expected)
+	}
+
+	for _, boilerplate := range []string{"trackVisitor", "color: red", "Subscribe", "Copyright", "About"} {
+		if strings.Contains(markdown, boilerplate) {
+			t.Errorf("Expected %q to be left out", boilerplate)
+		}
+	}
+}
+
+func TestHTMLToMarkdownWithoutArticle(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(`<html><body>
+		<header>Site name</header>
+		<div><p>First paragraph.</p><p>Second<br>line.</p></div>
+		<a href="#top">Back to top</a>
+	</body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, markdown := htmlToMarkdown(doc, nil)
+	expected := "First paragraph.\n\nSecond\nline.\n\nBack to top\n"
+	if markdown != expected {
+		t.Errorf("Unexpected markdown %q, expected %q", markdown, expected)
+	}
+}
+
+func TestParseRobots(t *testing.T) {
+	robots := `# Example
+User-agent: *
+Disallow: /private/
+Allow: /private/public-page
+Disallow: /*.pdf$
+
+User-agent: BadBot
+User-agent: goss
+Disallow: /no-goss
+`
+	tests := []struct {
+		agent   string
+		path    string
+		allowed bool
+	}{
+		{"somebot", "/index.html", true},
+		{"somebot", "/private/secret", false},
+		{"somebot", "/private/public-page", true},
+		{"somebot", "/files/report.pdf", false},
+		{"somebot", "/files/report.pdf?download=1", true},
+		{"goss", "/private/secret", true},
+		{"goss", "/no-goss/page", false},
+	}
+
+	for _, tt := range tests {
+		rules := parseRobots(strings.NewReader(robots), tt.agent)
+		if got := rules.allowed(tt.path); got != tt.allowed {
+			t.Errorf("%s: allowed(%q) = %t, expected %t", tt.agent, tt.path, got, tt.allowed)
+		}
+	}
+}
+
+func TestFetchURL(t *testing.T) {
+	var pageHits atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /admin\n"))
+	})
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		pageHits.Add(1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(samplePage))
+	})
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/article", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/to-admin", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/admin/panel", http.StatusFound)
+	})
+	mux.HandleFunc("/notes.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strings.Repeat("0123456789", 10)))
+	})
+	mux.HandleFunc("/huge", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strings.Repeat("x", MaxFetchBytes+100)))
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// The test server listens on loopback
+	SetPolicy(Policy{FetchPrivateNetworks: true})
+	t.Cleanup(func() { SetPolicy(DefaultPolicy()) })
+
+	ctx := context.Background()
+	fetch := func(args map[string]interface{}) (string, error) {
+		return callTool(ctx, "fetch_url", args)
+	}
+
+	t.Run("converts html", func(t *testing.T) {
+		result, err := fetch(map[string]interface{}{"url": server.URL + "/article"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(result, "Title: Release notes & more\nURL: "+server.URL+"/article\n\n# Version 2.0") {
+			t.Errorf("Unexpected result:\n%s", result)
+		}
+		if !strings.Contains(result, "[install guide]("+server.URL+"/docs/install)") {
+			t.Errorf("Expected links resolved against the page:\n%s", result)
+		}
+	})
+
+	t.Run("serves repeats from cache", func(t *testing.T) {
+		before := pageHits.Load()
+		result, err := fetch(map[string]interface{}{"url": server.URL + "/article"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pageHits.Load() != before || !strings.Contains(result, "(served from cache)") {
+			t.Errorf("Expected the page to come from the cache (%d hits):\n%s", pageHits.Load(), result)
+		}
+	})
+
+	t.Run("follows redirects", func(t *testing.T) {
+		result, err := fetch(map[string]interface{}{"url": server.URL + "/old"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(result, "URL: "+server.URL+"/article\n") {
+			t.Errorf("Expected the final URL to be reported:\n%s", result)
+		}
+	})
+
+	t.Run("pages long content", func(t *testing.T) {
+		result, err := fetch(map[string]interface{}{"url": server.URL + "/notes.txt", "max_chars": float64(30)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(result, "\n012345678901234567890123456789\n") ||
+			!strings.Contains(result, "showing characters 0-30 of 100; call fetch_url with start_index=30") {
+			t.Errorf("Unexpected first chunk:\n%s", result)
+		}
+
+		result, err = fetch(map[string]interface{}{"url": server.URL + "/notes.txt", "start_index": float64(90)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(result, "\n0123456789") {
+			t.Errorf("Unexpected last chunk:\n%s", result)
+		}
+	})
+
+	t.Run("limits download size", func(t *testing.T) {
+		result, err := fetch(map[string]interface{}{"url": server.URL + "/huge", "max_chars": float64(10)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(result, "only the beginning was downloaded") ||
+			!strings.Contains(result, "of 5242880;") {
+			t.Errorf("Expected the body to be capped:\n%s", result)
+		}
+	})
+
+	errorCases := []struct {
+		name    string
+		url     string
+		errPart string
+	}{
+		{"robots disallow", server.URL + "/admin/panel", "robots.txt"},
+		{"redirect into disallowed path", server.URL + "/to-admin", "robots.txt"},
+		{"not found", server.URL + "/missing", "404"},
+		{"binary content", server.URL + "/image.png", "unsupported content type image/png"},
+		{"unsupported scheme", "file:///etc/passwd", "unsupported url scheme"},
+		{"empty", " ", "cannot be empty"},
+	}
+	for _, tt := range errorCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := fetch(map[string]interface{}{"url": tt.url})
+			if err == nil || !strings.Contains(err.Error(), tt.errPart) {
+				t.Errorf("Expected error containing %q, got %v", tt.errPart, err)
+			}
+		})
+	}
+
+	t.Run("refuses private addresses", func(t *testing.T) {
+		SetPolicy(DefaultPolicy())
+		for _, target := range []string{server.URL + "/notes.txt?again", "http://169.254.169.254/latest/meta-data/", "http://[::1]:1/"} {
+			_, err := fetch(map[string]interface{}{"url": target})
+			if err == nil || !strings.Contains(err.Error(), "refusing to connect to non-public address") {
+				t.Errorf("%s: expected the address to be refused, got %v", target, err)
+			}
+		}
+	})
+}
+
+func TestCheckFetchAddress(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34:443":         true,
+		"[2606:4700::1111]:443":     true,
+		"127.0.0.1:80":              false,
+		"10.1.2.3:80":               false,
+		"172.16.0.1:80":             false,
+		"192.168.1.1:80":            false,
+		"169.254.169.254:80":        false,
+		"100.100.100.200:80":        false,
+		"0.0.0.0:80":                false,
+		"[::1]:80":                  false,
+		"[fe80::1]:80":              false,
+		"[fd00::1]:80":              false,
+		"[::ffff:127.0.0.1]:80":     false,
+		"[::ffff:93.184.216.34]:80": true,
+	}
+	for address, allowed := range tests {
+		if err := checkFetchAddress("tcp", address, nil); (err == nil) != allowed {
+			t.Errorf("%s: allowed=%t, got %v", address, allowed, err)
+		}
+	}
+}
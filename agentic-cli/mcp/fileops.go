@@ -0,0 +1,429 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultTreeDepth is how deep directory_tree descends by default
+	DefaultTreeDepth = 3
+	// MaxTreeDepth caps the depth argument of directory_tree
+	MaxTreeDepth = 10
+	// MaxTreeEntries limits how many entries directory_tree prints
+	MaxTreeEntries = 500
+)
+
+// transferArgs are the arguments of move_path and copy_path
+type transferArgs struct {
+	Source      string `json:"source" description:"Path to move or copy"`
+	Destination string `json:"destination" description:"Path to move or copy to"`
+	Overwrite   bool   `json:"overwrite,omitempty" description:"Replace an existing destination file"`
+}
+
+type deletePathArgs struct {
+	Path      string `json:"path" description:"Path to delete"`
+	Recursive bool   `json:"recursive,omitempty" description:"Required to delete a non-empty directory"`
+}
+
+type statPathArgs struct {
+	Path string `json:"path" description:"Path to inspect"`
+}
+
+type directoryTreeArgs struct {
+	Path  string `json:"path,omitempty" description:"Root directory (default: current directory)"`
+	Depth int    `json:"depth,omitempty" description:"Levels to descend (default 3, max 10)"`
+	ignoredArg
+}
+
+func movePathHandler(ctx context.Context, args transferArgs) (string, error) {
+	source, destination, err := sourceAndDestination(args)
+	if err != nil {
+		return "", err
+	}
+	if err := checkDestination(destination, args.Destination, args.Overwrite); err != nil {
+		return "", err
+	}
+	if err := confirmMutation(ctx, "move_path", fmt.Sprintf("move %s to %s", args.Source, args.Destination)); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return "", fmt.Errorf("cannot create directory for %s: %w", args.Destination, err)
+	}
+	if err := movePath(source, destination); err != nil {
+		return "", fmt.Errorf("failed to move %s to %s: %w", args.Source, args.Destination, err)
+	}
+
+	return fmt.Sprintf("Successfully moved %s to %s", args.Source, args.Destination), nil
+}
+
+func copyPathHandler(ctx context.Context, args transferArgs) (string, error) {
+	source, destination, err := sourceAndDestination(args)
+	if err != nil {
+		return "", err
+	}
+	if err := checkDestination(destination, args.Destination, args.Overwrite); err != nil {
+		return "", err
+	}
+	if err := confirmMutation(ctx, "copy_path", fmt.Sprintf("copy %s to %s", args.Source, args.Destination)); err != nil {
+		return "", err
+	}
+
+	count, err := copyPath(source, destination)
+	if err != nil {
+		return "", fmt.Errorf("failed to copy %s to %s: %w", args.Source, args.Destination, err)
+	}
+
+	return fmt.Sprintf("Successfully copied %s to %s (%d files)", args.Source, args.Destination, count), nil
+}
+
+func deletePathHandler(ctx context.Context, args deletePathArgs) (string, error) {
+	path, recursive := args.Path, args.Recursive
+
+	// Security validation
+	resolved, err := validateLinkPath(path)
+	if err != nil {
+		return "", fmt.Errorf("security validation failed: %w", err)
+	}
+	if filepath.Clean(path) == "." {
+		return "", fmt.Errorf("refusing to delete the working directory")
+	}
+
+	info, err := os.Lstat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("cannot access %s: %w", path, err)
+	}
+	if info.IsDir() && !recursive {
+		entries, err := os.ReadDir(resolved)
+		if err != nil {
+			return "", fmt.Errorf("failed to read directory %s: %w", path, err)
+		}
+		if len(entries) > 0 {
+			return "", fmt.Errorf("directory %s is not empty; set recursive to delete it with its contents", path)
+		}
+	}
+	if err := confirmMutation(ctx, "delete_path", "delete "+path); err != nil {
+		return "", err
+	}
+
+	trashed, err := moveToTrash(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to delete %s: %w", path, err)
+	}
+
+	return fmt.Sprintf("Moved %s to the trash at %s; it can be restored from there", path, trashed), nil
+}
+
+func statPathHandler(ctx context.Context, args statPathArgs) (string, error) {
+	path := args.Path
+
+	// Security validation
+	resolved, err := validateLinkPath(path)
+	if err != nil {
+		return "", fmt.Errorf("security validation failed: %w", err)
+	}
+
+	info, err := os.Lstat(resolved)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Sprintf("%s does not exist", path), nil
+		}
+		return "", fmt.Errorf("cannot access %s: %w", path, err)
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Path: %s\n", path))
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, _ := os.Readlink(resolved)
+		result.WriteString(fmt.Sprintf("Type: symlink -> %s\n", target))
+	case info.IsDir():
+		result.WriteString("Type: directory\n")
+		if entries, err := os.ReadDir(resolved); err == nil {
+			result.WriteString(fmt.Sprintf("Entries: %d\n", len(entries)))
+		}
+	default:
+		result.WriteString("Type: file\n")
+		result.WriteString(fmt.Sprintf("Size: %d bytes\n", info.Size()))
+	}
+	result.WriteString(fmt.Sprintf("Permissions: %s\n", info.Mode().Perm()))
+	result.WriteString(fmt.Sprintf("Modified: %s\n", info.ModTime().Format(time.RFC3339)))
+
+	return result.String(), nil
+}
+
+func directoryTreeHandler(ctx context.Context, args directoryTreeArgs) (string, error) {
+	path := "."
+	if args.Path != "" {
+		path = args.Path
+	}
+	depth := DefaultTreeDepth
+	if args.Depth >= 1 {
+		depth = min(args.Depth, MaxTreeDepth)
+	}
+
+	// Security validation
+	resolved, err := validatePath(path)
+	if err != nil {
+		return "", fmt.Errorf("security validation failed: %w", err)
+	}
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("cannot access %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", path)
+	}
+
+	tree := &treePrinter{maxDepth: depth, filter: newWalkFilter(args.IncludeIgnored)}
+	tree.out.WriteString(filepath.ToSlash(path) + "/\n")
+	tree.walk(resolved, "", 1)
+
+	result := tree.out.String()
+	if tree.truncated {
+		result += fmt.Sprintf("... [stopped after %d entries; list a subdirectory for more]\n", MaxTreeEntries)
+	}
+	if note := tree.filter.note(); note != "" {
+		result += note + "\n"
+	}
+	return result, nil
+}
+
+// treePrinter renders a directory hierarchy with box-drawing characters
+type treePrinter struct {
+	out       strings.Builder
+	maxDepth  int
+	filter    *walkFilter
+	entries   int
+	truncated bool
+}
+
+func (t *treePrinter) walk(dir, indent string, depth int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	// Restricted and ignored entries are left out entirely
+	visible := entries[:0]
+	for _, entry := range entries {
+		if !t.filter.skip(filepath.Join(dir, entry.Name()), entry.IsDir()) {
+			visible = append(visible, entry)
+		}
+	}
+	sort.Slice(visible, func(i, j int) bool { return visible[i].Name() < visible[j].Name() })
+
+	for i, entry := range visible {
+		if t.entries >= MaxTreeEntries {
+			t.truncated = true
+			return
+		}
+		t.entries++
+
+		branch, next := "├── ", "│   "
+		if i == len(visible)-1 {
+			branch, next = "└── ", "    "
+		}
+
+		name := entry.Name()
+		if !entry.IsDir() {
+			t.out.WriteString(indent + branch + name + "\n")
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		if depth >= t.maxDepth {
+			if children, err := os.ReadDir(path); err == nil && len(children) > 0 {
+				t.out.WriteString(fmt.Sprintf("%s%s%s/ (%d entries)\n", indent, branch, name, len(children)))
+				continue
+			}
+		}
+		t.out.WriteString(indent + branch + name + "/\n")
+		if depth < t.maxDepth {
+			t.walk(path, indent+next, depth+1)
+		}
+	}
+}
+
+// sourceAndDestination validates the source and destination arguments and
+// returns their resolved paths. A symlink source is moved or copied as a
+// link.
+func sourceAndDestination(args transferArgs) (string, string, error) {
+	// Security validation
+	source, err := validateLinkPath(args.Source)
+	if err != nil {
+		return "", "", fmt.Errorf("security validation failed: %w", err)
+	}
+	destination, err := validateLinkPath(args.Destination)
+	if err != nil {
+		return "", "", fmt.Errorf("security validation failed: %w", err)
+	}
+	if _, err := os.Lstat(source); err != nil {
+		return "", "", fmt.Errorf("cannot access %s: %w", args.Source, err)
+	}
+	if source == destination {
+		return "", "", fmt.Errorf("source and destination are the same")
+	}
+	if within(source, destination) {
+		return "", "", fmt.Errorf("cannot move or copy %s into itself (%s)", args.Source, args.Destination)
+	}
+	return source, destination, nil
+}
+
+// checkDestination refuses to replace an existing path unless overwrite is
+// set. name is the destination as given, for messages.
+func checkDestination(destination, name string, overwrite bool) error {
+	info, err := os.Lstat(destination)
+	if err != nil {
+		return nil
+	}
+	if !overwrite {
+		return fmt.Errorf("%s already exists; set overwrite to replace it", name)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is an existing directory and cannot be overwritten", name)
+	}
+	return nil
+}
+
+// movePath renames source, copying across file systems when needed
+func movePath(source, destination string) error {
+	err := os.Rename(source, destination)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if _, err := copyPath(source, destination); err != nil {
+		return err
+	}
+	return os.RemoveAll(source)
+}
+
+// copyPath copies a file, symlink or directory tree and returns the
+// number of files copied
+func copyPath(source, destination string) (int, error) {
+	info, err := os.Lstat(source)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(source)
+		if err != nil {
+			return 0, err
+		}
+		os.Remove(destination)
+		return 1, os.Symlink(target, destination)
+
+	case info.IsDir():
+		if err := os.MkdirAll(destination, info.Mode().Perm()); err != nil {
+			return 0, err
+		}
+		entries, err := os.ReadDir(source)
+		if err != nil {
+			return 0, err
+		}
+		count := 0
+		for _, entry := range entries {
+			n, err := copyPath(filepath.Join(source, entry.Name()), filepath.Join(destination, entry.Name()))
+			count += n
+			if err != nil {
+				return count, err
+			}
+		}
+		return count, nil
+
+	default:
+		if info.Size() > currentPolicy().MaxFileSize {
+			return 0, fmt.Errorf("%s is larger than %d bytes", source, currentPolicy().MaxFileSize)
+		}
+		return 1, copyFile(source, destination, info.Mode().Perm())
+	}
+}
+
+func copyFile(source, destination string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(destination, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// moveToTrash moves path into a timestamped folder of the trash directory
+// and returns the new location. Paths in the working directory keep their
+// path relative to it; paths in other allowed roots go under roots/ with the
+// root's absolute path, so no two roots share a location.
+func moveToTrash(path string) (string, error) {
+	trashDir := currentPolicy().TrashDir
+	if trashDir == "" {
+		return "", fmt.Errorf("no trash directory is configured, so the deletion could not be undone")
+	}
+	key, err := trashKey(path)
+	if err != nil {
+		return "", err
+	}
+
+	stamp := time.Now().Format("20060102-150405.000")
+	folder := filepath.Join(trashDir, stamp)
+	trashed := filepath.Join(folder, key)
+	if trashed == folder || !within(folder, trashed) {
+		return "", fmt.Errorf("cannot place %s in the trash", path)
+	}
+	if _, err := os.Lstat(trashed); err == nil {
+		return "", fmt.Errorf("%s is already in the trash", trashed)
+	}
+	if err := os.MkdirAll(filepath.Dir(trashed), 0700); err != nil {
+		return "", fmt.Errorf("cannot create trash directory: %w", err)
+	}
+	if err := movePath(path, trashed); err != nil {
+		return "", err
+	}
+	return trashed, nil
+}
+
+// trashKey returns where abs, a path resolved by validateLinkPath, goes
+// inside a trash folder
+func trashKey(abs string) (string, error) {
+	if wd, err := os.Getwd(); err == nil {
+		if wd, err := resolvePath(wd); err == nil && within(wd, abs) && abs != wd {
+			return filepath.Rel(wd, abs)
+		}
+	}
+	sandbox, err := currentSandbox()
+	if err != nil {
+		return "", err
+	}
+	root, ok := sandbox.rootOf(abs)
+	if !ok || abs == root {
+		return "", fmt.Errorf("%s is not inside an allowed root", abs)
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return "", err
+	}
+	rootPath := strings.TrimPrefix(root, filepath.VolumeName(root))
+	return filepath.Join("roots", rootPath, rel), nil
+}
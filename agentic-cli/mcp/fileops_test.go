@@ -0,0 +1,170 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileOperations(t *testing.T) {
+	chdirTemp(t, map[string]string{
+		"a.txt":         "alpha\n",
+		"b.txt":         "beta\n",
+		"src/main.go":   "package main\n",
+		"src/util/u.go": "package util\n",
+	})
+	trash := t.TempDir()
+	SetPolicy(Policy{TrashDir: trash})
+	t.Cleanup(func() { SetPolicy(DefaultPolicy()) })
+	ctx := context.Background()
+
+	if _, err := callTool(ctx, "move_path", map[string]interface{}{"source": "a.txt", "destination": "b.txt"}); err == nil {
+		t.Error("Expected move onto an existing file to fail without overwrite")
+	}
+	if _, err := callTool(ctx, "move_path", map[string]interface{}{"source": "a.txt", "destination": "docs/a.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat("docs/a.txt"); err != nil {
+		t.Errorf("Expected moved file: %v", err)
+	}
+
+	result, err := callTool(ctx, "copy_path", map[string]interface{}{"source": "src", "destination": "backup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, "(2 files)") {
+		t.Errorf("Expected 2 files copied, got %q", result)
+	}
+	if content, err := os.ReadFile("backup/util/u.go"); err != nil || string(content) != "package util\n" {
+		t.Errorf("Expected copied content, got %q (%v)", content, err)
+	}
+
+	for _, tool := range []string{"copy_path", "move_path"} {
+		for _, destination := range []string{"src/sub", "src/util/deeper", "./src/../src/util"} {
+			if _, err := callTool(ctx, tool, map[string]interface{}{"source": "src", "destination": destination}); err == nil ||
+				!strings.Contains(err.Error(), "into itself") {
+				t.Errorf("%s to %s: expected a destination inside the source to be rejected, got %v", tool, destination, err)
+			}
+		}
+	}
+	if _, err := os.Stat("src/sub"); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be copied into the source")
+	}
+
+	if _, err := callTool(ctx, "delete_path", map[string]interface{}{"path": "src"}); err == nil {
+		t.Error("Expected deleting a non-empty directory without recursive to fail")
+	}
+	if _, err := callTool(ctx, "delete_path", map[string]interface{}{"path": "src", "recursive": true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat("src"); !os.IsNotExist(err) {
+		t.Error("Expected src to be removed")
+	}
+	matches, _ := filepath.Glob(filepath.Join(trash, "*", "src", "util", "u.go"))
+	if len(matches) != 1 {
+		t.Errorf("Expected deleted files to be recoverable from the trash, found %v", matches)
+	}
+
+	if _, err := callTool(ctx, "move_path", map[string]interface{}{"source": "b.txt", "destination": "../b.txt"}); err == nil {
+		t.Error("Expected a destination outside the working directory to be rejected")
+	}
+}
+
+func TestStatAndTree(t *testing.T) {
+	chdirTemp(t, map[string]string{
+		"README.md":             "# readme\n",
+		"cmd/app/main.go":       "package main\n",
+		"internal/a/b/c/d.go":   "package c\n",
+		"node_modules/lib/x.js": "x",
+	})
+	ctx := context.Background()
+
+	result, err := callTool(ctx, "stat_path", map[string]interface{}{"path": "README.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, "Type: file\nSize: 9 bytes\n") {
+		t.Errorf("Unexpected stat result %q", result)
+	}
+
+	result, err = callTool(ctx, "directory_tree", map[string]interface{}{"depth": float64(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "./\n" +
+		"├── README.md\n" +
+		"├── cmd/\n" +
+		"│   └── app/ (1 entries)\n" +
+		"└── internal/\n" +
+		"    └── a/ (1 entries)\n"
+	if result != want {
+		t.Errorf("Expected tree:\n%s\ngot:\n%s", want, result)
+	}
+}
+
+func TestConfirmMutations(t *testing.T) {
+	chdirTemp(t, map[string]string{"a.txt": "alpha\n"})
+	SetPolicy(Policy{ConfirmMutations: true, TrashDir: t.TempDir()})
+	t.Cleanup(func() { SetPolicy(DefaultPolicy()) })
+	requests := withApprover(t, false)
+
+	if _, err := callTool(context.Background(), "delete_path", map[string]interface{}{"path": "a.txt"}); err == nil {
+		t.Error("Expected a declined deletion to fail")
+	}
+	if _, err := os.Stat("a.txt"); err != nil {
+		t.Error("Expected a.txt to survive a declined deletion")
+	}
+	if _, err := callTool(context.Background(), "write_file", map[string]interface{}{"path": "new/dir/b.txt", "content": "x"}); err == nil {
+		t.Error("Expected a declined write to fail")
+	}
+	if _, err := os.Stat("new"); !os.IsNotExist(err) {
+		t.Error("Expected a declined write to leave the filesystem unchanged")
+	}
+	if _, err := callTool(context.Background(), "stat_path", map[string]interface{}{"path": "a.txt"}); err != nil {
+		t.Errorf("Expected read-only tools to run without approval: %v", err)
+	}
+	if len(*requests) != 2 || (*requests)[0].Tool != "delete_path" || (*requests)[1].Tool != "write_file" {
+		t.Errorf("Expected approval requests for delete_path and write_file, got %+v", *requests)
+	}
+}
+
+func TestTrashRoots(t *testing.T) {
+	chdirTemp(t, map[string]string{"a.txt": "alpha\n"})
+	wd, _ := os.Getwd()
+	other, _ := filepath.EvalSymlinks(t.TempDir())
+	if err := os.WriteFile(filepath.Join(other, "a.txt"), []byte("other\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	trash := t.TempDir()
+	SetPolicy(Policy{AllowedRoots: []string{wd, other}, TrashDir: trash})
+	t.Cleanup(func() { SetPolicy(DefaultPolicy()) })
+
+	for _, path := range []string{"a.txt", filepath.Join(other, "a.txt")} {
+		if _, err := callTool(context.Background(), "delete_path", map[string]interface{}{"path": path}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var trashed []string
+	filepath.Walk(trash, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			rel, _ := filepath.Rel(trash, path)
+			trashed = append(trashed, rel)
+		}
+		return nil
+	})
+	if len(trashed) != 2 || !strings.Contains(strings.Join(trashed, "\n"), filepath.Join("roots", other, "a.txt")) {
+		t.Errorf("Expected both files in the trash, keyed by root, got %v", trashed)
+	}
+
+	SetPolicy(Policy{})
+	os.WriteFile("b.txt", []byte("beta\n"), 0644)
+	if _, err := callTool(context.Background(), "delete_path", map[string]interface{}{"path": "b.txt"}); err == nil ||
+		!strings.Contains(err.Error(), "no trash directory") {
+		t.Errorf("Expected deletion without a trash directory to fail, got %v", err)
+	}
+	if _, err := os.Stat("b.txt"); err != nil {
+		t.Error("Expected b.txt to be kept")
+	}
+}
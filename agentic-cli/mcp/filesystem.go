@@ -13,143 +13,105 @@ import (
 // CreateFilesystemTools returns a slice of filesystem MCP tools
 func CreateFilesystemTools() []openai.Tool {
 	return []openai.Tool{
-		{
-			Type: "function",
-			Function: openai.ToolFunction{
-				Name:        "read_file",
-				Description: "Read the contents of a file",
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"path": map[string]interface{}{
-							"type":        "string",
-							"description": "Path to the file to read",
-						},
-					},
-					"required": []string{"path"},
-				},
-				Handler: readFileHandler,
-			},
-		},
-		{
-			Type: "function",
-			Function: openai.ToolFunction{
-				Name:        "write_file",
-				Description: "Write content to a file",
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"path": map[string]interface{}{
-							"type":        "string",
-							"description": "Path to the file to write",
-						},
-						"content": map[string]interface{}{
-							"type":        "string",
-							"description": "Content to write to the file",
-						},
-					},
-					"required": []string{"path", "content"},
-				},
-				Handler: writeFileHandler,
-			},
-		},
-		{
-			Type: "function",
-			Function: openai.ToolFunction{
-				Name:        "list_directory",
-				Description: "List files and directories in a given path",
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"path": map[string]interface{}{
-							"type":        "string",
-							"description": "Path to the directory to list",
-						},
-					},
-					"required": []string{"path"},
-				},
-				Handler: listDirectoryHandler,
-			},
-		},
-		{
-			Type: "function",
-			Function: openai.ToolFunction{
-				Name:        "search_files",
-				Description: "Search for files matching a pattern",
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"path": map[string]interface{}{
-							"type":        "string",
-							"description": "Directory path to search in",
-						},
-						"pattern": map[string]interface{}{
-							"type":        "string",
-							"description": "File name pattern to search for",
-						},
-					},
-					"required": []string{"path", "pattern"},
-				},
-				Handler: searchFilesHandler,
-			},
-		},
-		{
-			Type: "function",
-			Function: openai.ToolFunction{
-				Name:        "create_directory",
-				Description: "Create a new directory",
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"path": map[string]interface{}{
-							"type":        "string",
-							"description": "Path to the directory to create",
-						},
-					},
-					"required": []string{"path"},
-				},
-				Handler: createDirectoryHandler,
-			},
-		},
+		openai.NewTool("read_file",
+			"Read a file as numbered lines. Large files are returned in pages; use offset and limit to read further",
+			readFileHandler).AsReadOnly(),
+		openai.NewTool("write_file", "Write content to a file", writeFileHandler),
+		openai.NewTool("list_directory", "List files and directories in a given path", listDirectoryHandler).AsReadOnly(),
+		openai.NewTool("search_files", "Search for files matching a pattern", searchFilesHandler).AsReadOnly(),
+		openai.NewTool("grep_files",
+			"Search file contents with a regular expression and return matching lines as path:line:text",
+			grepFilesHandler).AsReadOnly(),
+		openai.NewTool("create_directory", "Create a new directory", createDirectoryHandler),
+		openai.NewTool("move_path", "Move or rename a file or directory", movePathHandler),
+		openai.NewTool("copy_path", "Copy a file or directory tree", copyPathHandler),
+		openai.NewTool("delete_path",
+			"Delete a file or directory by moving it to the trash, where it can be recovered",
+			deletePathHandler),
+		openai.NewTool("stat_path",
+			"Show the type, size, permissions and modification time of a path",
+			statPathHandler).AsReadOnly(),
+		openai.NewTool("directory_tree", "Show a directory hierarchy as a tree", directoryTreeHandler).AsReadOnly(),
 	}
 }
 
-func readFileHandler(ctx context.Context, args map[string]interface{}) (string, error) {
-	path, ok := args["path"].(string)
-	if !ok {
-		return "", fmt.Errorf("path must be a string")
+// ignoredArg is embedded in the arguments of tools that walk directories
+type ignoredArg struct {
+	IncludeIgnored bool `json:"include_ignored,omitempty" description:"Also include files matched by .gitignore or .gossignore"`
+}
+
+type readFileArgs struct {
+	Path   string `json:"path" description:"Path to the file to read"`
+	Offset int    `json:"offset,omitempty" description:"First line to read, starting at 1 (default 1)"`
+	Limit  int    `json:"limit,omitempty" description:"Maximum number of lines to read (default 500, max 2000)"`
+}
+
+type writeFileArgs struct {
+	Path    string `json:"path" description:"Path to the file to write"`
+	Content string `json:"content" description:"Content to write to the file"`
+}
+
+type listDirectoryArgs struct {
+	Path string `json:"path" description:"Path to the directory to list"`
+	ignoredArg
+}
+
+type searchFilesArgs struct {
+	Path    string `json:"path" description:"Directory path to search in"`
+	Pattern string `json:"pattern" description:"File name pattern to search for"`
+	ignoredArg
+}
+
+type createDirectoryArgs struct {
+	Path string `json:"path" description:"Path to the directory to create"`
+}
+
+func readFileHandler(ctx context.Context, args readFileArgs) (string, error) {
+	path := args.Path
+
+	offset := 1
+	if args.Offset >= 1 {
+		offset = args.Offset
+	}
+	limit := DefaultReadLines
+	if args.Limit >= 1 {
+		limit = min(args.Limit, MaxReadLines)
 	}
 
 	// Security validation
-	if err := validateReadOperation(path); err != nil {
+	resolved, err := validateReadOperation(path)
+	if err != nil {
 		return "", fmt.Errorf("security validation failed: %w", err)
 	}
 
-	content, err := os.ReadFile(path)
+	content, err := os.ReadFile(resolved)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file %s: %w", path, err)
 	}
 
-	return string(content), nil
-}
-
-func writeFileHandler(ctx context.Context, args map[string]interface{}) (string, error) {
-	path, ok := args["path"].(string)
-	if !ok {
-		return "", fmt.Errorf("path must be a string")
+	if isBinary(content) {
+		return binarySummary(path, content), nil
 	}
 
-	content, ok := args["content"].(string)
-	if !ok {
-		return "", fmt.Errorf("content must be a string")
-	}
+	return numberedLines(path, content, offset, limit), nil
+}
+
+func writeFileHandler(ctx context.Context, args writeFileArgs) (string, error) {
+	path, content := args.Path, args.Content
 
 	// Security validation
-	if err := validateWriteOperation(path, len(content)); err != nil {
+	resolved, err := validateWriteOperation(path, len(content))
+	if err != nil {
 		return "", fmt.Errorf("security validation failed: %w", err)
 	}
+	if err := confirmMutation(ctx, "write_file", fmt.Sprintf("write %d bytes to %s", len(content), path)); err != nil {
+		return "", err
+	}
 
-	err := os.WriteFile(path, []byte(content), 0644)
+	if err := os.MkdirAll(filepath.Dir(resolved), 0755); err != nil {
+		return "", fmt.Errorf("cannot create directory for %s: %w", path, err)
+	}
+	err = os.WriteFile(resolved, []byte(content), 0644)
 	if err != nil {
 		return "", fmt.Errorf("failed to write file %s: %w", path, err)
 	}
@@ -157,26 +119,29 @@ func writeFileHandler(ctx context.Context, args map[string]interface{}) (string,
 	return fmt.Sprintf("Successfully wrote %d bytes to %s", len(content), path), nil
 }
 
-func listDirectoryHandler(ctx context.Context, args map[string]interface{}) (string, error) {
-	path, ok := args["path"].(string)
-	if !ok {
-		return "", fmt.Errorf("path must be a string")
-	}
+func listDirectoryHandler(ctx context.Context, args listDirectoryArgs) (string, error) {
+	path := args.Path
 
 	// Security validation  
-	if err := validatePath(path); err != nil {
+	resolved, err := validatePath(path)
+	if err != nil {
 		return "", fmt.Errorf("security validation failed: %w", err)
 	}
 
-	entries, err := os.ReadDir(path)
+	entries, err := os.ReadDir(resolved)
 	if err != nil {
 		return "", fmt.Errorf("failed to read directory %s: %w", path, err)
 	}
 
+	filter := newWalkFilter(args.IncludeIgnored)
+
 	var result strings.Builder
 	result.WriteString(fmt.Sprintf("Contents of %s:\n", path))
 
 	for _, entry := range entries {
+		if filter.skip(filepath.Join(resolved, entry.Name()), entry.IsDir()) {
+			continue
+		}
 		if entry.IsDir() {
 			result.WriteString(fmt.Sprintf("[DIR]  %s/\n", entry.Name()))
 		} else {
@@ -188,35 +153,38 @@ func listDirectoryHandler(ctx context.Context, args map[string]interface{}) (str
 			}
 		}
 	}
+	if note := filter.note(); note != "" {
+		result.WriteString(note + "\n")
+	}
 
 	return result.String(), nil
 }
 
-func searchFilesHandler(ctx context.Context, args map[string]interface{}) (string, error) {
-	basePath, ok := args["path"].(string)
-	if !ok {
-		return "", fmt.Errorf("path must be a string")
-	}
-
-	pattern, ok := args["pattern"].(string)
-	if !ok {
-		return "", fmt.Errorf("pattern must be a string")
-	}
+func searchFilesHandler(ctx context.Context, args searchFilesArgs) (string, error) {
+	basePath, pattern := args.Path, args.Pattern
 
 	// Security validation
-	if err := validatePath(basePath); err != nil {
+	resolved, err := validatePath(basePath)
+	if err != nil {
 		return "", fmt.Errorf("security validation failed: %w", err)
 	}
 
 	var matches []string
+	filter := newWalkFilter(args.IncludeIgnored)
 
-	err := filepath.Walk(basePath, func(path string, info os.FileInfo, err error) error {
+	err = filepath.Walk(resolved, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // Continue walking even if we can't access some files
 		}
+		if path != resolved && filter.skip(path, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 
 		if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
-			matches = append(matches, path)
+			matches = append(matches, displayPath(basePath, resolved, path))
 		}
 
 		return nil
@@ -227,7 +195,11 @@ func searchFilesHandler(ctx context.Context, args map[string]interface{}) (strin
 	}
 
 	if len(matches) == 0 {
-		return fmt.Sprintf("No files matching pattern '%s' found in %s", pattern, basePath), nil
+		result := fmt.Sprintf("No files matching pattern '%s' found in %s", pattern, basePath)
+		if note := filter.note(); note != "" {
+			result += " " + note
+		}
+		return result, nil
 	}
 
 	var result strings.Builder
@@ -235,22 +207,27 @@ func searchFilesHandler(ctx context.Context, args map[string]interface{}) (strin
 	for _, match := range matches {
 		result.WriteString(fmt.Sprintf("- %s\n", match))
 	}
+	if note := filter.note(); note != "" {
+		result.WriteString(note + "\n")
+	}
 
 	return result.String(), nil
 }
 
-func createDirectoryHandler(ctx context.Context, args map[string]interface{}) (string, error) {
-	path, ok := args["path"].(string)
-	if !ok {
-		return "", fmt.Errorf("path must be a string")
-	}
+func createDirectoryHandler(ctx context.Context, args createDirectoryArgs) (string, error) {
+	path := args.Path
 
 	// Security validation
-	if err := validatePath(path); err != nil {
+	resolved, err := validatePath(path)
+	if err != nil {
 		return "", fmt.Errorf("security validation failed: %w", err)
 	}
 
-	err := os.MkdirAll(path, 0755)
+	if err := confirmMutation(ctx, "create_directory", "create directory "+path); err != nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(resolved, 0755)
 	if err != nil {
 		return "", fmt.Errorf("failed to create directory %s: %w", path, err)
 	}
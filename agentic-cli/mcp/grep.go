@@ -0,0 +1,282 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultGrepResults is how many matches grep_files returns by default
+	DefaultGrepResults = 100
+	// MaxGrepResults caps the max_results argument of grep_files
+	MaxGrepResults = 1000
+	// MaxGrepContext caps the context_lines argument of grep_files
+	MaxGrepContext = 10
+	// maxGrepLineLength truncates very long lines such as minified code
+	maxGrepLineLength = 500
+)
+
+type grepFilesArgs struct {
+	Pattern      string `json:"pattern" description:"Regular expression (RE2 syntax) to search for" schema:"minLength=1"`
+	Path         string `json:"path,omitempty" description:"Directory or file to search (default: current directory)"`
+	Include      string `json:"include,omitempty" description:"Comma-separated globs of files to search, e.g. \"*.go,docs/**/*.md\""`
+	Exclude      string `json:"exclude,omitempty" description:"Comma-separated globs of files to skip, e.g. \"*_test.go\""`
+	IgnoreCase   bool   `json:"ignore_case,omitempty" description:"Match case-insensitively"`
+	ContextLines int    `json:"context_lines,omitempty" description:"Lines of context to show around each match (max 10)"`
+	MaxResults   int    `json:"max_results,omitempty" description:"Maximum number of matching lines to return (default 100, max 1000)"`
+	ignoredArg
+}
+
+// grepOptions holds the parsed arguments of grep_files
+type grepOptions struct {
+	pattern    *regexp.Regexp
+	include    []*regexp.Regexp
+	exclude    []*regexp.Regexp
+	context    int
+	maxResults int
+}
+
+// grepSummary accumulates matches across files
+type grepSummary struct {
+	out        strings.Builder
+	matches    int
+	files      int
+	skipped    int // Binary or oversized files
+	truncated  bool
+	maxResults int
+}
+
+func grepFilesHandler(ctx context.Context, args grepFilesArgs) (string, error) {
+	pattern := args.Pattern
+	if pattern == "" {
+		return "", fmt.Errorf("pattern must be a non-empty string")
+	}
+
+	basePath := "."
+	if args.Path != "" {
+		basePath = args.Path
+	}
+
+	// Security validation
+	resolved, err := validatePath(basePath)
+	if err != nil {
+		return "", fmt.Errorf("security validation failed: %w", err)
+	}
+
+	opts, err := parseGrepOptions(args)
+	if err != nil {
+		return "", err
+	}
+
+	summary := &grepSummary{maxResults: opts.maxResults}
+	filter := newWalkFilter(args.IncludeIgnored)
+	err = filepath.WalkDir(resolved, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Continue walking even if we can't access some files
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if path != resolved && filter.skip(path, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, relErr := filepath.Rel(resolved, path)
+		if relErr != nil {
+			rel = path
+		}
+		if !opts.selects(filepath.ToSlash(rel)) {
+			return nil
+		}
+
+		if summary.grepFile(displayPath(basePath, resolved, path), path, opts) {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to search files: %w", err)
+	}
+
+	result := summary.String(pattern, basePath)
+	if note := filter.note(); note != "" {
+		result += "\n" + note
+	}
+	return result, nil
+}
+
+// parseGrepOptions validates the optional grep_files arguments
+func parseGrepOptions(args grepFilesArgs) (*grepOptions, error) {
+	pattern := args.Pattern
+	if args.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+
+	opts := &grepOptions{pattern: re, maxResults: DefaultGrepResults}
+	if opts.include, err = globList(args.Include); err != nil {
+		return nil, fmt.Errorf("include: %w", err)
+	}
+	if opts.exclude, err = globList(args.Exclude); err != nil {
+		return nil, fmt.Errorf("exclude: %w", err)
+	}
+
+	if args.ContextLines > 0 {
+		opts.context = min(args.ContextLines, MaxGrepContext)
+	}
+	if args.MaxResults > 0 {
+		opts.maxResults = min(args.MaxResults, MaxGrepResults)
+	}
+	return opts, nil
+}
+
+// globList compiles a comma-separated list of glob patterns. Patterns
+// without a slash match file names at any depth.
+func globList(value string) ([]*regexp.Regexp, error) {
+	var out []*regexp.Regexp
+	for _, glob := range strings.Split(value, ",") {
+		glob = strings.TrimSpace(glob)
+		if glob == "" {
+			continue
+		}
+		expr := globToRegexp(strings.TrimPrefix(glob, "/"))
+		if !strings.Contains(glob, "/") {
+			expr = "(.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", glob, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+// selects reports whether the relative path passes the include and
+// exclude globs
+func (o *grepOptions) selects(rel string) bool {
+	for _, re := range o.exclude {
+		if re.MatchString(rel) {
+			return false
+		}
+	}
+	if len(o.include) == 0 {
+		return true
+	}
+	for _, re := range o.include {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// grepFile searches the file at path, reported as name, and reports whether
+// the result limit was hit
+func (s *grepSummary) grepFile(name, path string, opts *grepOptions) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > currentPolicy().MaxFileSize {
+		s.skipped++
+		return false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	if isBinary(content) {
+		if opts.pattern.Match(content) {
+			s.skipped++
+		}
+		return false
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	name = filepath.ToSlash(name)
+	lastPrinted := -1
+	matched := false
+	for i, line := range lines {
+		if !opts.pattern.MatchString(line) {
+			continue
+		}
+		if s.matches >= s.maxResults {
+			s.truncated = true
+			return true
+		}
+		if !matched {
+			matched = true
+			s.files++
+		}
+		s.matches++
+
+		// Separate non-adjacent groups like grep does
+		from := max(i-opts.context, lastPrinted+1)
+		if opts.context > 0 && s.out.Len() > 0 && (lastPrinted < 0 || from > lastPrinted+1) {
+			s.out.WriteString("--\n")
+		}
+		for j := from; j < i; j++ {
+			s.writeLine(name, j, '-', lines[j])
+		}
+		s.writeLine(name, i, ':', line)
+		lastPrinted = i
+
+		// Trailing context stops at the next match so it is printed as one
+		for j := i + 1; j <= i+opts.context && j < len(lines) && !opts.pattern.MatchString(lines[j]); j++ {
+			s.writeLine(name, j, '-', lines[j])
+			lastPrinted = j
+		}
+	}
+	return false
+}
+
+// writeLine prints a line as path:line:text, or path-line-text for context
+func (s *grepSummary) writeLine(name string, index int, sep rune, text string) {
+	if len(text) > maxGrepLineLength {
+		text = text[:maxGrepLineLength] + "…"
+	}
+	fmt.Fprintf(&s.out, "%s%c%d%c%s\n", name, sep, index+1, sep, text)
+}
+
+// String formats the collected matches with a summary header
+func (s *grepSummary) String(pattern, basePath string) string {
+	if s.matches == 0 {
+		result := fmt.Sprintf("No matches for '%s' in %s", pattern, basePath)
+		if s.skipped > 0 {
+			result += fmt.Sprintf(" (%d binary or oversized files skipped)", s.skipped)
+		}
+		return result
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Found %d matches in %d files", s.matches, s.files))
+	if s.truncated {
+		result.WriteString(fmt.Sprintf(" (stopped at max_results=%d; narrow the search for more)", s.maxResults))
+	}
+	if s.skipped > 0 {
+		result.WriteString(fmt.Sprintf(", %d binary or oversized files skipped", s.skipped))
+	}
+	result.WriteString(":\n")
+	result.WriteString(s.out.String())
+	return result.String()
+}
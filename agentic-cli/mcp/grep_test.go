@@ -0,0 +1,89 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGrepFiles(t *testing.T) {
+	chdirTemp(t, map[string]string{
+		"main.go":           "package main\n\nfunc main() {\n\trun()\n}\n",
+		"run.go":            "package main\n\nfunc run() {}\n",
+		"run_test.go":       "package main\n\nfunc TestRun() { run() }\n",
+		"docs/guide.md":     "Call run() to start.\n",
+		"data.bin":          "run()\x00\x01",
+		"node_modules/x.js": "run()\n",
+	})
+
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "plain",
+			args: map[string]interface{}{"pattern": `run\(\)`},
+			want: []string{
+				"Found 4 matches in 4 files, 1 binary or oversized files skipped:",
+				"main.go:4:\trun()",
+				"docs/guide.md:1:Call run() to start.",
+			},
+			notWant: []string{"node_modules", "data.bin:"},
+		},
+		{
+			name:    "include and exclude",
+			args:    map[string]interface{}{"pattern": "run", "include": "*.go", "exclude": "*_test.go"},
+			want:    []string{"main.go:4:", "run.go:3:func run() {}"},
+			notWant: []string{"run_test.go", "guide.md"},
+		},
+		{
+			name: "context",
+			args: map[string]interface{}{"pattern": `^\trun`, "context_lines": float64(1)},
+			want: []string{"main.go-3-func main() {\nmain.go:4:\trun()\nmain.go-5-}\n"},
+		},
+		{
+			name:    "max results",
+			args:    map[string]interface{}{"pattern": "package", "max_results": float64(2)},
+			want:    []string{"Found 2 matches in 2 files (stopped at max_results=2"},
+			notWant: []string{"run_test.go"},
+		},
+		{
+			name: "ignore case",
+			args: map[string]interface{}{"pattern": "CALL", "ignore_case": true},
+			want: []string{"docs/guide.md:1:"},
+		},
+		{
+			name: "no match",
+			args: map[string]interface{}{"pattern": "nothing here"},
+			want: []string{"No matches for 'nothing here' in ."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := callTool(context.Background(), "grep_files", tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(result, want) {
+					t.Errorf("Expected %q in:\n%s", want, result)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(result, notWant) {
+					t.Errorf("Did not expect %q in:\n%s", notWant, result)
+				}
+			}
+		})
+	}
+
+	if _, err := callTool(context.Background(), "grep_files", map[string]interface{}{"pattern": "("}); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+	if _, err := callTool(context.Background(), "grep_files", map[string]interface{}{"pattern": "x", "path": "../"}); err == nil {
+		t.Error("Expected an error for a path outside the working directory")
+	}
+}
@@ -0,0 +1,271 @@
+package mcp
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ignoreRule is a single parsed .gitignore pattern
+type ignoreRule struct {
+	pattern *regexp.Regexp
+	negate  bool // Pattern started with "!" and re-includes matches
+	dirOnly bool // Pattern ended with "/" and only matches directories
+}
+
+// ignoreFiles are the per-directory rule files, in increasing precedence
+var ignoreFiles = []string{".gitignore", ".gossignore"}
+
+// ignoreMatcher evaluates ignore files hierarchically below a root
+// directory, on top of the global excludes. Rule files are loaded lazily
+// and cached per directory.
+type ignoreMatcher struct {
+	root   string
+	files  []string     // Rule file names read in every directory, in order
+	global []ignoreRule // Rules that apply below the root with the lowest precedence
+
+	mu    sync.Mutex
+	rules map[string][]ignoreRule
+}
+
+// newIgnoreMatcher returns a matcher for the ignore files below root
+func newIgnoreMatcher(root string) *ignoreMatcher {
+	// Paths are matched in their resolved form, as the sandbox returns them
+	if resolved, err := resolvePath(root); err == nil {
+		root = resolved
+	}
+	root = filepath.Clean(root)
+
+	var global []ignoreRule
+	for _, path := range globalExcludeFiles(root) {
+		global = append(global, readIgnoreFile(path)...)
+	}
+
+	return &ignoreMatcher{
+		root:   root,
+		files:  ignoreFiles,
+		global: global,
+		rules:  make(map[string][]ignoreRule),
+	}
+}
+
+// globalExcludeFiles lists the ignore files that apply to every directory:
+// git's user-wide excludes, the repository's info/exclude and goss's own
+// user-wide ignore file.
+func globalExcludeFiles(root string) []string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return []string{filepath.Join(root, ".git", "info", "exclude")}
+	}
+	return []string{
+		filepath.Join(configDir, "git", "ignore"),
+		filepath.Join(root, ".git", "info", "exclude"),
+		filepath.Join(configDir, "goss", "ignore"),
+	}
+}
+
+// ignored reports whether the absolute path is excluded, either directly
+// or because one of its parent directories is.
+func (m *ignoreMatcher) ignored(absPath string, isDir bool) bool {
+	rel, err := filepath.Rel(m.root, absPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i := range parts {
+		last := i == len(parts)-1
+		if m.match(parts[:i+1], !last || isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// match evaluates the rules of every directory from the root down to the
+// parent of the entry named by parts. The last matching rule wins.
+func (m *ignoreMatcher) match(parts []string, isDir bool) bool {
+	ignored := false
+	dir := m.root
+	for i := range parts {
+		rel := strings.Join(parts[i:], "/")
+		rules := m.dirRules(dir)
+		if i == 0 {
+			rules = append(append([]ignoreRule(nil), m.global...), rules...)
+		}
+		for _, rule := range rules {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if rule.pattern.MatchString(rel) {
+				ignored = !rule.negate
+			}
+		}
+		dir = filepath.Join(dir, parts[i])
+	}
+	return ignored
+}
+
+// dirRules returns the cached rules defined directly in dir
+func (m *ignoreMatcher) dirRules(dir string) []ignoreRule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rules, ok := m.rules[dir]; ok {
+		return rules
+	}
+
+	var rules []ignoreRule
+	for _, name := range m.files {
+		rules = append(rules, readIgnoreFile(filepath.Join(dir, name))...)
+	}
+	m.rules[dir] = rules
+	return rules
+}
+
+// readIgnoreFile parses a gitignore-style file; missing files yield no rules
+func readIgnoreFile(path string) []ignoreRule {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreRule(scanner.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// parseIgnoreRule converts a gitignore line into a rule matched against
+// slash-separated paths relative to the directory defining it.
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	line = strings.TrimPrefix(line, "\\")
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// Patterns without an inner slash match at any depth
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if !anchored {
+		expr = "(.*/)?" + expr
+	}
+	pattern, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.pattern = pattern
+	return rule, true
+}
+
+// globToRegexp translates gitignore glob syntax, including "**", to a
+// regular expression over slash-separated paths.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("(/.*)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// walkFilter decides which entries the listing and search tools visit:
+// restricted paths are always skipped, ignored ones unless requested.
+type walkFilter struct {
+	ignore  *ignoreMatcher // Nil when ignored files are included
+	ignored int            // Entries skipped because of ignore rules
+}
+
+// newWalkFilter returns a filter rooted at the working directory.
+// includeIgnored, set from the include_ignored argument, turns off the ignore
+// rules for one call.
+func newWalkFilter(includeIgnored bool) *walkFilter {
+	f := &walkFilter{}
+	if includeIgnored {
+		return f
+	}
+	if wd, err := os.Getwd(); err == nil {
+		f.ignore = newIgnoreMatcher(wd)
+	}
+	return f
+}
+
+// skip reports whether path should be left out of a listing or search
+func (f *walkFilter) skip(path string, isDir bool) bool {
+	if _, err := validatePath(path); err != nil {
+		return true
+	}
+	if f.ignore == nil {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || !f.ignore.ignored(abs, isDir) {
+		return false
+	}
+	f.ignored++
+	return true
+}
+
+// note describes skipped ignored entries, or returns an empty string
+func (f *walkFilter) note() string {
+	if f.ignored == 0 {
+		return ""
+	}
+	return fmt.Sprintf("(%d ignored entries hidden by .gitignore/.gossignore; set include_ignored to show them)", f.ignored)
+}
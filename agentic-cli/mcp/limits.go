@@ -0,0 +1,318 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vivesm/GOSS-CLI/agentic-cli/openai"
+)
+
+// RateLimiter is a token bucket: it allows bursts of up to maxTokens calls
+// and earns one token back every refillRate
+type RateLimiter struct {
+	mu         sync.Mutex
+	tokens     float64
+	maxTokens  int
+	refillRate time.Duration
+	lastRefill time.Time
+}
+
+// NewRateLimiter creates a new rate limiter
+func NewRateLimiter(maxTokens int, refillRate time.Duration) *RateLimiter {
+	return &RateLimiter{
+		tokens:     float64(maxTokens),
+		maxTokens:  maxTokens,
+		refillRate: refillRate,
+		lastRefill: time.Now(),
+	}
+}
+
+// Allow checks if a request can proceed
+func (rl *RateLimiter) Allow() bool {
+	ok, _ := rl.Reserve()
+	return ok
+}
+
+// Reserve takes a token if one is available; otherwise it reports how long
+// until the next one is earned
+func (rl *RateLimiter) Reserve() (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.refill()
+	if rl.tokens >= 1 {
+		rl.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - rl.tokens) * float64(rl.refillRate))
+}
+
+// Available returns the number of whole tokens left
+func (rl *RateLimiter) Available() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.refill()
+	return int(rl.tokens)
+}
+
+// refill adds the tokens earned since the last call, keeping fractions so
+// that frequent calls still accrue tokens
+func (rl *RateLimiter) refill() {
+	now := time.Now()
+	if rl.refillRate > 0 {
+		earned := float64(now.Sub(rl.lastRefill)) / float64(rl.refillRate)
+		rl.tokens = math.Min(float64(rl.maxTokens), rl.tokens+earned)
+	}
+	rl.lastRefill = now
+}
+
+// ToolLimit restricts how often a tool may be called. Zero fields are
+// unlimited.
+type ToolLimit struct {
+	RatePerMinute float64 // Sustained calls per minute
+	Burst         int     // Calls allowed back to back; defaults to RatePerMinute rounded up
+	SessionQuota  int     // Calls per session
+	DailyQuota    int     // Calls per calendar day across sessions, persisted to disk
+}
+
+// DefaultToolLimits returns the limits applied when none are configured
+func DefaultToolLimits() map[string]ToolLimit {
+	return map[string]ToolLimit{
+		"web_search": {RatePerMinute: 10, Burst: 10},
+	}
+}
+
+// LimitError reports a call refused by a rate limit or quota. The message
+// tells the model whether waiting will help.
+type LimitError struct {
+	Tool       string
+	Limit      string        // "rate", "session" or "daily"
+	Max        float64       // The limit that was reached
+	RetryAfter time.Duration // When the call would be allowed again; zero for the session quota
+}
+
+func (e *LimitError) Error() string {
+	switch e.Limit {
+	case "rate":
+		return fmt.Sprintf("rate limit exceeded for %s (%g calls per minute); wait %s before calling it again, or continue without it",
+			e.Tool, e.Max, e.RetryAfter.Round(time.Second))
+	case "session":
+		return fmt.Sprintf("session quota exhausted for %s (%g calls per session); do not call it again in this session",
+			e.Tool, e.Max)
+	default:
+		return fmt.Sprintf("daily quota exhausted for %s (%g calls per day); it resets in %s, do not call it again today",
+			e.Tool, e.Max, e.RetryAfter.Round(time.Minute))
+	}
+}
+
+// ToolLimitStatus is the current state of one limited tool
+type ToolLimitStatus struct {
+	Tool         string
+	Limit        ToolLimit
+	Available    int // Calls allowed right now by the rate limit
+	SessionCalls int
+	DailyCalls   int
+}
+
+// ToolLimiter enforces the configured limits of every tool
+type ToolLimiter struct {
+	mu        sync.Mutex
+	limits    map[string]ToolLimit
+	buckets   map[string]*RateLimiter
+	session   map[string]int
+	usagePath string // File holding today's call counts; empty keeps them in memory
+	daily     dailyUsage
+}
+
+// dailyUsage is the file format of the persisted daily call counts
+type dailyUsage struct {
+	Date  string         `json:"date"`
+	Calls map[string]int `json:"calls"`
+}
+
+// NewToolLimiter returns a limiter enforcing limits. Daily counts are
+// persisted to usagePath when it is set.
+func NewToolLimiter(limits map[string]ToolLimit, usagePath string) *ToolLimiter {
+	l := &ToolLimiter{
+		session:   make(map[string]int),
+		usagePath: usagePath,
+	}
+	l.Update(limits)
+	return l
+}
+
+// Update replaces the limits while keeping the session and daily counts.
+// Rate limits that changed start with a full bucket.
+func (l *ToolLimiter) Update(limits map[string]ToolLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	buckets := make(map[string]*RateLimiter, len(limits))
+	for tool, limit := range limits {
+		if limit.RatePerMinute <= 0 {
+			continue
+		}
+		if old, ok := l.limits[tool]; ok && old == limit {
+			buckets[tool] = l.buckets[tool]
+			continue
+		}
+		burst := limit.Burst
+		if burst <= 0 {
+			burst = int(math.Ceil(limit.RatePerMinute))
+		}
+		buckets[tool] = NewRateLimiter(burst, time.Duration(float64(time.Minute)/limit.RatePerMinute))
+	}
+	l.limits = limits
+	l.buckets = buckets
+}
+
+// Allow records a call to tool, or returns a *LimitError if a limit or
+// quota has been reached
+func (l *ToolLimiter) Allow(tool string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit, ok := l.limits[tool]
+	if !ok {
+		return nil
+	}
+	if limit.SessionQuota > 0 && l.session[tool] >= limit.SessionQuota {
+		return &LimitError{Tool: tool, Limit: "session", Max: float64(limit.SessionQuota)}
+	}
+	if limit.DailyQuota > 0 {
+		// Reload so that other running sessions' calls are counted
+		l.loadUsage()
+		if l.daily.Calls[tool] >= limit.DailyQuota {
+			return &LimitError{Tool: tool, Limit: "daily", Max: float64(limit.DailyQuota), RetryAfter: untilMidnight()}
+		}
+	}
+	if bucket := l.buckets[tool]; bucket != nil {
+		if ok, wait := bucket.Reserve(); !ok {
+			return &LimitError{Tool: tool, Limit: "rate", Max: limit.RatePerMinute, RetryAfter: wait}
+		}
+	}
+
+	l.session[tool]++
+	if limit.DailyQuota > 0 {
+		l.daily.Calls[tool]++
+		l.saveUsage()
+	}
+	return nil
+}
+
+// Status returns the state of every limited tool, sorted by name
+func (l *ToolLimiter) Status() []ToolLimitStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.loadUsage()
+	statuses := make([]ToolLimitStatus, 0, len(l.limits))
+	for tool, limit := range l.limits {
+		status := ToolLimitStatus{
+			Tool:         tool,
+			Limit:        limit,
+			SessionCalls: l.session[tool],
+			DailyCalls:   l.daily.Calls[tool],
+		}
+		if bucket := l.buckets[tool]; bucket != nil {
+			status.Available = bucket.Available()
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Tool < statuses[j].Tool })
+	return statuses
+}
+
+// loadUsage reads today's counts from disk, starting over on a new day
+func (l *ToolLimiter) loadUsage() {
+	today := time.Now().Format("2006-01-02")
+	if l.usagePath != "" {
+		var usage dailyUsage
+		if data, err := os.ReadFile(l.usagePath); err == nil && json.Unmarshal(data, &usage) == nil {
+			l.daily = usage
+		}
+	}
+	if l.daily.Date != today || l.daily.Calls == nil {
+		l.daily = dailyUsage{Date: today, Calls: make(map[string]int)}
+	}
+}
+
+// saveUsage persists the daily counts; failures only lose the count
+func (l *ToolLimiter) saveUsage() {
+	if l.usagePath == "" {
+		return
+	}
+	data, err := json.Marshal(l.daily)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(l.usagePath), 0700); err != nil {
+		return
+	}
+	tmp := l.usagePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err == nil {
+		os.Rename(tmp, l.usagePath)
+	}
+}
+
+// untilMidnight returns the time left until the daily quotas reset
+func untilMidnight() time.Duration {
+	now := time.Now()
+	year, month, day := now.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, now.Location()).Sub(now)
+}
+
+var (
+	limiterMu   sync.RWMutex
+	toolLimiter = NewToolLimiter(DefaultToolLimits(), "")
+)
+
+// SetToolLimiter replaces the limiter used by RateLimited handlers
+func SetToolLimiter(limiter *ToolLimiter) {
+	limiterMu.Lock()
+	defer limiterMu.Unlock()
+	toolLimiter = limiter
+}
+
+// CurrentToolLimiter returns the limiter used by RateLimited handlers
+func CurrentToolLimiter() *ToolLimiter {
+	limiterMu.RLock()
+	defer limiterMu.RUnlock()
+	return toolLimiter
+}
+
+// limitExempt lists calls that cost nothing and are not counted, keyed by
+// tool name
+var limitExempt = map[string]func(args map[string]interface{}) bool{
+	// Cached searches never reach a provider
+	"web_search": func(args map[string]interface{}) bool {
+		var search webSearchArgs
+		if err := openai.DecodeArguments(args, &search); err != nil {
+			return true
+		}
+		query, count, err := searchQuery(search)
+		return err != nil || servedFromCache(query, count)
+	},
+}
+
+// RateLimited checks every call of tool against the current ToolLimiter
+func RateLimited(tool openai.ToolFunction, next openai.ToolHandler) openai.ToolHandler {
+	return func(ctx context.Context, args map[string]interface{}) (string, error) {
+		if exempt := limitExempt[tool.Name]; exempt == nil || !exempt(args) {
+			if limiter := CurrentToolLimiter(); limiter != nil {
+				if err := limiter.Allow(tool.Name); err != nil {
+					return "", err
+				}
+			}
+		}
+		return next(ctx, args)
+	}
+}
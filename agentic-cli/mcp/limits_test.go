@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vivesm/GOSS-CLI/agentic-cli/openai"
+)
+
+func TestToolLimiter(t *testing.T) {
+	usage := filepath.Join(t.TempDir(), "tool-usage.json")
+	limiter := NewToolLimiter(map[string]ToolLimit{
+		"fetch_url":   {RatePerMinute: 1, Burst: 2},
+		"run_command": {SessionQuota: 2},
+		"web_search":  {DailyQuota: 3},
+	}, usage)
+
+	var limitErr *LimitError
+	limiter.Allow("fetch_url")
+	limiter.Allow("fetch_url")
+	err := limiter.Allow("fetch_url")
+	if !errors.As(err, &limitErr) || limitErr.Limit != "rate" || limitErr.RetryAfter <= 0 ||
+		!strings.Contains(err.Error(), "rate limit exceeded for fetch_url (1 calls per minute); wait ") {
+		t.Errorf("Expected a rate limit error after the burst, got %v", err)
+	}
+
+	limiter.Allow("run_command")
+	limiter.Allow("run_command")
+	if err := limiter.Allow("run_command"); err == nil || !strings.Contains(err.Error(), "do not call it again in this session") {
+		t.Errorf("Expected the session quota to be exhausted, got %v", err)
+	}
+	if err := limiter.Allow("read_file"); err != nil {
+		t.Errorf("Expected tools without limits to be allowed, got %v", err)
+	}
+
+	// Daily counts are shared with other sessions through the usage file
+	limiter.Allow("web_search")
+	limiter.Allow("web_search")
+	other := NewToolLimiter(map[string]ToolLimit{"web_search": {DailyQuota: 3}}, usage)
+	if err := other.Allow("web_search"); err != nil {
+		t.Fatalf("Expected the third call of the day to be allowed, got %v", err)
+	}
+	if err := limiter.Allow("web_search"); err == nil || !strings.Contains(err.Error(), "daily quota exhausted for web_search (3 calls per day)") {
+		t.Errorf("Expected the daily quota to be exhausted, got %v", err)
+	}
+
+	// Updating the limits keeps the counts
+	limiter.Update(map[string]ToolLimit{"run_command": {SessionQuota: 3}})
+	if err := limiter.Allow("run_command"); err != nil {
+		t.Errorf("Expected a raised quota to allow another call, got %v", err)
+	}
+	statuses := limiter.Status()
+	if len(statuses) != 1 || statuses[0].Tool != "run_command" || statuses[0].SessionCalls != 3 {
+		t.Errorf("Unexpected status %+v", statuses)
+	}
+}
+
+func TestRateLimitedHandler(t *testing.T) {
+	previous := CurrentToolLimiter()
+	t.Cleanup(func() {
+		SetToolLimiter(previous)
+		SetSearchCache(nil)
+		SetSearchProviders(nil)
+	})
+	SetToolLimiter(NewToolLimiter(map[string]ToolLimit{"web_search": {SessionQuota: 1}}, ""))
+
+	calls := 0
+	handler := RateLimited(openai.ToolFunction{Name: "web_search"}, func(context.Context, map[string]interface{}) (string, error) {
+		calls++
+		return "ok", nil
+	})
+	args := map[string]interface{}{"query": "golang generics", "count": float64(2)}
+	if _, err := handler(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := handler(context.Background(), args); err == nil {
+		t.Error("Expected the second search to exceed the session quota")
+	}
+
+	// Searches answered from the cache are not counted
+	cache := NewSearchCache(t.TempDir(), 0)
+	SetSearchCache(cache)
+	provider, _ := NewSearchProvider(SearchProviderConfig{Type: "duckduckgo"})
+	SetSearchProviders([]SearchProvider{provider})
+	cache.Put("duckduckgo", "golang generics", 2, []SearchResult{{Title: "Go", URL: "https://go.dev"}})
+	if _, err := handler(context.Background(), args); err != nil {
+		t.Errorf("Expected a cached search to bypass the quota, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 handler calls, got %d", calls)
+	}
+}
@@ -0,0 +1,459 @@
+package mcp
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// boilerplate elements never contribute to the readable content of a page
+var boilerplate = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Canvas: true, atom.Iframe: true, atom.Object: true,
+	atom.Form: true, atom.Button: true, atom.Input: true, atom.Select: true, atom.Textarea: true,
+	atom.Nav: true, atom.Aside: true, atom.Footer: true, atom.Dialog: true,
+}
+
+// htmlToMarkdown extracts the title and the main content of an HTML
+// document as Markdown. Relative links and images are resolved against base.
+func htmlToMarkdown(doc *html.Node, base *url.URL) (title, markdown string) {
+	if t := findFirst(doc, func(n *html.Node) bool { return n.DataAtom == atom.Title }); t != nil {
+		title = inlineText(t)
+	}
+
+	root, whole := mainContent(doc)
+	w := &markdownWriter{base: base, skipHeader: whole}
+	w.children(root)
+	return title, w.String()
+}
+
+// mainContent picks the element holding the article: <main>, an element
+// with role="main", the longest <article>, or the whole body. whole reports
+// the body fallback, where page headers are boilerplate too.
+func mainContent(doc *html.Node) (*html.Node, bool) {
+	if n := findFirst(doc, func(n *html.Node) bool { return n.DataAtom == atom.Main }); n != nil {
+		return n, false
+	}
+	if n := findFirst(doc, func(n *html.Node) bool { return attr(n, "role") == "main" }); n != nil {
+		return n, false
+	}
+
+	var best *html.Node
+	bestLength := 0
+	walkElements(doc, func(n *html.Node) {
+		if n.DataAtom != atom.Article {
+			return
+		}
+		if length := len(inlineText(n)); length > bestLength {
+			best, bestLength = n, length
+		}
+	})
+	if best != nil {
+		return best, false
+	}
+
+	if body := findFirst(doc, func(n *html.Node) bool { return n.DataAtom == atom.Body }); body != nil {
+		return body, true
+	}
+	return doc, true
+}
+
+// markdownWriter renders HTML nodes as Markdown, tracking line starts so
+// block elements are separated by exactly one blank line and nested lists
+// and quotes keep their prefixes.
+type markdownWriter struct {
+	base       *url.URL
+	skipHeader bool
+
+	out       []byte
+	prefix    string // Written at the start of every line, e.g. "> " or list indentation
+	lineStart bool   // Nothing has been written on the current line yet
+	itemStart bool   // A list marker was just written and no content followed yet
+	lists     int    // Depth of the list being written
+}
+
+func (w *markdownWriter) String() string {
+	return strings.TrimSpace(string(w.out)) + "\n"
+}
+
+// write appends inline text, starting new lines with the current prefix
+func (w *markdownWriter) write(s string) {
+	if s == "" {
+		return
+	}
+	if len(w.out) == 0 || w.out[len(w.out)-1] == '\n' {
+		w.lineStart = true
+	}
+	if w.lineStart {
+		w.out = append(w.out, w.prefix...)
+		w.lineStart = false
+	}
+	w.out = append(w.out, s...)
+	w.itemStart = false
+}
+
+// newline ends the current line, dropping trailing spaces
+func (w *markdownWriter) newline() {
+	w.out = bytes.TrimRight(w.out, " \t")
+	w.out = append(w.out, '\n')
+	w.lineStart = true
+}
+
+// block ensures the next output starts a new paragraph
+func (w *markdownWriter) block() {
+	if len(w.out) == 0 || w.itemStart {
+		return
+	}
+	if !w.lineStart && w.out[len(w.out)-1] != '\n' {
+		w.newline()
+	}
+	quoted := strings.TrimRight(w.prefix, " ")
+	if !bytes.HasSuffix(w.out, []byte("\n"+quoted+"\n")) && !bytes.HasSuffix(w.out, []byte("\n\n")) {
+		w.out = append(w.out, quoted...)
+		w.out = append(w.out, '\n')
+	}
+	w.lineStart = true
+}
+
+// text writes a text node with whitespace collapsed
+func (w *markdownWriter) text(s string) {
+	s = collapseSpace(s)
+	atStart := w.lineStart || len(w.out) == 0 || w.out[len(w.out)-1] == '\n' || w.out[len(w.out)-1] == ' ' || w.itemStart
+	if atStart {
+		s = strings.TrimLeft(s, " ")
+	}
+	w.write(s)
+}
+
+func (w *markdownWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+func (w *markdownWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.ElementNode:
+	case html.DocumentNode:
+		w.children(n)
+		return
+	default:
+		return
+	}
+
+	if boilerplate[n.DataAtom] || attr(n, "hidden") != "" || attr(n, "aria-hidden") == "true" {
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Header:
+		if !w.skipHeader {
+			w.block()
+			w.children(n)
+			w.block()
+		}
+
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		heading := inlineText(n)
+		if heading == "" {
+			return
+		}
+		w.block()
+		w.write(strings.Repeat("#", int(n.Data[1]-'0')) + " " + heading)
+		w.block()
+
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Figure,
+		atom.Figcaption, atom.Dl, atom.Dd, atom.Dt, atom.Details, atom.Summary, atom.Address:
+		w.block()
+		w.children(n)
+		w.block()
+
+	case atom.Br:
+		w.newline()
+
+	case atom.Hr:
+		w.block()
+		w.write("---")
+		w.block()
+
+	case atom.A:
+		w.link(n)
+
+	case atom.Img:
+		src := w.resolve(attr(n, "src"))
+		if src != "" && !strings.HasPrefix(src, "data:") {
+			w.write(fmt.Sprintf("![%s](%s)", strings.TrimSpace(collapseSpace(attr(n, "alt"))), src))
+		}
+
+	case atom.Strong, atom.B:
+		w.emphasis(n, "**")
+
+	case atom.Em, atom.I:
+		w.emphasis(n, "*")
+
+	case atom.Code, atom.Kbd, atom.Samp:
+		if code := inlineText(n); code != "" {
+			w.write("`" + code + "`")
+		}
+
+	case atom.Pre:
+		w.pre(n)
+
+	case atom.Ul, atom.Ol:
+		w.list(n)
+
+	case atom.Blockquote:
+		w.block()
+		saved := w.prefix
+		w.prefix += "> "
+		w.children(n)
+		// Drop the quoted blank line left by the last paragraph
+		if quoted := strings.TrimRight(w.prefix, " "); bytes.HasSuffix(w.out, []byte("\n"+quoted+"\n")) {
+			w.out = w.out[:len(w.out)-len(quoted)-1]
+		}
+		w.prefix = saved
+		w.block()
+
+	case atom.Table:
+		w.table(n)
+
+	default:
+		w.children(n)
+	}
+}
+
+// link writes an anchor as [text](url), or just its text when the target
+// is missing, a fragment or a script
+func (w *markdownWriter) link(n *html.Node) {
+	label := inlineText(n)
+	if label == "" {
+		if img := findFirst(n, func(c *html.Node) bool { return c.DataAtom == atom.Img }); img != nil {
+			label = strings.TrimSpace(collapseSpace(attr(img, "alt")))
+		}
+	}
+	href := attr(n, "href")
+	if label == "" {
+		return
+	}
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		w.text(label)
+		return
+	}
+	w.write(fmt.Sprintf("[%s](%s)", label, w.resolve(href)))
+}
+
+// emphasis wraps plain text in marker; elements with nested markup are
+// rendered without it so links inside bold text survive
+func (w *markdownWriter) emphasis(n *html.Node, marker string) {
+	if findFirst(n, func(*html.Node) bool { return true }) != nil {
+		w.children(n)
+		return
+	}
+	if content := inlineText(n); content != "" {
+		w.write(marker + content + marker)
+	}
+}
+
+// pre writes a fenced code block, keeping the text verbatim
+func (w *markdownWriter) pre(n *html.Node) {
+	language := ""
+	if code := findFirst(n, func(c *html.Node) bool { return c.DataAtom == atom.Code }); code != nil {
+		for _, class := range strings.Fields(attr(code, "class")) {
+			if l, ok := strings.CutPrefix(class, "language-"); ok {
+				language = l
+				break
+			}
+		}
+	}
+
+	w.block()
+	w.write("```" + language)
+	w.newline()
+	for _, line := range strings.Split(strings.Trim(textContent(n), "\n"), "\n") {
+		w.write(line)
+		w.newline()
+	}
+	w.write("```")
+	w.block()
+}
+
+// list writes ul/ol items with markers, indenting continuation lines
+func (w *markdownWriter) list(n *html.Node) {
+	ordered := n.DataAtom == atom.Ol
+	if w.lists > 0 {
+		// Nested lists stay tight under their parent item
+		if !w.lineStart && len(w.out) > 0 && w.out[len(w.out)-1] != '\n' {
+			w.newline()
+		}
+	} else {
+		w.block()
+	}
+	w.lists++
+	defer func() { w.lists-- }()
+
+	number := 1
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+
+		if !w.lineStart && len(w.out) > 0 && w.out[len(w.out)-1] != '\n' {
+			w.newline()
+		}
+		w.write(marker)
+		w.itemStart = true
+
+		saved := w.prefix
+		w.prefix += strings.Repeat(" ", len(marker))
+		w.children(c)
+		w.prefix = saved
+		w.itemStart = false
+	}
+	if w.lists == 1 {
+		w.block()
+	}
+}
+
+// table writes rows as a pipe table, using the first row as the header
+func (w *markdownWriter) table(n *html.Node) {
+	var rows [][]string
+	walkElements(n, func(tr *html.Node) {
+		if tr.DataAtom != atom.Tr {
+			return
+		}
+		var cells []string
+		for c := tr.FirstChild; c != nil; c = c.NextSibling {
+			if c.DataAtom == atom.Td || c.DataAtom == atom.Th {
+				cells = append(cells, strings.ReplaceAll(inlineText(c), "|", `\|`))
+			}
+		}
+		if len(cells) > 0 {
+			rows = append(rows, cells)
+		}
+	})
+	if len(rows) == 0 {
+		return
+	}
+
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+
+	w.block()
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		w.write("| " + strings.Join(row, " | ") + " |")
+		w.newline()
+		if i == 0 {
+			w.write("|" + strings.Repeat(" --- |", columns))
+			w.newline()
+		}
+	}
+	w.block()
+}
+
+// resolve makes ref absolute relative to the page URL
+func (w *markdownWriter) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || w.base == nil {
+		return ref
+	}
+	u, err := w.base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+// textContent returns the concatenated text below n
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && boilerplate[c.DataAtom] {
+			continue
+		}
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
+
+// inlineText returns the text below n on a single trimmed line
+func inlineText(n *html.Node) string {
+	return strings.TrimSpace(collapseSpace(textContent(n)))
+}
+
+// collapseSpace replaces runs of whitespace with a single space, keeping
+// one at either end so adjacent inline elements stay separated
+func collapseSpace(s string) string {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s == "" {
+			return ""
+		}
+		return " "
+	}
+	collapsed := strings.Join(fields, " ")
+	if r, _ := utf8.DecodeRuneInString(s); unicode.IsSpace(r) {
+		collapsed = " " + collapsed
+	}
+	if r, _ := utf8.DecodeLastRuneInString(s); unicode.IsSpace(r) {
+		collapsed += " "
+	}
+	return collapsed
+}
+
+// attr returns the value of an attribute, or an empty string
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			if a.Val == "" {
+				return name // Boolean attributes such as hidden
+			}
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// findFirst returns the first node below n, in document order, matching fn
+func findFirst(n *html.Node, fn func(*html.Node) bool) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && fn(c) {
+			return c
+		}
+		if found := findFirst(c, fn); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// walkElements calls fn for every element below n
+func walkElements(n *html.Node, fn func(*html.Node)) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode {
+			fn(c)
+		}
+		walkElements(c, fn)
+	}
+}
@@ -0,0 +1,289 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/vivesm/GOSS-CLI/agentic-cli/internal/schema"
+	"github.com/vivesm/GOSS-CLI/agentic-cli/openai"
+)
+
+// DefaultMaxToolOutput caps the size of a tool result in bytes
+const DefaultMaxToolOutput = 128 * 1024
+
+// redactedText replaces secrets removed from tool output
+const redactedText = "[REDACTED]"
+
+// ToolMiddleware returns the chain every tool call runs through, outermost
+// first: panic recovery, logging, secret redaction, output truncation,
+// argument validation and rate limiting
+func ToolMiddleware() []openai.ToolMiddleware {
+	return []openai.ToolMiddleware{
+		Recovered,
+		Logged,
+		Redacted,
+		Truncated,
+		Validated,
+		RateLimited,
+	}
+}
+
+// Recovered turns a panicking handler into an error so that one broken tool
+// cannot take down the session
+func Recovered(tool openai.ToolFunction, next openai.ToolHandler) openai.ToolHandler {
+	return func(ctx context.Context, args map[string]interface{}) (result string, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				currentToolLogger().Error("tool panicked", "tool", tool.Name, "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+				result, err = "", fmt.Errorf("tool %s failed unexpectedly: %v", tool.Name, r)
+			}
+		}()
+		return next(ctx, args)
+	}
+}
+
+// Logged records every call with its redacted arguments, duration, output
+// size and error
+func Logged(tool openai.ToolFunction, next openai.ToolHandler) openai.ToolHandler {
+	return func(ctx context.Context, args map[string]interface{}) (string, error) {
+		started := time.Now()
+		result, err := next(ctx, args)
+
+		attrs := []any{
+			"tool", tool.Name,
+			"args", redactArgs(args),
+			"duration_ms", time.Since(started).Milliseconds(),
+			"output_bytes", len(result),
+		}
+		if err != nil {
+			currentToolLogger().Warn("tool call failed", append(attrs, "error", redactSecrets(err.Error()))...)
+		} else {
+			currentToolLogger().Info("tool call", attrs...)
+		}
+		return result, err
+	}
+}
+
+// Redacted removes credentials from tool output and errors before they
+// reach the model or the logs. Output only loses known token formats and
+// credential environment values: it may be source code that is written back,
+// where "apiKey: cfg.APIKey" must survive.
+func Redacted(tool openai.ToolFunction, next openai.ToolHandler) openai.ToolHandler {
+	return func(ctx context.Context, args map[string]interface{}) (string, error) {
+		result, err := next(ctx, args)
+		if err != nil {
+			if redacted := redactSecrets(err.Error()); redacted != err.Error() {
+				err = fmt.Errorf("%s", redacted)
+			}
+		}
+		return redactTokens(result), err
+	}
+}
+
+// Truncated caps tool output at DefaultMaxToolOutput bytes, telling the
+// model how much was cut
+func Truncated(tool openai.ToolFunction, next openai.ToolHandler) openai.ToolHandler {
+	return func(ctx context.Context, args map[string]interface{}) (string, error) {
+		result, err := next(ctx, args)
+		return truncateOutput(result, DefaultMaxToolOutput), err
+	}
+}
+
+// Validated repairs common argument mistakes, such as numbers sent as
+// strings, and checks the result against the tool's parameter schema before
+// the handler runs. Every violation is reported so the model can fix them
+// all in one retry.
+func Validated(tool openai.ToolFunction, next openai.ToolHandler) openai.ToolHandler {
+	return func(ctx context.Context, args map[string]interface{}) (string, error) {
+		if tool.Parameters != nil {
+			if args == nil {
+				args = map[string]interface{}{}
+			}
+			repaired, notes := schema.Repair(tool.Parameters, args)
+			if len(notes) > 0 {
+				currentToolLogger().Info("repaired tool arguments", "tool", tool.Name, "repairs", notes)
+			}
+			if errs := schema.Validate(tool.Parameters, repaired); len(errs) > 0 {
+				return "", argumentError(tool, errs)
+			}
+			args, _ = repaired.(map[string]interface{})
+		}
+		return next(ctx, args)
+	}
+}
+
+// argumentError lists every schema violation with its JSON pointer, followed
+// by the properties the tool accepts
+func argumentError(tool openai.ToolFunction, errs []schema.Error) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid arguments for %s:", tool.Name)
+	for _, e := range errs {
+		path := e.Path
+		if path == "" {
+			path = "/"
+		}
+		fmt.Fprintf(&b, "\n- %s: %s", path, e.Message)
+	}
+
+	if props, ok := tool.Parameters["properties"].(map[string]interface{}); ok && len(props) > 0 {
+		required := make(map[string]bool)
+		for _, name := range stringList(tool.Parameters["required"]) {
+			required[name] = true
+		}
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		parts := make([]string, len(names))
+		for i, name := range names {
+			parts[i] = name
+			if prop, ok := props[name].(map[string]interface{}); ok {
+				if t, ok := prop["type"].(string); ok {
+					parts[i] += " (" + t + ")"
+				}
+			}
+			if required[name] {
+				parts[i] += " required"
+			}
+		}
+		fmt.Fprintf(&b, "\nExpected properties: %s. Fix the arguments and call %s again.", strings.Join(parts, ", "), tool.Name)
+	}
+	return fmt.Errorf("%s", b.String())
+}
+
+// stringList accepts the []string or []interface{} forms of "required"
+func stringList(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		var out []string
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func truncateOutput(output string, max int) string {
+	if len(output) <= max {
+		return output
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(output[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s\n... [output truncated: showing %d of %d bytes]", output[:cut], cut, len(output))
+}
+
+// secretPatterns match credentials in free text, such as error messages
+// and logged arguments. The first group, when present, is kept so that
+// "token=abc" becomes "token=[REDACTED]".
+var secretPatterns = append([]*regexp.Regexp{
+	regexp.MustCompile(`(?i)((?:api[_-]?key|access[_-]?token|auth[_-]?token|token|secret|passw(?:or)?d)["']?\s*[:=]\s*["']?)[^\s"',;]{4,}`),
+	regexp.MustCompile(`(?i)(authorization:\s*(?:bearer|basic)\s+)\S+`),
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/-]{16,}=*`),
+}, tokenPatterns...)
+
+// tokenPatterns match credentials by their well-known formats, which do not
+// occur in ordinary code
+var tokenPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`()\bsk-[A-Za-z0-9_-]{16,}`),
+	regexp.MustCompile(`()\bgh[pousr]_[A-Za-z0-9]{20,}`),
+	regexp.MustCompile(`()\bAKIA[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`()-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+}
+
+// minSecretValue is the shortest environment value redacted verbatim;
+// shorter values would match ordinary text
+const minSecretValue = 8
+
+// redactSecrets replaces credentials in text: well-known token formats,
+// key=value assignments and the values of credential environment variables
+func redactSecrets(text string) string {
+	return redact(text, secretPatterns)
+}
+
+// redactTokens replaces well-known token formats and the values of
+// credential environment variables in tool output, leaving assignments
+// alone
+func redactTokens(text string) string {
+	return redact(text, tokenPatterns)
+}
+
+func redact(text string, patterns []*regexp.Regexp) string {
+	if text == "" {
+		return text
+	}
+	for _, pattern := range patterns {
+		text = pattern.ReplaceAllString(text, "${1}"+redactedText)
+	}
+	for _, value := range secretEnvValues() {
+		text = strings.ReplaceAll(text, value, redactedText)
+	}
+	return text
+}
+
+// secretEnvValues returns the values of environment variables that look like
+// credentials, longest first so that overlapping values are fully replaced
+func secretEnvValues() []string {
+	var values []string
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		if secretEnv.MatchString(name) && len(value) >= minSecretValue {
+			values = append(values, value)
+		}
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	return values
+}
+
+// redactArgs returns a copy of args with secrets removed from string values,
+// for logging
+func redactArgs(args map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(args))
+	for key, value := range args {
+		if s, ok := value.(string); ok {
+			value = redactSecrets(s)
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+var (
+	loggerMu   sync.RWMutex
+	toolLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+)
+
+// SetToolLogger sets where the Logged middleware records tool calls; nil
+// discards them
+func SetToolLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	toolLogger = logger
+}
+
+func currentToolLogger() *slog.Logger {
+	loggerMu.RLock()
+	defer loggerMu.RUnlock()
+	return toolLogger
+}
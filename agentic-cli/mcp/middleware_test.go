@@ -0,0 +1,114 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/vivesm/GOSS-CLI/agentic-cli/openai"
+)
+
+func TestToolMiddleware(t *testing.T) {
+	t.Setenv("GOSS_TEST_API_KEY", "hunter2-very-secret")
+	var logs bytes.Buffer
+	SetToolLogger(slog.New(slog.NewJSONHandler(&logs, nil)))
+	t.Cleanup(func() { SetToolLogger(nil) })
+
+	client := openai.NewClient("http://localhost", "")
+	client.Use(ToolMiddleware()...)
+	client.AddTool(openai.Tool{Type: "function", Function: openai.ToolFunction{
+		Name: "echo",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"text":   map[string]interface{}{"type": "string"},
+				"repeat": map[string]interface{}{"type": "integer", "minimum": 1},
+			},
+			"required": []string{"text"},
+		},
+		Handler: func(_ context.Context, args map[string]interface{}) (string, error) {
+			text := args["text"].(string)
+			if text == "panic" {
+				panic("boom")
+			}
+			repeat := 1
+			if n, ok := args["repeat"].(float64); ok {
+				repeat = int(n)
+			}
+			return strings.Repeat(text, repeat), nil
+		},
+	}})
+
+	call := func(arguments string) (string, error) {
+		return client.ExecuteTool(context.Background(), openai.ToolCall{
+			Function: openai.Function{Name: "echo", Arguments: arguments},
+		})
+	}
+
+	if _, err := call(`{"repeat": 0}`); err == nil ||
+		!strings.Contains(err.Error(), "invalid arguments for echo:\n- /: missing required property \"text\"\n- /repeat: must be >= 1\n") ||
+		!strings.Contains(err.Error(), "Expected properties: repeat (integer), text (string) required.") {
+		t.Errorf("Expected schema validation errors, got %v", err)
+	}
+	if _, err := call(`{"text": "a", "repeat": "many"}`); err == nil || !strings.Contains(err.Error(), "- /repeat: expected integer, got string") {
+		t.Errorf("Expected an unrepairable value to be reported, got %v", err)
+	}
+
+	// Stringified numbers and trailing commas are repaired
+	if result, err := call("```json\n{\"text\": \"ab\", \"repeat\": \" 3\",}\n```"); err != nil || result != "ababab" {
+		t.Errorf("Expected repaired arguments to run, got %q (%v)", result, err)
+	}
+
+	if _, err := call(`{"text": "panic"}`); err == nil || !strings.Contains(err.Error(), "tool echo failed unexpectedly: boom") {
+		t.Errorf("Expected the panic to be recovered, got %v", err)
+	}
+
+	result, err := call(`{"text": "key hunter2-very-secret, api_key=abcd1234 and sk-abcdefghijklmnopqrstuv"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(result, "hunter2") || strings.Contains(result, "sk-abc") ||
+		!strings.Contains(result, "api_key=abcd1234") {
+		t.Errorf("Expected tokens to be redacted and assignments kept, got %q", result)
+	}
+	if strings.Contains(logs.String(), "abcd1234") {
+		t.Errorf("Expected assignments to be redacted in the log:\n%s", logs.String())
+	}
+
+	result, err = call(`{"text": "ééé", "repeat": 50000}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) > DefaultMaxToolOutput+100 || !strings.Contains(result, "[output truncated: showing 131072 of 300000 bytes]") {
+		t.Errorf("Expected the output to be truncated, got %d bytes ending %q", len(result), result[len(result)-60:])
+	}
+
+	if !strings.Contains(logs.String(), `"msg":"tool call","tool":"echo"`) || !strings.Contains(logs.String(), `"duration_ms":`) ||
+		!strings.Contains(logs.String(), `"msg":"tool panicked"`) || strings.Contains(logs.String(), "hunter2") {
+		t.Errorf("Unexpected log:\n%s", logs.String())
+	}
+}
+
+func TestReadFileKeepsCredentialCode(t *testing.T) {
+	source := "package search\n\nfunc newClient(cfg Config) *Client {\n\tcfg.APIKey = os.Getenv(cfg.APIKeyEnv)\n\treturn &Client{apiKey: cfg.APIKey, timeout: cfg.Timeout}\n}\n"
+	chdirTemp(t, map[string]string{"search.go": source})
+
+	client := openai.NewClient("http://localhost", "")
+	client.Use(ToolMiddleware()...)
+	for _, tool := range builtinTools() {
+		client.AddTool(tool)
+	}
+	result, err := client.ExecuteTool(context.Background(), openai.ToolCall{
+		Function: openai.Function{Name: "read_file", Arguments: `{"path": "search.go"}`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"\tcfg.APIKey = os.Getenv(cfg.APIKeyEnv)\n", "\treturn &Client{apiKey: cfg.APIKey, timeout: cfg.Timeout}\n"} {
+		if !strings.Contains(result, line) {
+			t.Errorf("Expected %q unchanged in:\n%s", line, result)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vivesm/GOSS-CLI/agentic-cli/openai"
+)
+
+const (
+	// DefaultPluginTimeout is how long a plugin may run unless its manifest
+	// says otherwise
+	DefaultPluginTimeout = 30 * time.Second
+	// maxPluginOutput caps the JSON a plugin may write to stdout
+	maxPluginOutput = 4 * 1024 * 1024
+	// maxPluginStderr is how much of stderr is kept for error messages
+	maxPluginStderr = 4 * 1024
+)
+
+// pluginName matches names the OpenAI API accepts for functions
+var pluginName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// PluginManifest describes an external tool: an executable that reads its
+// arguments as a JSON object on stdin and writes {"result": ...} or
+// {"error": "..."} as JSON to stdout
+type PluginManifest struct {
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description"`
+	Command        string                 `json:"command,omitempty"` // Executable, relative to the manifest; defaults to the manifest name without .json
+	Args           []string               `json:"args,omitempty"`
+	Parameters     map[string]interface{} `json:"parameters"`
+	ReadOnly       bool                   `json:"readOnly,omitempty"` // Runs without approval and alongside other read-only tools
+	TimeoutSeconds float64                `json:"timeoutSeconds,omitempty"`
+}
+
+// plugin is a loaded manifest with its resolved executable
+type plugin struct {
+	PluginManifest
+	path    string
+	timeout time.Duration
+}
+
+// DefaultPluginDir returns the directory plugin manifests are loaded from,
+// ~/.config/goss/tools on Linux
+func DefaultPluginDir() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "goss", "tools"), nil
+}
+
+// LoadPlugins reads every *.json manifest in dir and returns the tools they
+// describe. A missing directory has no plugins; an invalid manifest is
+// skipped and reported in the returned errors.
+func LoadPlugins(dir string) ([]openai.Tool, []error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, []error{fmt.Errorf("failed to read plugin directory: %w", err)}
+	}
+
+	reserved := make(map[string]bool)
+	for _, tool := range builtinTools() {
+		reserved[tool.Function.Name] = true
+	}
+
+	var tools []openai.Tool
+	var errs []error
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		p, err := loadPlugin(filepath.Join(dir, entry.Name()))
+		if err == nil && reserved[p.Name] {
+			err = fmt.Errorf("name %q is already used by another tool", p.Name)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: %w", entry.Name(), err))
+			continue
+		}
+		reserved[p.Name] = true
+		tools = append(tools, p.tool())
+	}
+	return tools, errs
+}
+
+func loadPlugin(manifestPath string) (*plugin, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	p := &plugin{timeout: DefaultPluginTimeout}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&p.PluginManifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	switch {
+	case !pluginName.MatchString(p.Name):
+		return nil, fmt.Errorf("name %q must be 1-64 letters, digits, underscores or dashes", p.Name)
+	case strings.TrimSpace(p.Description) == "":
+		return nil, fmt.Errorf("description is required")
+	case p.Parameters == nil:
+		p.Parameters = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	case p.Parameters["type"] != "object":
+		return nil, fmt.Errorf(`parameters must be a JSON Schema with "type": "object"`)
+	}
+	if p.TimeoutSeconds > 0 {
+		p.timeout = time.Duration(p.TimeoutSeconds * float64(time.Second))
+	}
+	if p.timeout > MaxCommandTimeout {
+		p.timeout = MaxCommandTimeout
+	}
+
+	command := p.Command
+	if command == "" {
+		command = strings.TrimSuffix(filepath.Base(manifestPath), ".json")
+	}
+	if !filepath.IsAbs(command) {
+		command = filepath.Join(filepath.Dir(manifestPath), command)
+	}
+	info, err := os.Stat(command)
+	if err != nil {
+		return nil, fmt.Errorf("command: %w", err)
+	}
+	if info.IsDir() || info.Mode().Perm()&0111 == 0 {
+		return nil, fmt.Errorf("command %s is not executable", command)
+	}
+	p.path = command
+	return p, nil
+}
+
+func (p *plugin) tool() openai.Tool {
+	return openai.Tool{
+		Type: "function",
+		Function: openai.ToolFunction{
+			Name:        p.Name,
+			Description: p.Description,
+			Parameters:  p.Parameters,
+			Handler:     p.run,
+			ReadOnly:    p.ReadOnly,
+		},
+	}
+}
+
+// run applies the command policy to the plugin, then executes it in the
+// working directory with the same scrubbed environment as run_command
+func (p *plugin) run(ctx context.Context, args map[string]interface{}) (string, error) {
+	policy := currentPolicy().Commands
+	if denied := deniedBy(p.Name, policy.Deny); denied != "" {
+		return "", fmt.Errorf("plugin %s is denied by policy (matches %q)", p.Name, denied)
+	}
+	if err := p.checkPaths(args); err != nil {
+		return "", fmt.Errorf("security validation failed: %w", err)
+	}
+	if !p.ReadOnly && !allowListed(p.Name, policy.Allow) {
+		err := requestApproval(ctx, ApprovalRequest{
+			Tool:   p.Name,
+			Action: fmt.Sprintf("run the %s plugin (%s)", p.Name, p.path),
+			Reason: "plugins that are not read-only need approval",
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	input, err := json.Marshal(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode arguments: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.path, p.Args...)
+	cmd.Env = append(scrubbedEnv(os.Environ()), "GOSS_TOOL_NAME="+p.Name)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.WaitDelay = time.Second
+	stdout := &cappedBuffer{max: maxPluginOutput}
+	stderr := &cappedBuffer{max: maxPluginStderr}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("plugin %s timed out after %s", p.Name, p.timeout)
+		}
+		message := strings.TrimSpace(stderr.buf.String())
+		if message == "" {
+			message = strings.TrimSpace(stdout.buf.String())
+		}
+		return "", fmt.Errorf("plugin %s failed: %v: %s", p.Name, err, message)
+	}
+	if stdout.dropped > 0 {
+		return "", fmt.Errorf("plugin %s wrote more than %d bytes", p.Name, maxPluginOutput)
+	}
+	return decodePluginOutput(p.Name, stdout.buf.Bytes())
+}
+
+// checkPaths runs string arguments whose schema has "format": "path"
+// through the sandbox
+func (p *plugin) checkPaths(args map[string]interface{}) error {
+	props, _ := p.Parameters["properties"].(map[string]interface{})
+	for name, value := range args {
+		prop, _ := props[name].(map[string]interface{})
+		if prop["format"] != "path" {
+			continue
+		}
+		if path, ok := value.(string); ok {
+			if _, err := validatePath(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decodePluginOutput returns the result of a plugin response: strings as
+// they are, other JSON values indented
+func decodePluginOutput(name string, output []byte) (string, error) {
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  string          `json:"error"`
+	}
+	if err := json.Unmarshal(output, &response); err != nil {
+		return "", fmt.Errorf("plugin %s returned invalid JSON (%v); it must write {\"result\": ...} or {\"error\": \"...\"}", name, err)
+	}
+	if response.Error != "" {
+		return "", fmt.Errorf("%s: %s", name, response.Error)
+	}
+
+	var text string
+	if err := json.Unmarshal(response.Result, &text); err == nil {
+		return text, nil
+	}
+	if len(response.Result) == 0 || string(response.Result) == "null" {
+		return "(no result)", nil
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, response.Result, "", "  "); err != nil {
+		return string(response.Result), nil
+	}
+	return indented.String(), nil
+}
+
+// allowListed reports whether the plugin has a "plugin:<name>" entry in the
+// command allow list. A bare name is not enough, so that a plugin called rg
+// or ls is not approved by the entry for the command.
+func allowListed(name string, allow []string) bool {
+	for _, entry := range allow {
+		if strings.TrimSpace(entry) == "plugin:"+name {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	pluginsMu sync.RWMutex
+	plugins   []openai.Tool
+)
+
+// SetPlugins registers the plugin tools returned by LoadPlugins
+func SetPlugins(tools []openai.Tool) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	plugins = tools
+}
+
+// Plugins returns the registered plugin tools, sorted by name
+func Plugins() []openai.Tool {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	tools := append([]openai.Tool(nil), plugins...)
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Function.Name < tools[j].Function.Name })
+	return tools
+}
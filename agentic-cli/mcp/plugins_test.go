@@ -0,0 +1,119 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePlugin writes a manifest and a shell script executable to dir
+func writePlugin(t *testing.T, dir, name, manifest, script string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name+".json"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if script != "" {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPlugins(t *testing.T) {
+	chdirTemp(t, map[string]string{"notes.txt": "hello\n"})
+	dir := t.TempDir()
+	t.Setenv("GOSS_TEST_API_KEY", "sk-secret")
+
+	writePlugin(t, dir, "echo_args", `{
+		"name": "echo_args",
+		"description": "Echo the arguments",
+		"readOnly": true,
+		"parameters": {"type": "object", "properties": {"file": {"type": "string", "format": "path"}}}
+	}`, `read input; printf '{"result": {"args": %s, "key": "%s"}}' "$input" "$GOSS_TEST_API_KEY"`)
+	writePlugin(t, dir, "word_count", `{"name": "word_count", "description": "Count words", "readOnly": true}`,
+		`printf '{"result": "3 words"}'`)
+	writePlugin(t, dir, "fails", `{"name": "fails", "description": "Always fails", "readOnly": true}`,
+		`echo "something broke" >&2; exit 3`)
+	writePlugin(t, dir, "reports", `{"name": "reports", "description": "Reports an error", "readOnly": true}`,
+		`printf '{"error": "ticket not found"}'`)
+	writePlugin(t, dir, "slow", `{"name": "slow", "description": "Sleeps", "readOnly": true, "timeoutSeconds": 0.2}`,
+		`sleep 5`)
+	writePlugin(t, dir, "deploy", `{"name": "deploy", "description": "Deploys"}`, `printf '{"result": "deployed"}'`)
+	writePlugin(t, dir, "read_file", `{"name": "read_file", "description": "Shadows a built-in"}`, `true`)
+	writePlugin(t, dir, "missing", `{"name": "missing", "description": "Has no executable"}`, "")
+	writePlugin(t, dir, "bad", `{"name": "bad tool", "description": "Invalid name"}`, `true`)
+
+	tools, errs := LoadPlugins(dir)
+	if len(tools) != 6 {
+		t.Fatalf("Expected 6 plugins, got %d", len(tools))
+	}
+	if joined := strings.Join(errorStrings(errs), "\n"); len(errs) != 3 ||
+		!strings.Contains(joined, `plugin read_file.json: name "read_file" is already used`) ||
+		!strings.Contains(joined, "plugin missing.json: command:") ||
+		!strings.Contains(joined, `plugin bad.json: name "bad tool" must be`) {
+		t.Errorf("Unexpected load errors:\n%s", joined)
+	}
+	SetPlugins(tools)
+	t.Cleanup(func() { SetPlugins(nil) })
+
+	result, err := callTool(context.Background(), "echo_args", map[string]interface{}{"file": "notes.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result, `"file": "notes.txt"`) || strings.Contains(result, "sk-secret") {
+		t.Errorf("Expected the arguments back without secrets, got %s", result)
+	}
+	if result, err := callTool(context.Background(), "word_count", nil); err != nil || result != "3 words" {
+		t.Errorf("Expected a string result as is, got %q (%v)", result, err)
+	}
+
+	errorTests := map[string]string{
+		"fails":   "plugin fails failed: exit status 3: something broke",
+		"reports": "reports: ticket not found",
+		"slow":    "plugin slow timed out after 200ms",
+		"deploy":  "deploy plugin",
+	}
+	for name, want := range errorTests {
+		if _, err := callTool(context.Background(), name, map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected an error containing %q, got %v", name, want, err)
+		}
+	}
+	if _, err := callTool(context.Background(), "echo_args", map[string]interface{}{"file": "../outside"}); err == nil ||
+		!strings.Contains(err.Error(), "security validation failed") {
+		t.Errorf("Expected path arguments to be sandboxed, got %v", err)
+	}
+
+	requests := withApprover(t, true)
+	if result, err := callTool(context.Background(), "deploy", nil); err != nil || result != "deployed" || len(*requests) != 1 {
+		t.Errorf("Expected the approved plugin to run, got %q (%v) after %d requests", result, err, len(*requests))
+	}
+
+	// A command allow-list entry with the plugin's name is not enough
+	SetPolicy(Policy{Commands: CommandPolicy{Allow: []string{"deploy"}}})
+	t.Cleanup(func() { SetPolicy(DefaultPolicy()) })
+	if _, err := callTool(context.Background(), "deploy", nil); err != nil || len(*requests) != 2 {
+		t.Errorf("Expected the plugin to ask despite the command entry, got %v after %d requests", err, len(*requests))
+	}
+	SetPolicy(Policy{Commands: CommandPolicy{Allow: []string{"plugin:deploy"}}})
+	if _, err := callTool(context.Background(), "deploy", nil); err != nil || len(*requests) != 2 {
+		t.Errorf("Expected the allow-listed plugin to run without asking, got %v after %d requests", err, len(*requests))
+	}
+
+	names, err := ResolveTools("readonly", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if joined := strings.Join(names, ","); !strings.Contains(joined, "echo_args") || strings.Contains(joined, "deploy") {
+		t.Errorf("Expected read-only plugins in the readonly profile, got %s", joined)
+	}
+}
+
+func errorStrings(errs []error) []string {
+	out := make([]string, len(errs))
+	for i, err := range errs {
+		out[i] = err.Error()
+	}
+	return out
+}
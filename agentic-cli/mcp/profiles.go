@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vivesm/GOSS-CLI/agentic-cli/openai"
+)
+
+// DefaultToolProfile enables every built-in tool
+const DefaultToolProfile = "dev"
+
+// CreateTools returns every built-in MCP tool followed by the registered
+// plugins
+func CreateTools() []openai.Tool {
+	return append(builtinTools(), Plugins()...)
+}
+
+func builtinTools() []openai.Tool {
+	var tools []openai.Tool
+	tools = append(tools, CreateFilesystemTools()...)
+	tools = append(tools, CreateWebSearchTools()...)
+	tools = append(tools, CreateCommandTools()...)
+	return tools
+}
+
+// builtinProfiles returns the tool names of the built-in profiles: readonly
+// has every tool that never changes anything, research the web tools and
+// those that read and search files, and dev every tool. Plugins are part of
+// readonly and dev.
+func builtinProfiles() map[string][]string {
+	var readonly, all []string
+	for _, tool := range CreateTools() {
+		all = append(all, tool.Function.Name)
+		if tool.Function.ReadOnly {
+			readonly = append(readonly, tool.Function.Name)
+		}
+	}
+	return map[string][]string{
+		"readonly": readonly,
+		"research": {"web_search", "fetch_url", "read_file", "list_directory", "search_files", "grep_files"},
+		"dev":      all,
+	}
+}
+
+// ToolProfiles returns the built-in profiles merged with custom ones, which
+// replace built-in profiles of the same name
+func ToolProfiles(custom map[string][]string) map[string][]string {
+	profiles := builtinProfiles()
+	for name, tools := range custom {
+		profiles[name] = tools
+	}
+	return profiles
+}
+
+// ResolveTools expands a comma-separated list of profile and tool names,
+// such as "research,write_file", into tool names in the order given
+func ResolveTools(spec string, custom map[string][]string) ([]string, error) {
+	profiles := ToolProfiles(custom)
+	known := make(map[string]bool)
+	for _, tool := range CreateTools() {
+		known[tool.Function.Name] = true
+	}
+
+	names := []string{}
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		switch {
+		case item == "":
+		case profiles[item] != nil:
+			for _, name := range profiles[item] {
+				add(name)
+			}
+		case known[item]:
+			add(item)
+		default:
+			return nil, fmt.Errorf("unknown tool or profile %q (profiles: %s)", item, strings.Join(profileNames(profiles), ", "))
+		}
+	}
+	return names, nil
+}
+
+func profileNames(profiles map[string][]string) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
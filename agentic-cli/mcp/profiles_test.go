@@ -0,0 +1,33 @@
+package mcp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveTools(t *testing.T) {
+	custom := map[string][]string{"review": {"read_file", "grep_files"}}
+
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"research", "web_search,fetch_url,read_file,list_directory,search_files,grep_files"},
+		{"review, write_file,read_file", "read_file,grep_files,write_file"},
+		{"readonly", "read_file,list_directory,search_files,grep_files,stat_path,directory_tree,web_search,fetch_url"},
+	}
+	for _, tt := range tests {
+		names, err := ResolveTools(tt.spec, custom)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Join(names, ","); got != tt.want {
+			t.Errorf("ResolveTools(%q) = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+
+	if _, err := ResolveTools("research,teleport", custom); err == nil ||
+		!strings.Contains(err.Error(), `unknown tool or profile "teleport" (profiles: dev, readonly, research, review)`) {
+		t.Errorf("Expected an unknown name to be reported, got %v", err)
+	}
+}
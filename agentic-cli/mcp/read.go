@@ -0,0 +1,73 @@
+package mcp
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultReadLines is how many lines read_file returns without a limit
+	DefaultReadLines = 500
+	// MaxReadLines caps the limit argument of read_file
+	MaxReadLines = 2000
+	// MaxReadBytes caps the size of a single read_file result
+	MaxReadBytes = 64 * 1024
+	// maxReadLineLength truncates very long lines such as minified code
+	maxReadLineLength = 2000
+)
+
+// numberedLines formats up to limit lines of content starting at the
+// 1-based offset, prefixed with line numbers. When lines remain, a marker
+// tells the model how to continue reading.
+func numberedLines(path string, content []byte, offset, limit int) string {
+	if len(content) == 0 {
+		return fmt.Sprintf("%s is empty", path)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	if strings.HasSuffix(string(content), "\n") {
+		lines = lines[:len(lines)-1]
+	}
+	total := len(lines)
+
+	if offset > total {
+		return fmt.Sprintf("%s has %d lines; offset %d is past the end of the file", path, total, offset)
+	}
+
+	end := min(offset-1+limit, total)
+	width := len(fmt.Sprint(end))
+
+	var result strings.Builder
+	last := offset - 1
+	for i := offset - 1; i < end; i++ {
+		line := lines[i]
+		if len(line) > maxReadLineLength {
+			// Cut at a rune boundary so the result stays valid UTF-8
+			cut := maxReadLineLength
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			line = line[:cut] + "… [line truncated]"
+		}
+		entry := fmt.Sprintf("%*d\t%s\n", width, i+1, line)
+		if result.Len()+len(entry) > MaxReadBytes && i > offset-1 {
+			break
+		}
+		result.WriteString(entry)
+		last = i + 1
+	}
+
+	if last < total {
+		result.WriteString(fmt.Sprintf("\n... [more available: showing lines %d-%d of %d; call read_file with offset=%d to continue]\n",
+			offset, last, total, last+1))
+	}
+	return result.String()
+}
+
+// binarySummary describes a binary file instead of returning its bytes
+func binarySummary(path string, content []byte) string {
+	return fmt.Sprintf("%s is a binary file (%d bytes, detected type %s); its content is not shown",
+		path, len(content), http.DetectContentType(content))
+}
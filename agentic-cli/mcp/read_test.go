@@ -0,0 +1,105 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestReadFileRanges(t *testing.T) {
+	var long strings.Builder
+	for i := 1; i <= 1200; i++ {
+		fmt.Fprintf(&long, "line %d\n", i)
+	}
+	chdirTemp(t, map[string]string{
+		"short.txt": "alpha\nbeta\ngamma",
+		"long.txt":  long.String(),
+		"image.png": "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR",
+		"empty.txt": "",
+	})
+
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "whole short file",
+			args:    map[string]interface{}{"path": "short.txt"},
+			want:    []string{"1\talpha\n2\tbeta\n3\tgamma\n"},
+			notWant: []string{"more available"},
+		},
+		{
+			name:    "default page",
+			args:    map[string]interface{}{"path": "long.txt"},
+			want:    []string{"  1\tline 1\n", "\n500\tline 500\n", "showing lines 1-500 of 1200; call read_file with offset=501"},
+			notWant: []string{"line 501\n"},
+		},
+		{
+			name:    "offset and limit",
+			args:    map[string]interface{}{"path": "long.txt", "offset": float64(1190), "limit": float64(5)},
+			want:    []string{"1190\tline 1190\n", "1194\tline 1194\n", "offset=1195"},
+			notWant: []string{"line 1195\n"},
+		},
+		{
+			name:    "last page",
+			args:    map[string]interface{}{"path": "long.txt", "offset": float64(1199)},
+			want:    []string{"1199\tline 1199\n1200\tline 1200\n"},
+			notWant: []string{"more available"},
+		},
+		{
+			name: "past the end",
+			args: map[string]interface{}{"path": "short.txt", "offset": float64(10)},
+			want: []string{"short.txt has 3 lines; offset 10 is past the end"},
+		},
+		{
+			name: "binary",
+			args: map[string]interface{}{"path": "image.png"},
+			want: []string{"image.png is a binary file", "image/png"},
+		},
+		{
+			name: "empty",
+			args: map[string]interface{}{"path": "empty.txt"},
+			want: []string{"empty.txt is empty"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := callTool(context.Background(), "read_file", tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(result, want) {
+					t.Errorf("Expected %q in:\n%.300s", want, result)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(result, notWant) {
+					t.Errorf("Did not expect %q in result", notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestReadFileTruncatesLongLines(t *testing.T) {
+	// Two-byte runes after one ASCII byte put the cut inside a rune
+	chdirTemp(t, map[string]string{"wide.txt": "a" + strings.Repeat("é", maxReadLineLength) + "\nnext"})
+
+	result, err := callTool(context.Background(), "read_file", map[string]interface{}{"path": "wide.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.ValidString(result) {
+		t.Errorf("Expected valid UTF-8, got %q", result[len(result)-80:])
+	}
+	want := "1\ta" + strings.Repeat("é", (maxReadLineLength-1)/2) + "… [line truncated]\n2\tnext\n"
+	if !strings.Contains(result, want) {
+		t.Errorf("Expected the line cut before the split rune, got:\n%.300s", result)
+	}
+}
@@ -0,0 +1,258 @@
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxSymlinkHops bounds how many symlinks are followed in one path
+const maxSymlinkHops = 40
+
+// Sandbox confines tool paths to a set of root directories. Paths are made
+// absolute, their symlinks are resolved before any ".." after them, and the
+// result must lie inside a root and match none of the deny rules.
+type Sandbox struct {
+	roots []string
+	deny  []denyRule
+}
+
+// denyRule is a compiled deny glob
+type denyRule struct {
+	glob     string
+	pattern  *regexp.Regexp
+	absolute bool // Matched against the absolute path rather than the root-relative one
+}
+
+// NewSandbox returns a sandbox for the given roots and deny globs.
+//
+// Deny globs use .gitignore syntax: a pattern without a slash, such as
+// ".git" or "*.pem", matches that name at any depth; a pattern with a slash,
+// such as "secrets/**", is anchored at each root; and an absolute pattern,
+// such as "/etc/shadow", matches the absolute path. A rule matching a
+// directory also denies everything below it.
+func NewSandbox(roots []string, deny []string) (*Sandbox, error) {
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("sandbox requires at least one root")
+	}
+
+	s := &Sandbox{}
+	for _, root := range roots {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve root %s: %w", root, err)
+		}
+		resolved, err := resolvePath(abs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve root %s: %w", root, err)
+		}
+		s.roots = append(s.roots, resolved)
+	}
+
+	for _, glob := range deny {
+		glob = strings.TrimSpace(glob)
+		if glob == "" {
+			continue
+		}
+		rule := denyRule{glob: glob}
+		pattern := strings.TrimRight(filepath.ToSlash(glob), "/")
+		switch {
+		case filepath.IsAbs(glob):
+			rule.absolute = true
+			pattern = globToRegexp(pattern)
+		case strings.Contains(pattern, "/"):
+			pattern = globToRegexp(strings.TrimPrefix(pattern, "./"))
+		default:
+			pattern = "(.*/)?" + globToRegexp(pattern)
+		}
+		re, err := regexp.Compile("^" + pattern + "$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid deny rule %q: %w", glob, err)
+		}
+		rule.pattern = re
+		s.deny = append(s.deny, rule)
+	}
+	return s, nil
+}
+
+// Roots returns the resolved root directories
+func (s *Sandbox) Roots() []string {
+	return append([]string(nil), s.roots...)
+}
+
+// Resolve returns the absolute, symlink-resolved form of path, or an error
+// if it escapes every root or matches a deny rule. Relative paths are
+// interpreted against the working directory.
+func (s *Sandbox) Resolve(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("path cannot be empty")
+	}
+	if len(path) > MaxPathLength {
+		return "", fmt.Errorf("path too long (max %d characters)", MaxPathLength)
+	}
+
+	abs, err := absPath(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve absolute path: %w", err)
+	}
+	resolved, err := resolvePath(abs)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve '%s': %w", path, err)
+	}
+	return resolved, s.check(path, resolved)
+}
+
+// ResolveLink is Resolve for operations on a symlink itself rather than on
+// what it points to, such as deleting or moving it: the returned path has
+// its directory resolved but keeps its last element. Both the link and its
+// target must pass the checks.
+func (s *Sandbox) ResolveLink(path string) (string, error) {
+	resolved, err := s.Resolve(path)
+	if err != nil {
+		return "", err
+	}
+
+	abs, err := absPath(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve absolute path: %w", err)
+	}
+	dir, name := filepath.Split(abs)
+	if name == "" || name == "." || name == ".." {
+		return resolved, nil
+	}
+	parent, err := resolvePath(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve '%s': %w", path, err)
+	}
+	link := filepath.Join(parent, name)
+	return link, s.check(path, link)
+}
+
+// check returns an error if resolved, the resolved form of path, lies
+// outside every root or matches a deny rule
+func (s *Sandbox) check(path, resolved string) error {
+	root, ok := s.rootOf(resolved)
+	if !ok {
+		return fmt.Errorf("path '%s' attempts to access files outside the workspace", path)
+	}
+	if rule, denied := s.denied(root, resolved); denied {
+		return fmt.Errorf("access to '%s' is restricted (matches %q)", path, rule)
+	}
+	return nil
+}
+
+// rootOf returns the root containing path
+func (s *Sandbox) rootOf(path string) (string, bool) {
+	for _, root := range s.roots {
+		if within(root, path) {
+			return root, true
+		}
+	}
+	return "", false
+}
+
+// denied returns the first deny rule matching path or one of its parents
+func (s *Sandbox) denied(root, path string) (string, bool) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	abs := filepath.ToSlash(path)
+
+	for _, rule := range s.deny {
+		subject := rel
+		if rule.absolute {
+			subject = abs
+		} else if rel == "." {
+			continue
+		}
+		for {
+			if rule.pattern.MatchString(subject) {
+				return rule.glob, true
+			}
+			i := strings.LastIndex(subject, "/")
+			if i <= 0 {
+				break
+			}
+			subject = subject[:i]
+		}
+	}
+	return "", false
+}
+
+// within reports whether path is root or lies below it
+func within(root, path string) bool {
+	if path == root {
+		return true
+	}
+	if !strings.HasSuffix(root, string(filepath.Separator)) {
+		root += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, root)
+}
+
+// absPath makes path absolute against the working directory without
+// cleaning it: ".." may only be applied once the symlinks before it are
+// resolved, which resolvePath does.
+func absPath(path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return path, nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return wd + string(filepath.Separator) + path, nil
+}
+
+// resolvePath resolves the absolute path abs one component at a time,
+// following each symlink before applying the ".." after it, as the kernel
+// does. Components that do not exist are kept as they are, and dangling
+// links are followed so their targets are checked too.
+func resolvePath(abs string) (string, error) {
+	volume := filepath.VolumeName(abs)
+	resolved := volume + string(filepath.Separator)
+	pending := splitPath(abs[len(volume):])
+
+	for hops := 0; len(pending) > 0; {
+		name := pending[0]
+		pending = pending[1:]
+		switch name {
+		case ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, name)
+		info, err := os.Lstat(next)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		if hops++; hops > maxSymlinkHops {
+			return "", fmt.Errorf("too many levels of symbolic links")
+		}
+		target, err := os.Readlink(next)
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(target) {
+			volume = filepath.VolumeName(target)
+			resolved = volume + string(filepath.Separator)
+			target = target[len(volume):]
+		}
+		pending = append(splitPath(target), pending...)
+	}
+	return resolved, nil
+}
+
+// splitPath returns the non-empty components of path
+func splitPath(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool { return os.IsPathSeparator(uint8(r)) })
+}
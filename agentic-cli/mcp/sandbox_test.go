@@ -0,0 +1,260 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// sandboxFixture lays out a workspace next to a sibling directory whose name
+// shares the workspace's prefix, plus symlinks pointing in and out.
+//
+//	base/
+//	├── work/          the sandbox root
+//	│   ├── src/main.go
+//	│   ├── .github/workflows/ci.yml
+//	│   ├── .git/config
+//	│   ├── vendor/node_modules_backup/x.js
+//	│   ├── secrets/token.txt
+//	│   ├── certs/server.pem
+//	│   ├── link-inside  -> src
+//	│   ├── link-outside -> ../work2
+//	│   ├── link-abs     -> /etc
+//	│   ├── link-dangling -> ../work2/new.txt
+//	│   ├── link-chain   -> link-outside
+//	│   └── link-loop    -> link-loop
+//	├── work2/secret.txt
+//	└── other/notes.txt  a second root in some cases
+func sandboxFixture(t *testing.T) (work, work2, other string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require privileges on Windows")
+	}
+
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	work = filepath.Join(base, "work")
+	work2 = filepath.Join(base, "work2")
+	other = filepath.Join(base, "other")
+
+	for _, file := range []string{
+		"work/src/main.go",
+		"work/.github/workflows/ci.yml",
+		"work/.git/config",
+		"work/vendor/node_modules_backup/x.js",
+		"work/secrets/token.txt",
+		"work/certs/server.pem",
+		"work2/secret.txt",
+		"other/notes.txt",
+	} {
+		path := filepath.Join(base, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	links := map[string]string{
+		"link-inside":   "src",
+		"link-outside":  "../work2",
+		"link-abs":      "/etc",
+		"link-dangling": "../work2/new.txt",
+		"link-chain":    "link-outside",
+		"link-loop":     "link-loop",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(work, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return work, work2, other
+}
+
+func TestSandboxResolve(t *testing.T) {
+	work, work2, other := sandboxFixture(t)
+
+	deny := []string{".git", ".ssh", "node_modules", "*.pem", "secrets/**", "/etc/shadow"}
+	single, err := NewSandbox([]string{work}, deny)
+	if err != nil {
+		t.Fatal(err)
+	}
+	multi, err := NewSandbox([]string{work, other}, deny)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		sandbox *Sandbox
+		path    string
+		want    string // Expected resolved path, relative to base; empty when an error is expected
+		errPart string
+	}{
+		// Containment
+		{name: "root itself", sandbox: single, path: work, want: "work"},
+		{name: "file in root", sandbox: single, path: filepath.Join(work, "src/main.go"), want: "work/src/main.go"},
+		{name: "new file in root", sandbox: single, path: filepath.Join(work, "src/new.go"), want: "work/src/new.go"},
+		{name: "new nested dirs", sandbox: single, path: filepath.Join(work, "a/b/c.txt"), want: "work/a/b/c.txt"},
+		{name: "dot segments staying inside", sandbox: single, path: filepath.Join(work, "src/../src/./main.go"), want: "work/src/main.go"},
+		{name: "sibling sharing prefix", sandbox: single, path: filepath.Join(work2, "secret.txt"), errPart: "outside the workspace"},
+		{name: "sibling directory itself", sandbox: single, path: work2, errPart: "outside the workspace"},
+		{name: "parent traversal", sandbox: single, path: work + "/../work2/secret.txt", errPart: "outside the workspace"},
+		{name: "parent of root", sandbox: single, path: filepath.Dir(work), errPart: "outside the workspace"},
+		{name: "absolute system path", sandbox: single, path: "/etc/hosts", errPart: "outside the workspace"},
+		{name: "second root", sandbox: multi, path: filepath.Join(other, "notes.txt"), want: "other/notes.txt"},
+		{name: "second root sibling still outside", sandbox: multi, path: filepath.Join(work2, "secret.txt"), errPart: "outside the workspace"},
+
+		// Symlinks
+		{name: "link to inside", sandbox: single, path: filepath.Join(work, "link-inside/main.go"), want: "work/src/main.go"},
+		{name: "link to sibling", sandbox: single, path: filepath.Join(work, "link-outside/secret.txt"), errPart: "outside the workspace"},
+		{name: "new file under escaping link", sandbox: single, path: filepath.Join(work, "link-outside/new/file.txt"), errPart: "outside the workspace"},
+		{name: "link to /etc", sandbox: single, path: filepath.Join(work, "link-abs/passwd"), errPart: "outside the workspace"},
+		{name: "dangling link pointing outside", sandbox: single, path: filepath.Join(work, "link-dangling"), errPart: "outside the workspace"},
+		{name: "chained links", sandbox: single, path: filepath.Join(work, "link-chain/secret.txt"), errPart: "outside the workspace"},
+		{name: "link loop", sandbox: single, path: filepath.Join(work, "link-loop/x"), errPart: "symbolic links"},
+		{name: "parent traversal through link", sandbox: single, path: work + "/link-outside/../work2/secret.txt", errPart: "outside the workspace"},
+		{name: "parent traversal through inside link", sandbox: single, path: work + "/link-inside/../src/main.go", want: "work/src/main.go"},
+		{name: "parent traversal after missing dir", sandbox: single, path: work + "/missing/../src/main.go", want: "work/src/main.go"},
+		{name: "link into second root", sandbox: multi, path: filepath.Join(work, "link-outside"), errPart: "outside the workspace"},
+
+		// Deny rules
+		{name: "git directory", sandbox: single, path: filepath.Join(work, ".git"), errPart: `matches ".git"`},
+		{name: "file inside git directory", sandbox: single, path: filepath.Join(work, ".git/config"), errPart: `matches ".git"`},
+		{name: ".github is not .git", sandbox: single, path: filepath.Join(work, ".github/workflows/ci.yml"), want: "work/.github/workflows/ci.yml"},
+		{name: ".gitignore is not .git", sandbox: single, path: filepath.Join(work, ".gitignore"), want: "work/.gitignore"},
+		{name: "node_modules prefix only", sandbox: single, path: filepath.Join(work, "vendor/node_modules_backup/x.js"), want: "work/vendor/node_modules_backup/x.js"},
+		{name: "nested node_modules", sandbox: single, path: filepath.Join(work, "web/node_modules/react/index.js"), errPart: `matches "node_modules"`},
+		{name: "extension glob", sandbox: single, path: filepath.Join(work, "certs/server.pem"), errPart: `matches "*.pem"`},
+		{name: "anchored glob", sandbox: single, path: filepath.Join(work, "secrets/token.txt"), errPart: `matches "secrets/**"`},
+		{name: "anchored glob does not float", sandbox: single, path: filepath.Join(work, "src/secrets/token.txt"), want: "work/src/secrets/token.txt"},
+		{name: "deny through symlink", sandbox: single, path: filepath.Join(work, "link-inside/../.git/config"), errPart: `matches ".git"`},
+
+		// Input validation
+		{name: "empty", sandbox: single, path: "", errPart: "cannot be empty"},
+		{name: "too long", sandbox: single, path: strings.Repeat("a", MaxPathLength+1), errPart: "too long"},
+	}
+
+	base := filepath.Dir(work)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.sandbox.Resolve(tt.path)
+			if tt.errPart != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errPart) {
+					t.Fatalf("Expected error containing %q, got %q (err %v)", tt.errPart, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if want := filepath.Join(base, tt.want); got != want {
+				t.Errorf("Expected %s, got %s", want, got)
+			}
+		})
+	}
+}
+
+func TestSandboxRelativePaths(t *testing.T) {
+	work, _, _ := sandboxFixture(t)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(work); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	sandbox, err := NewSandbox([]string{"."}, []string{".git"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, ok := range map[string]bool{
+		".":                         true,
+		"src/main.go":               true,
+		"./src":                     true,
+		"../work2/secret.txt":       false,
+		"../work":                   true,
+		"link-outside/secret.txt":   false,
+		".git/config":               false,
+		"src/../../work/src":        true,
+		"src/../../work2/../work/x": true,
+	} {
+		_, err := sandbox.Resolve(path)
+		if (err == nil) != ok {
+			t.Errorf("Resolve(%q): expected allowed=%t, got err %v", path, ok, err)
+		}
+	}
+}
+
+func TestValidatePathUsesPolicy(t *testing.T) {
+	work, _, other := sandboxFixture(t)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(work); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	t.Cleanup(func() { SetPolicy(DefaultPolicy()) })
+
+	if _, err := validatePath(filepath.Join(other, "notes.txt")); err == nil {
+		t.Error("Expected paths outside the working directory to be rejected by default")
+	}
+	if _, err := validatePath("link-outside/../work2/secret.txt"); err == nil {
+		t.Error("Expected .. after a symlink to be applied to the link's target")
+	}
+	if got, err := validatePath("link-inside/../.github/workflows/ci.yml"); err != nil || got != filepath.Join(work, ".github/workflows/ci.yml") {
+		t.Errorf("Expected the resolved path of .github, got %q (%v)", got, err)
+	}
+
+	SetPolicy(Policy{AllowedRoots: []string{work, other}, RestrictedPaths: []string{"*.txt"}})
+	if _, err := validatePath(filepath.Join(other, "notes.txt")); err == nil || !strings.Contains(err.Error(), "restricted") {
+		t.Errorf("Expected the configured deny rule to apply, got %v", err)
+	}
+	if _, err := validatePath(filepath.Join(other, "notes.md")); err != nil {
+		t.Errorf("Expected a configured root to be accessible: %v", err)
+	}
+}
+
+func TestSandboxResolveLink(t *testing.T) {
+	work, _, _ := sandboxFixture(t)
+	s, err := NewSandbox([]string{work}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string // Expected path relative to work; empty when an error is expected
+	}{
+		{path: work + "/link-inside", want: "link-inside"},
+		{path: work + "/link-inside/../link-inside", want: "link-inside"},
+		{path: work + "/link-inside/main.go", want: "src/main.go"},
+		{path: work + "/link-inside/.", want: "src"},
+		{path: work + "/link-outside"},
+		{path: work + "/link-outside/../work2/secret.txt"},
+	}
+	for _, tt := range tests {
+		got, err := s.ResolveLink(tt.path)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %s", tt.path, got)
+			}
+			continue
+		}
+		if want := filepath.Join(work, tt.want); err != nil || got != want {
+			t.Errorf("%s: expected %s, got %q (%v)", tt.path, want, got, err)
+		}
+	}
+}
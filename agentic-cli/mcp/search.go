@@ -0,0 +1,253 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSearchTimeout bounds a single provider request
+const DefaultSearchTimeout = 10 * time.Second
+
+// SearchProvider is a web search backend used by the web_search tool
+type SearchProvider interface {
+	// Name identifies the provider in results and error messages
+	Name() string
+	// Search returns up to count results for query
+	Search(ctx context.Context, query string, count int) ([]SearchResult, error)
+}
+
+// SearchResult is a single search hit
+type SearchResult struct {
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// SearchProviderConfig describes one configured search provider
+type SearchProviderConfig struct {
+	Type    string        // brave, searxng, tavily, bing, duckduckgo or local
+	Name    string        // Display name; defaults to the type
+	BaseURL string        // Endpoint override; required for searxng
+	APIKey  string        // Key for brave, tavily and bing; defaults to their usual environment variable
+	Paths   []string      // Directories searched by the local provider
+	Timeout time.Duration // Time limit per request; defaults to DefaultSearchTimeout
+}
+
+// searchKeyEnv names the environment variable each keyed provider falls back to
+var searchKeyEnv = map[string]string{
+	"brave":  "BRAVE_API_KEY",
+	"tavily": "TAVILY_API_KEY",
+	"bing":   "BING_API_KEY",
+}
+
+// NewSearchProvider builds a provider from its configuration
+func NewSearchProvider(cfg SearchProviderConfig) (SearchProvider, error) {
+	if cfg.Name == "" {
+		cfg.Name = cfg.Type
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultSearchTimeout
+	}
+	if env, keyed := searchKeyEnv[cfg.Type]; keyed && cfg.APIKey == "" {
+		if cfg.Type == "brave" {
+			cfg.APIKey = loadBraveAPIKey()
+		} else {
+			cfg.APIKey = strings.TrimSpace(os.Getenv(env))
+		}
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("search provider %s requires an API key (set %s or apiKeyEnv)", cfg.Name, env)
+		}
+	}
+
+	base := searchBase{name: cfg.Name, endpoint: cfg.BaseURL, apiKey: cfg.APIKey, timeout: cfg.Timeout}
+	switch cfg.Type {
+	case "brave":
+		return &braveProvider{base.withEndpoint(braveEndpoint)}, nil
+	case "searxng":
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("search provider %s requires a baseURL", cfg.Name)
+		}
+		return &searxngProvider{base}, nil
+	case "tavily":
+		return &tavilyProvider{base.withEndpoint(tavilyEndpoint)}, nil
+	case "bing":
+		return &bingProvider{base.withEndpoint(bingEndpoint)}, nil
+	case "duckduckgo":
+		return &duckDuckGoProvider{base.withEndpoint(duckDuckGoEndpoint)}, nil
+	case "local":
+		if len(cfg.Paths) == 0 {
+			return nil, fmt.Errorf("search provider %s requires at least one path", cfg.Name)
+		}
+		return &localProvider{name: cfg.Name, paths: cfg.Paths}, nil
+	default:
+		return nil, fmt.Errorf("unknown search provider type %q", cfg.Type)
+	}
+}
+
+// DefaultSearchProviders returns Brave when an API key is available,
+// followed by DuckDuckGo
+func DefaultSearchProviders() []SearchProvider {
+	var providers []SearchProvider
+	if brave, err := NewSearchProvider(SearchProviderConfig{Type: "brave", Name: "Brave"}); err == nil {
+		providers = append(providers, brave)
+	}
+	ddg, _ := NewSearchProvider(SearchProviderConfig{Type: "duckduckgo", Name: "DuckDuckGo"})
+	return append(providers, ddg)
+}
+
+var (
+	searchMu        sync.RWMutex
+	searchProviders []SearchProvider
+)
+
+// SetSearchProviders replaces the providers web_search tries, in order.
+// An empty list restores DefaultSearchProviders.
+func SetSearchProviders(providers []SearchProvider) {
+	searchMu.Lock()
+	defer searchMu.Unlock()
+	searchProviders = providers
+}
+
+// currentSearchProviders returns the configured providers or the defaults
+func currentSearchProviders() []SearchProvider {
+	searchMu.RLock()
+	providers := searchProviders
+	searchMu.RUnlock()
+
+	if len(providers) == 0 {
+		return DefaultSearchProviders()
+	}
+	return providers
+}
+
+// SearchStatus classifies the outcome of a web search
+type SearchStatus string
+
+const (
+	// SearchHits means a provider returned results
+	SearchHits SearchStatus = "results"
+	// SearchEmpty means every provider answered but none found anything
+	SearchEmpty SearchStatus = "no_results"
+	// SearchFailed means no provider could answer
+	SearchFailed SearchStatus = "error"
+)
+
+// SearchOutcome is the result of a web search across the providers
+type SearchOutcome struct {
+	Query    string
+	Status   SearchStatus
+	Provider string          // Provider whose results are reported
+	Results  []SearchResult  // Only real hits; never filled with placeholders
+	Attempts []SearchAttempt // Every provider tried, in order
+	Offline  bool            // Only the search cache was consulted
+}
+
+// SearchAttempt records how one provider answered
+type SearchAttempt struct {
+	Provider string
+	Results  int
+	Err      error
+	Hint     string // Explains an empty answer, e.g. a provider with limited coverage
+	Cached   bool   // The answer came from the search cache
+	Duration time.Duration
+}
+
+// emptyResultHinter is implemented by providers that can explain why they
+// found nothing
+type emptyResultHinter interface {
+	EmptyResultHint() string
+}
+
+// performWebSearch tries each provider in order until one returns results,
+// recording every attempt so the model can tell an empty answer from a
+// failure. Answers are read from and stored in the search cache; in offline
+// mode only the cache is consulted.
+func performWebSearch(ctx context.Context, query string, count int) SearchOutcome {
+	outcome := SearchOutcome{Query: query, Status: SearchEmpty}
+	failures := 0
+	cache, offline := CurrentSearchCache(), Offline()
+	outcome.Offline = offline
+
+	for _, provider := range currentSearchProviders() {
+		started := time.Now()
+		attempt := SearchAttempt{Provider: provider.Name()}
+		var results []SearchResult
+		var err error
+		if cached, ok := cache.lookup(provider.Name(), query, count, offline); ok {
+			results, attempt.Cached = cached, true
+		} else if offline {
+			err = errOfflineMiss
+		} else {
+			results, err = provider.Search(ctx, query, count)
+			results = sourcedResults(results)
+			if err == nil && cache != nil {
+				// A failed write only means the next identical search goes out again
+				cache.Put(provider.Name(), query, count, results)
+			}
+		}
+		attempt.Results, attempt.Err, attempt.Duration = len(results), err, time.Since(started)
+		if err == nil && len(results) == 0 {
+			if hinter, ok := provider.(emptyResultHinter); ok {
+				attempt.Hint = hinter.EmptyResultHint()
+			}
+		}
+		outcome.Attempts = append(outcome.Attempts, attempt)
+
+		if err != nil {
+			failures++
+			continue
+		}
+		if len(results) > 0 {
+			if len(results) > count {
+				results = results[:count]
+			}
+			outcome.Status = SearchHits
+			outcome.Provider = provider.Name()
+			outcome.Results = results
+			return outcome
+		}
+	}
+
+	if failures > 0 && failures == len(outcome.Attempts) {
+		outcome.Status = SearchFailed
+	}
+	return outcome
+}
+
+// servedFromCache reports whether performWebSearch can answer without
+// contacting any provider, so the search need not count against the rate
+// limit
+func servedFromCache(query string, count int) bool {
+	if Offline() {
+		return true
+	}
+	cache := CurrentSearchCache()
+	if cache == nil {
+		return false
+	}
+	for _, provider := range currentSearchProviders() {
+		results, ok := cache.fresh(provider.Name(), query, count)
+		if !ok {
+			return false
+		}
+		if results > 0 {
+			return true
+		}
+	}
+	return true
+}
+
+// sourcedResults drops entries without a URL, which cannot be cited
+func sourcedResults(results []SearchResult) []SearchResult {
+	sourced := results[:0]
+	for _, r := range results {
+		if strings.TrimSpace(r.URL) != "" {
+			sourced = append(sourced, r)
+		}
+	}
+	return sourced
+}
@@ -0,0 +1,300 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fixtureServer serves a recorded provider response and hands every
+// request to check
+func fixtureServer(t *testing.T, fixture string, check func(*http.Request)) *httptest.Server {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "search", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		check(r)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSearchProviders(t *testing.T) {
+	tests := []struct {
+		config  SearchProviderConfig
+		fixture string
+		check   func(t *testing.T, r *http.Request)
+		results int
+		first   SearchResult
+	}{
+		{
+			config:  SearchProviderConfig{Type: "brave", APIKey: "brave-key"},
+			fixture: "brave.json",
+			check: func(t *testing.T, r *http.Request) {
+				if r.Header.Get("X-Subscription-Token") != "brave-key" || r.URL.Query().Get("count") != "5" {
+					t.Errorf("Unexpected Brave request %s %v", r.URL, r.Header)
+				}
+			},
+			results: 2,
+			first: SearchResult{
+				Title:       "Tutorial: Getting started with generics - The Go Programming Language",
+				URL:         "https://go.dev/doc/tutorial/generics",
+				Description: "This tutorial introduces the basics of <strong>generics</strong> in Go.",
+			},
+		},
+		{
+			config:  SearchProviderConfig{Type: "searxng"},
+			fixture: "searxng.json",
+			check: func(t *testing.T, r *http.Request) {
+				if r.URL.Path != "/search" || r.URL.Query().Get("format") != "json" || r.URL.Query().Get("q") != "golang generics" {
+					t.Errorf("Unexpected SearXNG request %s", r.URL)
+				}
+			},
+			results: 3,
+			first: SearchResult{
+				Title:       "Tutorial: Getting started with generics",
+				URL:         "https://go.dev/doc/tutorial/generics",
+				Description: "This tutorial introduces the basics of generics in Go.",
+			},
+		},
+		{
+			config:  SearchProviderConfig{Type: "tavily", APIKey: "tvly-key"},
+			fixture: "tavily.json",
+			check: func(t *testing.T, r *http.Request) {
+				var body map[string]interface{}
+				data, _ := io.ReadAll(r.Body)
+				json.Unmarshal(data, &body)
+				if r.Method != "POST" || r.Header.Get("Authorization") != "Bearer tvly-key" ||
+					body["query"] != "golang generics" || body["max_results"] != float64(5) {
+					t.Errorf("Unexpected Tavily request %s %v %s", r.Method, r.Header, data)
+				}
+			},
+			results: 2,
+			first: SearchResult{
+				Title:       "Tutorial: Getting started with generics - The Go Programming Language",
+				URL:         "https://go.dev/doc/tutorial/generics",
+				Description: "This tutorial introduces the basics of generics in Go. With generics, you can declare and use functions or types that are written to work with any of a set of types provided by calling code.",
+			},
+		},
+		{
+			config:  SearchProviderConfig{Type: "bing", APIKey: "bing-key"},
+			fixture: "bing.json",
+			check: func(t *testing.T, r *http.Request) {
+				if r.Header.Get("Ocp-Apim-Subscription-Key") != "bing-key" {
+					t.Errorf("Unexpected Bing headers %v", r.Header)
+				}
+			},
+			results: 1,
+			first: SearchResult{
+				Title:       "Tutorial: Getting started with generics - The Go Programming Language",
+				URL:         "https://go.dev/doc/tutorial/generics",
+				Description: "This tutorial introduces the basics of generics in Go.",
+			},
+		},
+		{
+			config:  SearchProviderConfig{Type: "duckduckgo"},
+			fixture: "duckduckgo.json",
+			check: func(t *testing.T, r *http.Request) {
+				if r.URL.Query().Get("format") != "json" {
+					t.Errorf("Unexpected DuckDuckGo request %s", r.URL)
+				}
+			},
+			results: 2,
+			first: SearchResult{
+				Title:       "Go (programming language)",
+				URL:         "https://en.wikipedia.org/wiki/Go_(programming_language)",
+				Description: "Go is a statically typed, compiled high-level programming language designed at Google.",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.config.Type, func(t *testing.T) {
+			server := fixtureServer(t, tt.fixture, func(r *http.Request) { tt.check(t, r) })
+			tt.config.BaseURL = server.URL
+
+			provider, err := NewSearchProvider(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if provider.Name() != tt.config.Type {
+				t.Errorf("Expected the name to default to the type, got %q", provider.Name())
+			}
+
+			results, err := provider.Search(context.Background(), "golang generics", 5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != tt.results {
+				t.Fatalf("Expected %d results, got %+v", tt.results, results)
+			}
+			if results[0] != tt.first {
+				t.Errorf("Unexpected first result %+v", results[0])
+			}
+		})
+	}
+}
+
+func TestLocalSearchProvider(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"deploy.md":       "# Deploying services\n\nRun the deploy pipeline, then check the rollout dashboard.\n",
+		"onboarding.md":   "# Onboarding\n\nAsk for access to the deploy tooling and read the deploy guide.\n",
+		"notes/faq.html":  "<html><head><title>FAQ</title></head><body>Rollout questions</body></html>",
+		"image.png":       "deploy rollout",
+		".hidden/skip.md": "deploy rollout",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	provider, err := NewSearchProvider(SearchProviderConfig{Type: "local", Name: "docs", Paths: []string{dir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := provider.Search(context.Background(), "deploy rollout", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var titles []string
+	for _, r := range results {
+		titles = append(titles, r.Title)
+	}
+	if got := strings.Join(titles, ", "); got != "Deploying services, Onboarding, FAQ" {
+		t.Errorf("Unexpected ranking: %s", got)
+	}
+	if !strings.HasPrefix(results[0].URL, "file://") || !strings.Contains(results[0].Description, "deploy pipeline") {
+		t.Errorf("Unexpected result %+v", results[0])
+	}
+}
+
+func TestNewSearchProviderErrors(t *testing.T) {
+	t.Setenv("TAVILY_API_KEY", "")
+	tests := []struct {
+		config  SearchProviderConfig
+		errPart string
+	}{
+		{SearchProviderConfig{Type: "tavily"}, "requires an API key (set TAVILY_API_KEY"},
+		{SearchProviderConfig{Type: "searxng"}, "requires a baseURL"},
+		{SearchProviderConfig{Type: "local"}, "requires at least one path"},
+		{SearchProviderConfig{Type: "altavista"}, "unknown search provider type"},
+	}
+	for _, tt := range tests {
+		if _, err := NewSearchProvider(tt.config); err == nil || !strings.Contains(err.Error(), tt.errPart) {
+			t.Errorf("%s: expected error containing %q, got %v", tt.config.Type, tt.errPart, err)
+		}
+	}
+
+	t.Setenv("TAVILY_API_KEY", "from-env")
+	if _, err := NewSearchProvider(SearchProviderConfig{Type: "tavily"}); err != nil {
+		t.Errorf("Expected the key to be read from TAVILY_API_KEY: %v", err)
+	}
+}
+
+func TestWebSearchOutcomes(t *testing.T) {
+	t.Cleanup(func() { SetSearchProviders(nil) })
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"quota exceeded"}`, http.StatusTooManyRequests)
+	}))
+	defer failing.Close()
+	searxng := fixtureServer(t, "searxng.json", func(*http.Request) {})
+	empty := fixtureServer(t, "duckduckgo_empty.json", func(*http.Request) {})
+
+	brave, _ := NewSearchProvider(SearchProviderConfig{Type: "brave", Name: "Brave", APIKey: "k", BaseURL: failing.URL})
+	team, _ := NewSearchProvider(SearchProviderConfig{Type: "searxng", Name: "Team SearXNG", BaseURL: searxng.URL})
+	ddg, _ := NewSearchProvider(SearchProviderConfig{Type: "duckduckgo", Name: "DuckDuckGo", BaseURL: empty.URL})
+
+	tests := []struct {
+		name      string
+		providers []SearchProvider
+		status    SearchStatus
+		contains  []string
+		excludes  []string
+	}{
+		{
+			name:      "falls back to the next provider",
+			providers: []SearchProvider{brave, team},
+			status:    SearchHits,
+			contains: []string{
+				"Web search results for: golang generics (via Team SearXNG)",
+				"1. Tutorial: Getting started with generics\n   URL: https://go.dev/doc/tutorial/generics",
+				"- Brave: error after ",
+				"request failed with status 429 Too Many Requests: {\"error\":\"quota exceeded\"}",
+				"- Team SearXNG: 3 results in ",
+			},
+			excludes: []string{"Go by Example"},
+		},
+		{
+			name:      "reports an empty answer without inventing results",
+			providers: []SearchProvider{ddg},
+			status:    SearchEmpty,
+			contains: []string{
+				"No web search results for: golang generics",
+				"Status: no_results",
+				"- DuckDuckGo: no results in ",
+				"only returns instant answers",
+				"call web_search again with a rephrased query",
+			},
+			excludes: []string{"URL:", "google.com", "Search for"},
+		},
+		{
+			name:      "distinguishes failures from empty answers",
+			providers: []SearchProvider{brave, ddg},
+			status:    SearchEmpty,
+			contains:  []string{"Status: no_results", "- Brave: error", "- DuckDuckGo: no results"},
+		},
+		{
+			name:      "reports when every provider fails",
+			providers: []SearchProvider{brave},
+			status:    SearchFailed,
+			contains: []string{
+				"Web search failed for: golang generics",
+				"Status: error",
+				"retrying the same query is unlikely to help",
+			},
+			excludes: []string{"URL:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetSearchProviders(tt.providers)
+
+			outcome := performWebSearch(context.Background(), "golang generics", 2)
+			if outcome.Status != tt.status || len(outcome.Attempts) != len(tt.providers) {
+				t.Fatalf("Expected status %s after %d attempts, got %+v", tt.status, len(tt.providers), outcome)
+			}
+
+			result, err := callTool(context.Background(), "web_search", map[string]interface{}{"query": "golang generics", "count": float64(2)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, part := range tt.contains {
+				if !strings.Contains(result, part) {
+					t.Errorf("Expected %q in:\n%s", part, result)
+				}
+			}
+			for _, part := range tt.excludes {
+				if strings.Contains(result, part) {
+					t.Errorf("Did not expect %q in:\n%s", part, result)
+				}
+			}
+		})
+	}
+}
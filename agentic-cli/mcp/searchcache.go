@@ -0,0 +1,229 @@
+package mcp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSearchCacheTTL is how long cached search results are served
+const DefaultSearchCacheTTL = 24 * time.Hour
+
+// errOfflineMiss is recorded for providers skipped in offline mode
+var errOfflineMiss = errors.New("offline: no cached result")
+
+// SearchCache stores provider answers on disk, one file per provider, query
+// and result count. Empty answers are cached too; errors are not.
+type SearchCache struct {
+	dir string
+	ttl time.Duration
+
+	mu     sync.Mutex
+	hits   int
+	misses int
+}
+
+// cachedSearch is the file format of a cache entry
+type cachedSearch struct {
+	Provider string         `json:"provider"`
+	Query    string         `json:"query"`
+	Count    int            `json:"count"`
+	Results  []SearchResult `json:"results"`
+	StoredAt time.Time      `json:"stored_at"`
+}
+
+// SearchCacheStats summarizes the cache contents and this session's lookups
+type SearchCacheStats struct {
+	Dir     string
+	TTL     time.Duration
+	Entries int
+	Expired int // Entries older than the TTL, still served in offline mode
+	Bytes   int64
+	Hits    int
+	Misses  int
+}
+
+// NewSearchCache returns a cache stored in dir. A ttl of zero or less uses
+// DefaultSearchCacheTTL.
+func NewSearchCache(dir string, ttl time.Duration) *SearchCache {
+	if ttl <= 0 {
+		ttl = DefaultSearchCacheTTL
+	}
+	return &SearchCache{dir: dir, ttl: ttl}
+}
+
+// TTL returns how long entries are served outside offline mode
+func (c *SearchCache) TTL() time.Duration {
+	return c.ttl
+}
+
+// path returns the file holding the entry for provider, query and count.
+// Queries differing only in case or spacing share an entry.
+func (c *SearchCache) path(provider, query string, count int) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d", provider, normalized, count)))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get returns the cached results for provider, query and count. Expired
+// entries are only returned when allowStale is set.
+func (c *SearchCache) Get(provider, query string, count int, allowStale bool) ([]SearchResult, bool) {
+	entry, err := c.read(c.path(provider, query, count))
+	found := err == nil && (allowStale || time.Since(entry.StoredAt) < c.ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !found {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	return entry.Results, true
+}
+
+// fresh reports whether an unexpired entry exists, without counting a lookup
+func (c *SearchCache) fresh(provider, query string, count int) (results int, ok bool) {
+	entry, err := c.read(c.path(provider, query, count))
+	if err != nil || time.Since(entry.StoredAt) >= c.ttl {
+		return 0, false
+	}
+	return len(entry.Results), true
+}
+
+// lookup is Get on a cache that may be nil
+func (c *SearchCache) lookup(provider, query string, count int, allowStale bool) ([]SearchResult, bool) {
+	if c == nil {
+		return nil, false
+	}
+	return c.Get(provider, query, count, allowStale)
+}
+
+// Put stores the results a provider returned
+func (c *SearchCache) Put(provider, query string, count int, results []SearchResult) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("failed to create search cache: %w", err)
+	}
+	data, err := json.Marshal(cachedSearch{
+		Provider: provider,
+		Query:    query,
+		Count:    count,
+		Results:  results,
+		StoredAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Write through a temporary file so readers never see a partial entry
+	path := c.path(provider, query, count)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write search cache: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Stats reports the number and size of cached entries and the hits and
+// misses since the cache was created
+func (c *SearchCache) Stats() (SearchCacheStats, error) {
+	c.mu.Lock()
+	stats := SearchCacheStats{Dir: c.dir, TTL: c.ttl, Hits: c.hits, Misses: c.misses}
+	c.mu.Unlock()
+
+	files, err := c.files()
+	if err != nil {
+		return stats, err
+	}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		stats.Entries++
+		stats.Bytes += info.Size()
+		if entry, err := c.read(file); err != nil || time.Since(entry.StoredAt) >= c.ttl {
+			stats.Expired++
+		}
+	}
+	return stats, nil
+}
+
+// Clear deletes every cached entry and returns how many were removed
+func (c *SearchCache) Clear() (int, error) {
+	files, err := c.files()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to clear search cache: %w", err)
+		}
+		removed++
+	}
+
+	c.mu.Lock()
+	c.hits, c.misses = 0, 0
+	c.mu.Unlock()
+	return removed, nil
+}
+
+func (c *SearchCache) files() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read search cache: %w", err)
+	}
+	return files, nil
+}
+
+func (c *SearchCache) read(path string) (cachedSearch, error) {
+	var entry cachedSearch
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return entry, err
+	}
+	err = json.Unmarshal(data, &entry)
+	return entry, err
+}
+
+var (
+	cacheMu     sync.RWMutex
+	searchCache *SearchCache
+	offline     bool
+)
+
+// SetSearchCache sets the cache web_search reads and fills; nil disables it
+func SetSearchCache(cache *SearchCache) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	searchCache = cache
+}
+
+// CurrentSearchCache returns the cache set with SetSearchCache, or nil
+func CurrentSearchCache() *SearchCache {
+	cacheMu.RLock()
+	defer cacheMu.RUnlock()
+	return searchCache
+}
+
+// SetOffline makes web_search answer only from the cache, even with expired
+// entries, and fetch_url serve only pages fetched earlier in the session
+func SetOffline(enabled bool) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	offline = enabled
+}
+
+// Offline reports whether offline mode is enabled
+func Offline() bool {
+	cacheMu.RLock()
+	defer cacheMu.RUnlock()
+	return offline
+}
@@ -0,0 +1,97 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearchCache(t *testing.T) {
+	t.Cleanup(func() {
+		SetSearchProviders(nil)
+		SetSearchCache(nil)
+		SetOffline(false)
+	})
+
+	requests := 0
+	server := fixtureServer(t, "searxng.json", func(*http.Request) { requests++ })
+	provider, _ := NewSearchProvider(SearchProviderConfig{Type: "searxng", Name: "Team SearXNG", BaseURL: server.URL})
+	SetSearchProviders([]SearchProvider{provider})
+
+	dir := t.TempDir()
+	cache := NewSearchCache(dir, time.Hour)
+	SetSearchCache(cache)
+
+	search := func(query string) string {
+		t.Helper()
+		result, err := callTool(context.Background(), "web_search", map[string]interface{}{"query": query, "count": float64(2)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	if result := search("golang generics"); !strings.Contains(result, "- Team SearXNG: 3 results in ") {
+		t.Errorf("Expected a live answer, got:\n%s", result)
+	}
+	result := search("  Golang   GENERICS ")
+	if !strings.Contains(result, "- Team SearXNG: 3 results (cached)") || !strings.Contains(result, "https://go.dev/doc/tutorial/generics") {
+		t.Errorf("Expected the normalized query to be served from the cache, got:\n%s", result)
+	}
+	if requests != 1 {
+		t.Errorf("Expected 1 provider request, got %d", requests)
+	}
+
+	// A different count is a different entry
+	search("golang generics")
+	callTool(context.Background(), "web_search", map[string]interface{}{"query": "golang generics", "count": float64(3)})
+	if requests != 2 {
+		t.Errorf("Expected a new request for another count, got %d requests", requests)
+	}
+
+	// Offline mode serves expired entries and never contacts a provider
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, file := range files {
+		entry, _ := cache.read(file)
+		entry.StoredAt = time.Now().Add(-2 * time.Hour)
+		data, _ := json.Marshal(entry)
+		os.WriteFile(file, data, 0600)
+	}
+	SetOffline(true)
+	if result := search("golang generics"); !strings.Contains(result, "3 results (cached)") {
+		t.Errorf("Expected an expired entry to be served offline, got:\n%s", result)
+	}
+	result = search("rust lifetimes")
+	if !strings.Contains(result, "Status: error") || !strings.Contains(result, "offline: no cached result") ||
+		!strings.Contains(result, "Offline mode") {
+		t.Errorf("Expected an offline miss, got:\n%s", result)
+	}
+	if _, err := callTool(context.Background(), "fetch_url", map[string]interface{}{"url": server.URL + "/page"}); err == nil ||
+		!strings.Contains(err.Error(), "offline mode") {
+		t.Errorf("Expected fetch_url to refuse uncached pages offline, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected no provider requests offline, got %d", requests)
+	}
+
+	stats, err := cache.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Entries != 2 || stats.Expired != 2 || stats.Hits != 3 || stats.Misses != 3 || stats.Bytes == 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	removed, err := cache.Clear()
+	if err != nil || removed != 2 {
+		t.Fatalf("Expected 2 entries cleared, got %d (%v)", removed, err)
+	}
+	if stats, _ := cache.Stats(); stats.Entries != 0 || stats.Hits != 0 {
+		t.Errorf("Expected an empty cache after Clear, got %+v", stats)
+	}
+}
@@ -0,0 +1,436 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	braveEndpoint      = "https://api.search.brave.com/res/v1/web/search"
+	tavilyEndpoint     = "https://api.tavily.com/search"
+	bingEndpoint       = "https://api.bing.microsoft.com/v7.0/search"
+	duckDuckGoEndpoint = "https://api.duckduckgo.com/"
+)
+
+// searchBase holds the settings shared by the HTTP providers
+type searchBase struct {
+	name     string
+	endpoint string
+	apiKey   string
+	timeout  time.Duration
+}
+
+func (b searchBase) Name() string { return b.name }
+
+// withEndpoint fills in the provider's public endpoint unless overridden
+func (b searchBase) withEndpoint(endpoint string) searchBase {
+	if b.endpoint == "" {
+		b.endpoint = endpoint
+	}
+	return b
+}
+
+// do sends req and decodes a JSON response into v
+func (b searchBase) do(req *http.Request, v interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "goss-cli/1.0")
+
+	client := &http.Client{Timeout: b.timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &searchStatusError{code: resp.StatusCode, status: resp.Status, body: strings.TrimSpace(string(body))}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// searchStatusError reports a non-200 response from a provider
+type searchStatusError struct {
+	code   int
+	status string
+	body   string // Start of the response body, often an error message
+}
+
+func (e *searchStatusError) Error() string {
+	if e.body != "" {
+		return fmt.Sprintf("request failed with status %s: %s", e.status, e.body)
+	}
+	return fmt.Sprintf("request failed with status %s", e.status)
+}
+
+// get requests endpoint with the given query parameters
+func (b searchBase) get(ctx context.Context, params url.Values, headers map[string]string, v interface{}) error {
+	endpoint, err := url.Parse(b.endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint: %w", err)
+	}
+	query := endpoint.Query()
+	for k, values := range params {
+		query[k] = values
+	}
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint.String(), nil)
+	if err != nil {
+		return err
+	}
+	for k, value := range headers {
+		req.Header.Set(k, value)
+	}
+	return b.do(req, v)
+}
+
+// braveProvider uses the Brave Search API
+type braveProvider struct{ searchBase }
+
+// BraveSearchResponse represents the Brave Search API response
+type BraveSearchResponse struct {
+	Web struct {
+		Type    string `json:"type"`
+		Results []struct {
+			Title       string `json:"title"`
+			URL         string `json:"url"`
+			Description string `json:"description"`
+			Age         string `json:"age,omitempty"`
+		} `json:"results"`
+	} `json:"web"`
+	Query struct {
+		Original string      `json:"original"`
+		Show     interface{} `json:"show_strict_warning"`
+		Altered  string      `json:"altered,omitempty"`
+	} `json:"query"`
+}
+
+func (p *braveProvider) Search(ctx context.Context, query string, count int) ([]SearchResult, error) {
+	var resp BraveSearchResponse
+	params := url.Values{"q": {query}, "count": {fmt.Sprint(count)}}
+	if err := p.get(ctx, params, map[string]string{"X-Subscription-Token": p.apiKey}, &resp); err != nil {
+		return nil, err
+	}
+
+	var results []SearchResult
+	for _, r := range resp.Web.Results {
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Description: r.Description})
+	}
+	return results, nil
+}
+
+// searxngProvider queries a SearXNG instance, typically self-hosted. The
+// instance must have the json format enabled in its settings.
+type searxngProvider struct{ searchBase }
+
+type searxngResponse struct {
+	Results []struct {
+		Title   string `json:"title"`
+		URL     string `json:"url"`
+		Content string `json:"content"`
+	} `json:"results"`
+}
+
+func (p *searxngProvider) Search(ctx context.Context, query string, count int) ([]SearchResult, error) {
+	endpoint := strings.TrimSuffix(p.endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/search") {
+		endpoint += "/search"
+	}
+	instance := p.searchBase
+	instance.endpoint = endpoint
+
+	var resp searxngResponse
+	params := url.Values{"q": {query}, "format": {"json"}}
+	if err := instance.get(ctx, params, nil, &resp); err != nil {
+		var status *searchStatusError
+		if errors.As(err, &status) && status.code == http.StatusForbidden {
+			return nil, fmt.Errorf("%w (is the json format enabled in the instance's settings.yml?)", err)
+		}
+		return nil, err
+	}
+
+	var results []SearchResult
+	for _, r := range resp.Results {
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Description: r.Content})
+	}
+	return results, nil
+}
+
+// tavilyProvider uses the Tavily search API
+type tavilyProvider struct{ searchBase }
+
+type tavilyResponse struct {
+	Results []struct {
+		Title   string `json:"title"`
+		URL     string `json:"url"`
+		Content string `json:"content"`
+	} `json:"results"`
+}
+
+func (p *tavilyProvider) Search(ctx context.Context, query string, count int) ([]SearchResult, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":        query,
+		"max_results":  count,
+		"search_depth": "basic",
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	var resp tavilyResponse
+	if err := p.do(req, &resp); err != nil {
+		return nil, err
+	}
+
+	var results []SearchResult
+	for _, r := range resp.Results {
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Description: r.Content})
+	}
+	return results, nil
+}
+
+// bingProvider uses the Bing Web Search API
+type bingProvider struct{ searchBase }
+
+type bingResponse struct {
+	WebPages struct {
+		Value []struct {
+			Name    string `json:"name"`
+			URL     string `json:"url"`
+			Snippet string `json:"snippet"`
+		} `json:"value"`
+	} `json:"webPages"`
+}
+
+func (p *bingProvider) Search(ctx context.Context, query string, count int) ([]SearchResult, error) {
+	var resp bingResponse
+	params := url.Values{"q": {query}, "count": {fmt.Sprint(count)}, "responseFilter": {"Webpages"}}
+	if err := p.get(ctx, params, map[string]string{"Ocp-Apim-Subscription-Key": p.apiKey}, &resp); err != nil {
+		return nil, err
+	}
+
+	var results []SearchResult
+	for _, r := range resp.WebPages.Value {
+		results = append(results, SearchResult{Title: r.Name, URL: r.URL, Description: r.Snippet})
+	}
+	return results, nil
+}
+
+// duckDuckGoProvider uses the keyless DuckDuckGo instant answer API
+type duckDuckGoProvider struct{ searchBase }
+
+type DuckDuckGoResponse struct {
+	AbstractText  string         `json:"AbstractText"`
+	AbstractURL   string         `json:"AbstractURL"`
+	Heading       string         `json:"Heading"`
+	RelatedTopics []RelatedTopic `json:"RelatedTopics"`
+}
+
+type RelatedTopic struct {
+	FirstURL string `json:"FirstURL"`
+	Text     string `json:"Text"`
+}
+
+func (p *duckDuckGoProvider) Search(ctx context.Context, query string, count int) ([]SearchResult, error) {
+	var ddgResponse DuckDuckGoResponse
+	params := url.Values{"q": {query}, "format": {"json"}, "no_html": {"1"}, "skip_disambig": {"1"}}
+	if err := p.get(ctx, params, nil, &ddgResponse); err != nil {
+		return nil, err
+	}
+
+	var results []SearchResult
+
+	// Add instant answer if available
+	if ddgResponse.AbstractText != "" {
+		results = append(results, SearchResult{
+			Title:       ddgResponse.Heading,
+			URL:         ddgResponse.AbstractURL,
+			Description: ddgResponse.AbstractText,
+		})
+	}
+
+	// Add related topics
+	for i, topic := range ddgResponse.RelatedTopics {
+		if i >= count-1 { // Save space for instant answer
+			break
+		}
+		if topic.Text != "" {
+			results = append(results, SearchResult{
+				Title:       extractTitle(topic.Text),
+				URL:         topic.FirstURL,
+				Description: topic.Text,
+			})
+		}
+	}
+
+	return results, nil
+}
+
+func (p *duckDuckGoProvider) EmptyResultHint() string {
+	return "DuckDuckGo only returns instant answers (encyclopedia-style topics), not general web results"
+}
+
+// localProvider searches text documents in local directories, such as a
+// checkout of internal documentation
+type localProvider struct {
+	name  string
+	paths []string
+}
+
+// localSearchExtensions are the document types the local provider reads
+var localSearchExtensions = map[string]bool{
+	".md": true, ".markdown": true, ".txt": true, ".rst": true, ".adoc": true, ".html": true, ".htm": true,
+}
+
+func (p *localProvider) Name() string { return p.name }
+
+func (p *localProvider) Search(ctx context.Context, query string, count int) ([]SearchResult, error) {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	type hit struct {
+		result  SearchResult
+		matched int // Distinct query terms found
+		total   int // Occurrences of all terms
+	}
+	var hits []hit
+
+	for _, root := range p.paths {
+		if _, err := os.Stat(root); err != nil {
+			return nil, fmt.Errorf("cannot read %s: %w", root, err)
+		}
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // Skip unreadable entries
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if d.IsDir() {
+				if path != root && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !localSearchExtensions[strings.ToLower(filepath.Ext(path))] {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil || info.Size() > currentPolicy().MaxFileSize {
+				return nil
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil
+			}
+			text := string(content)
+			lower := strings.ToLower(text)
+
+			h := hit{}
+			first := -1
+			for _, term := range terms {
+				n := strings.Count(lower, term)
+				if n == 0 {
+					continue
+				}
+				h.matched++
+				h.total += n
+				if i := strings.Index(lower, term); first < 0 || i < first {
+					first = i
+				}
+			}
+			if h.matched == 0 {
+				return nil
+			}
+
+			abs, _ := filepath.Abs(path)
+			h.result = SearchResult{
+				Title:       documentTitle(text, filepath.Base(path)),
+				URL:         (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String(),
+				Description: snippet(text, first),
+			}
+			hits = append(hits, h)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].matched != hits[j].matched {
+			return hits[i].matched > hits[j].matched
+		}
+		return hits[i].total > hits[j].total
+	})
+
+	var results []SearchResult
+	for i := 0; i < len(hits) && i < count; i++ {
+		results = append(results, hits[i].result)
+	}
+	return results, nil
+}
+
+// documentTitle returns the first Markdown heading or HTML title of text
+func documentTitle(text, fallback string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "# ") {
+			return strings.TrimSpace(line[2:])
+		}
+		if i := strings.Index(strings.ToLower(line), "<title>"); i >= 0 {
+			title := line[i+len("<title>"):]
+			if end := strings.Index(strings.ToLower(title), "</title>"); end >= 0 {
+				return strings.TrimSpace(title[:end])
+			}
+		}
+	}
+	return fallback
+}
+
+// snippet returns about 200 characters of text around offset on one line
+func snippet(text string, offset int) string {
+	start := max(0, offset-80)
+	end := min(len(text), offset+120)
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+
+	s := strings.TrimSpace(collapseSpace(text[start:end]))
+	if start > 0 {
+		s = "…" + s
+	}
+	if end < len(text) {
+		s += "…"
+	}
+	return s
+}
@@ -4,7 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -14,60 +15,167 @@ const (
 	MaxPathLength = 4096
 )
 
-// validatePath checks if a path is safe for file operations
-func validatePath(path string) error {
-	if path == "" {
-		return fmt.Errorf("path cannot be empty")
+// Policy holds the configurable limits applied by the filesystem tools
+type Policy struct {
+	MaxFileSize     int64         // Largest file in bytes that may be read or written
+	AllowedRoots    []string      // Directories the tools may access; empty means the working directory
+	RestrictedPaths []string      // Deny globs, see NewSandbox for their syntax
+	Commands        CommandPolicy // Limits applied by run_command
+	// ConfirmMutations asks for approval before tools modify files
+	ConfirmMutations bool
+	// FetchPrivateNetworks lets fetch_url connect to loopback, link-local
+	// and private addresses
+	FetchPrivateNetworks bool
+	// TrashDir receives files removed by delete_path, which fails when it
+	// is empty rather than lose files
+	TrashDir string
+}
+
+// CommandPolicy controls which commands run_command may execute
+type CommandPolicy struct {
+	Allow     []string      // Command prefixes that run without approval, e.g. "go test", and "plugin:<name>" entries
+	Deny      []string      // Commands that are never run, even with approval
+	Timeout   time.Duration // Default time limit for a command
+	MaxOutput int           // Bytes kept from each of stdout and stderr
+}
+
+// DefaultPolicy returns the built-in filesystem policy
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxFileSize: MaxFileSize,
+		RestrictedPaths: []string{
+			"/etc/passwd",
+			"/etc/shadow",
+			"/proc",
+			"/sys",
+			".ssh",
+			".git",
+			"node_modules",
+			"*.pem",
+			"id_rsa*",
+			"id_ed25519*",
+		},
+		Commands: CommandPolicy{
+			Allow: []string{
+				"go build", "go test", "go vet", "go list", "go version",
+				"git status", "git diff", "git log", "git show",
+				"ls", "pwd", "grep", "rg", "wc",
+			},
+			Deny: []string{
+				"sudo", "su", "doas", "rm -rf /", "mkfs", "dd", "shutdown", "reboot",
+			},
+			Timeout:   DefaultCommandTimeout,
+			MaxOutput: DefaultCommandOutput,
+		},
 	}
+}
 
-	if len(path) > MaxPathLength {
-		return fmt.Errorf("path too long (max %d characters)", MaxPathLength)
+var (
+	policyMu sync.RWMutex
+	policy   = DefaultPolicy()
+)
+
+// SetPolicy replaces the active filesystem policy.
+// Zero-valued fields keep their defaults.
+func SetPolicy(p Policy) {
+	defaults := DefaultPolicy()
+	if p.MaxFileSize <= 0 {
+		p.MaxFileSize = defaults.MaxFileSize
+	}
+	if len(p.RestrictedPaths) == 0 {
+		p.RestrictedPaths = defaults.RestrictedPaths
+	}
+	if p.Commands.Allow == nil {
+		p.Commands.Allow = defaults.Commands.Allow
+	}
+	if p.Commands.Deny == nil {
+		p.Commands.Deny = defaults.Commands.Deny
+	}
+	if p.Commands.Timeout <= 0 {
+		p.Commands.Timeout = defaults.Commands.Timeout
+	}
+	if p.Commands.MaxOutput <= 0 {
+		p.Commands.MaxOutput = defaults.Commands.MaxOutput
 	}
 
-	// Clean the path to resolve any .., ., etc.
-	cleanPath := filepath.Clean(path)
-	
-	// Get absolute path to check for directory traversal
-	absPath, err := filepath.Abs(cleanPath)
+	policyMu.Lock()
+	policy = p
+	policyMu.Unlock()
+
+	// Rebuild the sandbox from the new roots and rules on next use
+	sandboxMu.Lock()
+	sandbox = nil
+	sandboxMu.Unlock()
+}
+
+// currentPolicy returns the active filesystem policy
+func currentPolicy() Policy {
+	policyMu.RLock()
+	defer policyMu.RUnlock()
+	return policy
+}
+
+// validatePath checks if a path is safe for file operations and returns
+// its resolved form, which is what the operation must use
+func validatePath(path string) (string, error) {
+	sandbox, err := currentSandbox()
 	if err != nil {
-		return fmt.Errorf("failed to resolve absolute path: %w", err)
+		return "", err
 	}
+	return sandbox.Resolve(path)
+}
 
-	// Get current working directory
-	wd, err := os.Getwd()
+// validateLinkPath is validatePath for operations that act on a symlink
+// itself: the returned path keeps its last element unresolved
+func validateLinkPath(path string) (string, error) {
+	sandbox, err := currentSandbox()
 	if err != nil {
-		return fmt.Errorf("failed to get working directory: %w", err)
+		return "", err
 	}
+	return sandbox.ResolveLink(path)
+}
 
-	absWd, err := filepath.Abs(wd)
+// displayPath returns path, found below resolvedBase, as it appears below
+// base, the form the caller gave, so results read like the request
+func displayPath(base, resolvedBase, path string) string {
+	rel, err := filepath.Rel(resolvedBase, path)
 	if err != nil {
-		return fmt.Errorf("failed to resolve working directory: %w", err)
+		return path
 	}
+	return filepath.Join(base, rel)
+}
 
-	// Check if the path tries to escape the working directory
-	if !strings.HasPrefix(absPath, absWd) {
-		return fmt.Errorf("path '%s' attempts to access files outside working directory", path)
-	}
+var (
+	sandboxMu  sync.Mutex
+	sandbox    *Sandbox
+	sandboxDir string // Working directory the cached sandbox was built for
+)
 
-	// Prevent access to sensitive files/directories
-	restrictedPaths := []string{
-		"/etc/passwd",
-		"/etc/shadow",
-		"/proc",
-		"/sys",
-		".ssh",
-		".git",
-		"node_modules",
+// currentSandbox returns the sandbox for the active policy. Without
+// configured roots the working directory is the only root.
+func currentSandbox() (*Sandbox, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	lowerPath := strings.ToLower(absPath)
-	for _, restricted := range restrictedPaths {
-		if strings.Contains(lowerPath, strings.ToLower(restricted)) {
-			return fmt.Errorf("access to '%s' is restricted", path)
-		}
+	sandboxMu.Lock()
+	defer sandboxMu.Unlock()
+	if sandbox != nil && sandboxDir == wd {
+		return sandbox, nil
 	}
 
-	return nil
+	p := currentPolicy()
+	roots := p.AllowedRoots
+	if len(roots) == 0 {
+		roots = []string{wd}
+	}
+	s, err := NewSandbox(roots, p.RestrictedPaths)
+	if err != nil {
+		return nil, err
+	}
+	sandbox, sandboxDir = s, wd
+	return s, nil
 }
 
 // validateFileSize checks if a file size is within acceptable limits
@@ -81,54 +189,64 @@ func validateFileSize(path string) error {
 		return fmt.Errorf("failed to get file info: %w", err)
 	}
 
-	if info.Size() > MaxFileSize {
+	maxSize := currentPolicy().MaxFileSize
+	if info.Size() > maxSize {
 		return fmt.Errorf("file size %d bytes exceeds maximum allowed size of %d bytes", 
-			info.Size(), MaxFileSize)
+			info.Size(), maxSize)
 	}
 
 	return nil
 }
 
-// validateWriteOperation checks if a write operation is safe
-func validateWriteOperation(path string, contentSize int) error {
-	if err := validatePath(path); err != nil {
-		return err
+// validateWriteOperation checks if a write operation is safe and returns
+// the resolved path to write
+func validateWriteOperation(path string, contentSize int) (string, error) {
+	resolved, err := validatePath(path)
+	if err != nil {
+		return "", err
 	}
 
-	if contentSize > MaxFileSize {
-		return fmt.Errorf("content size %d bytes exceeds maximum allowed size of %d bytes", 
-			contentSize, MaxFileSize)
+	maxSize := currentPolicy().MaxFileSize
+	if int64(contentSize) > maxSize {
+		return "", fmt.Errorf("content size %d bytes exceeds maximum allowed size of %d bytes", 
+			contentSize, maxSize)
 	}
 
-	// Check if directory is writable
-	dir := filepath.Dir(path)
-	if dir != "." {
-		// Try to create directory if it doesn't exist
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("cannot create directory '%s': %w", dir, err)
+	// Missing directories are created after approval; the closest existing
+	// one must be a directory
+	for dir := filepath.Dir(resolved); ; dir = filepath.Dir(dir) {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return "", fmt.Errorf("cannot create directory under '%s': not a directory", dir)
+			}
+			return resolved, nil
+		}
+		if !os.IsNotExist(err) || filepath.Dir(dir) == dir {
+			return "", fmt.Errorf("cannot access directory '%s': %w", dir, err)
 		}
 	}
-
-	return nil
 }
 
-// validateReadOperation checks if a read operation is safe
-func validateReadOperation(path string) error {
-	if err := validatePath(path); err != nil {
-		return err
+// validateReadOperation checks if a read operation is safe and returns the
+// resolved path to read
+func validateReadOperation(path string) (string, error) {
+	resolved, err := validatePath(path)
+	if err != nil {
+		return "", err
 	}
 
-	if err := validateFileSize(path); err != nil {
-		return err
+	if err := validateFileSize(resolved); err != nil {
+		return "", err
 	}
 
 	// Check if file is readable
-	if _, err := os.Stat(path); err != nil {
+	if _, err := os.Stat(resolved); err != nil {
 		if os.IsNotExist(err) {
-			return fmt.Errorf("file '%s' does not exist", path)
+			return "", fmt.Errorf("file '%s' does not exist", path)
 		}
-		return fmt.Errorf("cannot access file '%s': %w", path, err)
+		return "", fmt.Errorf("cannot access file '%s': %w", path, err)
 	}
 
-	return nil
+	return resolved, nil
 }
\ No newline at end of file
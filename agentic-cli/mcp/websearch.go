@@ -2,60 +2,14 @@ package mcp
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
-	"net/url"
 	"os"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/vivesm/GOSS-CLI/agentic-cli/openai"
 )
 
-// RateLimiter implements a simple token bucket rate limiter for web searches
-type RateLimiter struct {
-	tokens    int
-	maxTokens int
-	refillRate time.Duration
-	lastRefill time.Time
-	mu         sync.Mutex
-}
-
-// NewRateLimiter creates a new rate limiter
-func NewRateLimiter(maxTokens int, refillRate time.Duration) *RateLimiter {
-	return &RateLimiter{
-		tokens:     maxTokens,
-		maxTokens:  maxTokens,
-		refillRate: refillRate,
-		lastRefill: time.Now(),
-	}
-}
-
-// Allow checks if a request can proceed
-func (rl *RateLimiter) Allow() bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	now := time.Now()
-	elapsed := now.Sub(rl.lastRefill)
-	
-	// Refill tokens based on elapsed time
-	if elapsed >= rl.refillRate {
-		tokensToAdd := int(elapsed / rl.refillRate)
-		rl.tokens = min(rl.maxTokens, rl.tokens+tokensToAdd)
-		rl.lastRefill = now
-	}
-
-	if rl.tokens > 0 {
-		rl.tokens--
-		return true
-	}
-
-	return false
-}
-
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -63,238 +17,51 @@ func min(a, b int) int {
 	return b
 }
 
-// Global rate limiter for web searches (10 requests per minute)
-var webSearchRateLimiter = NewRateLimiter(10, time.Minute/10)
-
 // CreateWebSearchTools returns web search MCP tools
 func CreateWebSearchTools() []openai.Tool {
 	return []openai.Tool{
-		{
-			Type: "function",
-			Function: openai.ToolFunction{
-				Name:        "web_search",
-				Description: "Search the web using Brave Search API",
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"query": map[string]interface{}{
-							"type":        "string",
-							"description": "Search query string",
-						},
-						"count": map[string]interface{}{
-							"type":        "integer",
-							"description": "Number of search results to return (default: 5, max: 20)",
-							"minimum":     1,
-							"maximum":     20,
-						},
-					},
-					"required": []string{"query"},
-				},
-				Handler: webSearchHandler,
-			},
-		},
+		openai.NewTool("web_search",
+			"Search the web using the configured search providers (Brave, SearXNG, Tavily, Bing, DuckDuckGo or local documents)",
+			webSearchHandler).AsReadOnly(),
+		openai.NewTool("fetch_url",
+			"Download a web page and return its main content as Markdown. Long pages are returned in chunks; use start_index to continue",
+			fetchURLHandler).AsReadOnly(),
 	}
 }
 
-// BraveSearchResponse represents the Brave Search API response
-type BraveSearchResponse struct {
-	Web struct {
-		Type    string `json:"type"`
-		Results []struct {
-			Title       string `json:"title"`
-			URL         string `json:"url"`
-			Description string `json:"description"`
-			Age         string `json:"age,omitempty"`
-		} `json:"results"`
-	} `json:"web"`
-	Query struct {
-		Original string      `json:"original"`
-		Show     interface{} `json:"show_strict_warning"`
-		Altered  string      `json:"altered,omitempty"`
-	} `json:"query"`
+type webSearchArgs struct {
+	Query string `json:"query" description:"Search query string"`
+	Count int    `json:"count,omitempty" description:"Number of search results to return (default: 5, max: 20)" schema:"minimum=1,maximum=20"`
 }
 
-func webSearchHandler(ctx context.Context, args map[string]interface{}) (string, error) {
-	// Rate limiting check
-	if !webSearchRateLimiter.Allow() {
-		return "", fmt.Errorf("rate limit exceeded: maximum 10 web searches per minute allowed")
+func webSearchHandler(ctx context.Context, args webSearchArgs) (string, error) {
+	query, count, err := searchQuery(args)
+	if err != nil {
+		return "", err
 	}
 
-	query, ok := args["query"].(string)
-	if !ok {
-		return "", fmt.Errorf("query must be a string")
-	}
+	return formatSearchOutcome(performWebSearch(ctx, query, count)), nil
+}
+
+// searchQuery validates the web_search arguments, clamping count to 1-20
+func searchQuery(args webSearchArgs) (string, int, error) {
+	query := args.Query
 
 	// Input validation
 	if len(strings.TrimSpace(query)) == 0 {
-		return "", fmt.Errorf("search query cannot be empty")
+		return "", 0, fmt.Errorf("search query cannot be empty")
 	}
 
 	if len(query) > 1000 {
-		return "", fmt.Errorf("search query too long (max 1000 characters)")
+		return "", 0, fmt.Errorf("search query too long (max 1000 characters)")
 	}
 
 	count := 5 // default
-	if c, ok := args["count"].(float64); ok {
-		count = int(c)
-		if count > 20 {
-			count = 20
-		}
-		if count < 1 {
-			count = 1
-		}
-	}
-
-	// For now, we'll use a simple HTTP search instead of Brave API
-	// In production, you'd want to use proper Brave Search API with API key
-	results, err := performWebSearch(ctx, query, count)
-	if err != nil {
-		return "", fmt.Errorf("web search failed: %w", err)
+	if args.Count != 0 {
+		count = min(max(args.Count, 1), 20)
 	}
 
-	return formatSearchResults(query, results), nil
-}
-
-func performWebSearch(ctx context.Context, query string, count int) ([]SearchResult, error) {
-	// Try to load Brave API key from .env.brave.api file
-	apiKey := loadBraveAPIKey()
-
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	if apiKey != "" {
-		// Use Brave Search API if we have a key
-		apiURL := fmt.Sprintf("https://api.search.brave.com/res/v1/web/search?q=%s&count=%d",
-			url.QueryEscape(query), count)
-
-		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-		if err != nil {
-			return nil, err
-		}
-
-		req.Header.Set("Accept", "application/json")
-		req.Header.Set("X-Subscription-Token", apiKey)
-		req.Header.Set("User-Agent", "goss-cli/1.0")
-
-		resp, err := client.Do(req)
-		if err != nil {
-			return nil, err
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			// Fallback to DuckDuckGo if Brave API fails
-			return performDuckDuckGoSearch(ctx, query, count)
-		}
-
-		var braveResp BraveSearchResponse
-		if err := json.NewDecoder(resp.Body).Decode(&braveResp); err != nil {
-			return nil, err
-		}
-
-		var results []SearchResult
-		for i, r := range braveResp.Web.Results {
-			if i >= count {
-				break
-			}
-			results = append(results, SearchResult{
-				Title:       r.Title,
-				URL:         r.URL,
-				Description: r.Description,
-			})
-		}
-		return results, nil
-	}
-
-	// Fallback to DuckDuckGo if no API key
-	return performDuckDuckGoSearch(ctx, query, count)
-}
-
-func performDuckDuckGoSearch(ctx context.Context, query string, count int) ([]SearchResult, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	// Use DuckDuckGo API as fallback
-	apiURL := fmt.Sprintf("https://api.duckduckgo.com/?q=%s&format=json&no_html=1&skip_disambig=1",
-		url.QueryEscape(query))
-
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("User-Agent", "goss-cli/1.0")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	var ddgResponse DuckDuckGoResponse
-	if err := json.NewDecoder(resp.Body).Decode(&ddgResponse); err != nil {
-		return nil, err
-	}
-
-	var results []SearchResult
-
-	// Add instant answer if available
-	if ddgResponse.AbstractText != "" {
-		results = append(results, SearchResult{
-			Title:       ddgResponse.Heading,
-			URL:         ddgResponse.AbstractURL,
-			Description: ddgResponse.AbstractText,
-		})
-	}
-
-	// Add related topics
-	for i, topic := range ddgResponse.RelatedTopics {
-		if i >= count-1 { // Save space for instant answer
-			break
-		}
-		if topic.Text != "" {
-			results = append(results, SearchResult{
-				Title:       extractTitle(topic.Text),
-				URL:         topic.FirstURL,
-				Description: topic.Text,
-			})
-		}
-	}
-
-	// If we don't have enough results, try web scraping for current events
-	if len(results) == 0 {
-		scrapedResults, err := performWebScrapeSearch(ctx, query, count)
-		if err == nil && len(scrapedResults) > 0 {
-			results = scrapedResults
-		} else {
-			// Last resort: provide helpful search suggestions
-			results = []SearchResult{
-				{
-					Title:       "Search Suggestions",
-					URL:         "https://duckduckgo.com/?q=" + url.QueryEscape(query),
-					Description: fmt.Sprintf("For current information about '%s', try these specific searches: '%s recent news', '%s latest updates', or '%s today'.", query, query, query, query),
-				},
-			}
-		}
-	}
-
-	return results, nil
-}
-
-type SearchResult struct {
-	Title       string
-	URL         string
-	Description string
-}
-
-type DuckDuckGoResponse struct {
-	AbstractText  string         `json:"AbstractText"`
-	AbstractURL   string         `json:"AbstractURL"`
-	Heading       string         `json:"Heading"`
-	RelatedTopics []RelatedTopic `json:"RelatedTopics"`
-}
-
-type RelatedTopic struct {
-	FirstURL string `json:"FirstURL"`
-	Text     string `json:"Text"`
+	return query, count, nil
 }
 
 func extractTitle(text string) string {
@@ -333,77 +100,60 @@ func loadBraveAPIKey() string {
 	return ""
 }
 
-func performWebScrapeSearch(ctx context.Context, query string, count int) ([]SearchResult, error) {
-	// Use a simple search engine scraping approach
-	// In a production environment, you'd want to use proper APIs like Brave, Bing, etc.
-	
-	client := &http.Client{Timeout: 10 * time.Second}
-	
-	// Try using a simple search aggregator that provides JSON results
-	// Using SerpAPI-style free endpoint or similar
-	searchURL := fmt.Sprintf("https://html.duckduckgo.com/html/?q=%s", url.QueryEscape(query))
-	
-	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
-	if err != nil {
-		return nil, err
-	}
-	
-	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; goss-cli/1.0)")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("search request failed with status: %d", resp.StatusCode)
-	}
-	
-	// For now, return a helpful fallback result that directs users to search engines
-	// In a full implementation, you'd parse HTML or use a proper search API
-	results := []SearchResult{
-		{
-			Title:       fmt.Sprintf("Search for '%s' on DuckDuckGo", query),
-			URL:         fmt.Sprintf("https://duckduckgo.com/?q=%s", url.QueryEscape(query)),
-			Description: fmt.Sprintf("Click to search for current information about '%s' on DuckDuckGo. For breaking news, try adding 'today' or 'news' to your search.", query),
-		},
-		{
-			Title:       fmt.Sprintf("Search for '%s' on Google", query),
-			URL:         fmt.Sprintf("https://google.com/search?q=%s", url.QueryEscape(query)),
-			Description: fmt.Sprintf("Alternative search for '%s' on Google. Use this for the most current news and information.", query),
-		},
-	}
-	
-	// Add news-specific suggestions for news queries
-	if strings.Contains(strings.ToLower(query), "news") || 
-	   strings.Contains(strings.ToLower(query), "recent") || 
-	   strings.Contains(strings.ToLower(query), "today") ||
-	   strings.Contains(strings.ToLower(query), "latest") {
-		results = append(results, SearchResult{
-			Title:       "News Search Suggestion",
-			URL:         fmt.Sprintf("https://news.google.com/search?q=%s", url.QueryEscape(query)),
-			Description: fmt.Sprintf("For the latest news about '%s', try Google News, Reddit, or Twitter search. Current events require real-time sources.", query),
-		})
-	}
-	
-	return results, nil
-}
-
-func formatSearchResults(query string, results []SearchResult) string {
+// formatSearchOutcome renders a search for the model: the hits with their
+// sources, or a plain statement that nothing was found, followed by what
+// each provider answered.
+func formatSearchOutcome(outcome SearchOutcome) string {
 	var output strings.Builder
-	output.WriteString(fmt.Sprintf("Web search results for: %s\n\n", query))
 
-	if len(results) == 0 {
-		output.WriteString("No results found. Try a different search query.\n")
-		return output.String()
+	switch outcome.Status {
+	case SearchHits:
+		output.WriteString(fmt.Sprintf("Web search results for: %s (via %s)\n\n", outcome.Query, outcome.Provider))
+		for i, result := range outcome.Results {
+			output.WriteString(fmt.Sprintf("%d. %s\n", i+1, result.Title))
+			output.WriteString(fmt.Sprintf("   URL: %s\n", result.URL))
+			if result.Description != "" {
+				output.WriteString(fmt.Sprintf("   %s\n", result.Description))
+			}
+			output.WriteString("\n")
+		}
+	case SearchEmpty:
+		output.WriteString(fmt.Sprintf("No web search results for: %s\n\n", outcome.Query))
+	case SearchFailed:
+		output.WriteString(fmt.Sprintf("Web search failed for: %s\n\n", outcome.Query))
+	}
+
+	output.WriteString(fmt.Sprintf("Status: %s\n", outcome.Status))
+	output.WriteString("Providers:\n")
+	for _, attempt := range outcome.Attempts {
+		elapsed := attempt.Duration.Round(time.Millisecond)
+		switch {
+		case attempt.Cached && attempt.Results == 0:
+			output.WriteString(fmt.Sprintf("- %s: no results (cached)\n", attempt.Provider))
+		case attempt.Cached:
+			output.WriteString(fmt.Sprintf("- %s: %d results (cached)\n", attempt.Provider, attempt.Results))
+		case attempt.Err != nil:
+			output.WriteString(fmt.Sprintf("- %s: error after %s: %v\n", attempt.Provider, elapsed, attempt.Err))
+		case attempt.Results == 0 && attempt.Hint != "":
+			output.WriteString(fmt.Sprintf("- %s: no results in %s (%s)\n", attempt.Provider, elapsed, attempt.Hint))
+		default:
+			output.WriteString(fmt.Sprintf("- %s: %d results in %s\n", attempt.Provider, attempt.Results, elapsed))
+		}
 	}
 
-	for i, result := range results {
-		output.WriteString(fmt.Sprintf("%d. %s\n", i+1, result.Title))
-		output.WriteString(fmt.Sprintf("   URL: %s\n", result.URL))
-		output.WriteString(fmt.Sprintf("   %s\n\n", result.Description))
+	switch outcome.Status {
+	case SearchEmpty:
+		output.WriteString("\nNothing was found. You may call web_search again with a rephrased query " +
+			"(different or fewer keywords); otherwise tell the user that the search found nothing. " +
+			"Do not cite sources that are not listed in a search result.\n")
+	case SearchFailed:
+		if outcome.Offline {
+			output.WriteString("\nOffline mode: only searches cached by earlier runs can be answered. " +
+				"Tell the user this query is not cached; do not make up results.\n")
+			break
+		}
+		output.WriteString("\nNo provider could answer, so retrying the same query is unlikely to help. " +
+			"Tell the user the search is unavailable and why; do not make up results.\n")
 	}
 
 	return output.String()
@@ -0,0 +1,100 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// parseArguments decodes the arguments of a tool call into an object. Text
+// that is not valid JSON is repaired once for mistakes local models often
+// make: an empty string for no arguments, a Markdown code fence around the
+// object and trailing commas.
+func parseArguments(raw string) (map[string]interface{}, error) {
+	args, err := decodeArguments(raw)
+	if err == nil {
+		return args, nil
+	}
+	if repaired := repairJSON(raw); repaired != raw {
+		if args, repairErr := decodeArguments(repaired); repairErr == nil {
+			return args, nil
+		}
+	}
+	return nil, err
+}
+
+func decodeArguments(raw string) (map[string]interface{}, error) {
+	if strings.TrimSpace(raw) == "" {
+		return map[string]interface{}{}, nil
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return nil, fmt.Errorf("invalid JSON at character %d (%s): %v; send the arguments as a single JSON object",
+				syntaxErr.Offset, nearOffset(raw, syntaxErr.Offset), syntaxErr)
+		}
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	switch v := decoded.(type) {
+	case map[string]interface{}:
+		return v, nil
+	case nil:
+		return map[string]interface{}{}, nil
+	default:
+		return nil, fmt.Errorf("arguments must be a JSON object, got %T", decoded)
+	}
+}
+
+// repairJSON strips a code fence and removes commas that directly precede
+// a closing brace or bracket, leaving string contents untouched
+func repairJSON(raw string) string {
+	text := strings.TrimSpace(raw)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```")
+		if newline := strings.IndexByte(text, '\n'); newline >= 0 {
+			text = text[newline+1:] // Drop the language tag
+		}
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+	}
+
+	var b strings.Builder
+	inString, escaped := false, false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == ',':
+			next := strings.TrimLeft(text[i+1:], " \t\r\n")
+			if next != "" && (next[0] == '}' || next[0] == ']') {
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// nearOffset quotes the text around a syntax error position
+func nearOffset(raw string, offset int64) string {
+	start, end := int(offset)-10, int(offset)+10
+	if start < 0 {
+		start = 0
+	}
+	if end > len(raw) {
+		end = len(raw)
+	}
+	return fmt.Sprintf("near %q", raw[start:end])
+}
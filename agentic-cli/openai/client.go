@@ -15,10 +15,12 @@ import (
 
 // Client represents an OpenAI-compatible API client
 type Client struct {
-	BaseURL    string
-	APIKey     string
-	httpClient *http.Client
-	Tools      []Tool
+	BaseURL        string
+	APIKey         string
+	ReasoningStyle string // How the thinking level is sent, one of the ReasoningStyle constants; defaults to none
+	httpClient     *http.Client
+	Tools          []Tool
+	middleware     []ToolMiddleware
 }
 
 // NewClient creates a new OpenAI-compatible client
@@ -42,6 +44,28 @@ func (c *Client) AddTool(tool Tool) {
 	c.Tools = append(c.Tools, tool)
 }
 
+// SetTools replaces the registered tools
+func (c *Client) SetTools(tools []Tool) {
+	c.Tools = tools
+}
+
+// IsReadOnly reports whether name is a registered tool that never modifies
+// files or other state
+func (c *Client) IsReadOnly(name string) bool {
+	for _, tool := range c.Tools {
+		if tool.Function.Name == name {
+			return tool.Function.ReadOnly
+		}
+	}
+	return false
+}
+
+// Use appends middleware to the chain every tool call runs through. The
+// first middleware added is the outermost.
+func (c *Client) Use(middleware ...ToolMiddleware) {
+	c.middleware = append(c.middleware, middleware...)
+}
+
 // ChatCompletionRequest represents the request structure for chat completions
 type ChatCompletionRequest struct {
 	Model       string    `json:"model"`
@@ -50,6 +74,12 @@ type ChatCompletionRequest struct {
 	MaxTokens   int       `json:"max_tokens,omitempty"`
 	Stream      bool      `json:"stream,omitempty"`
 	Tools       []Tool    `json:"tools,omitempty"`
+	PromptTools bool      `json:"-"` // Tools are described in Messages instead of sent natively
+
+	// Thinking level, in the parameter the provider's reasoning style uses
+	ReasoningEffort string           `json:"reasoning_effort,omitempty"`
+	Thinking        *ThinkingConfig  `json:"thinking,omitempty"`
+	Reasoning       *ReasoningConfig `json:"reasoning,omitempty"`
 }
 
 // Message represents a chat message
@@ -58,6 +88,7 @@ type Message struct {
 	Content    string     `json:"content,omitempty"`
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string     `json:"tool_call_id,omitempty"`
+	Reasoning  string     `json:"reasoning,omitempty"` // Thinking that led to the reply; kept out of requests
 }
 
 // ToolCall represents a function call
@@ -85,11 +116,17 @@ type ToolFunction struct {
 	Description string                 `json:"description"`
 	Parameters  map[string]interface{} `json:"parameters"`
 	Handler     ToolHandler            `json:"-"`
+	ReadOnly    bool                   `json:"-"` // The tool never modifies files or other state
 }
 
 // ToolHandler is a function that executes a tool
 type ToolHandler func(ctx context.Context, args map[string]interface{}) (string, error)
 
+// ToolMiddleware wraps the handler of a tool with behaviour shared by all
+// tools, such as validation or logging. It receives the tool definition so
+// it can consult the name and parameter schema.
+type ToolMiddleware func(tool ToolFunction, next ToolHandler) ToolHandler
+
 // ChatCompletionResponse represents the response from chat completions
 type ChatCompletionResponse struct {
 	ID      string   `json:"id"`
@@ -125,7 +162,7 @@ type StreamingChoice struct {
 type StreamingDelta struct {
 	Role      string     `json:"role,omitempty"`
 	Content   string     `json:"content,omitempty"`
-	Reasoning string     `json:"reasoning,omitempty"` // LM Studio thinking tokens
+	Reasoning string     `json:"reasoning,omitempty"` // Thinking tokens, from "reasoning", "reasoning_content" or <think> tags
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 }
 
@@ -147,24 +184,11 @@ type ThinkingLevel struct {
 	Budget int    `json:"budget"` // Token budget for thinking
 }
 
-// GetThinkingBudget returns the token budget for a thinking level
-func GetThinkingBudget(level string) int {
-	budgets := map[string]int{
-		"off":  0,
-		"low":  50,
-		"med":  200,
-		"high": 500,
-	}
-	if budget, exists := budgets[level]; exists {
-		return budget
-	}
-	return 200 // Default to medium
-}
 
 // CreateChatCompletion sends a chat completion request
-func (c *Client) CreateChatCompletion(ctx context.Context, req ChatCompletionRequest) (*ChatCompletionResponse, error) {
+func (c *Client) CreateChatCompletion(ctx context.Context, req ChatCompletionRequest, thinkingLevel string) (*ChatCompletionResponse, error) {
 	// Add tools to request if available
-	if len(c.Tools) > 0 {
+	if len(c.Tools) > 0 && !req.PromptTools {
 		req.Tools = c.Tools
 		// Debug: Log that tools are being sent
 		if debugMode := os.Getenv("GOSS_DEBUG"); debugMode != "" {
@@ -175,6 +199,8 @@ func (c *Client) CreateChatCompletion(ctx context.Context, req ChatCompletionReq
 		}
 	}
 
+	c.applyThinking(&req, thinkingLevel)
+	req.Messages = requestMessages(req.Messages)
 	reqBody, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
@@ -215,6 +241,9 @@ func (c *Client) CreateChatCompletion(ctx context.Context, req ChatCompletionReq
 	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
 		return nil, fmt.Errorf("decode response: %w", err)
 	}
+	for i := range chatResp.Choices {
+		SplitThinking(&chatResp.Choices[i].Message)
+	}
 
 	// Debug: Log response details
 	if debugMode := os.Getenv("GOSS_DEBUG"); debugMode != "" {
@@ -238,25 +267,29 @@ func (c *Client) CreateChatCompletion(ctx context.Context, req ChatCompletionReq
 // ExecuteTool executes a tool call and returns the result
 func (c *Client) ExecuteTool(ctx context.Context, toolCall ToolCall) (string, error) {
 	// Find the tool handler
-	var handler ToolHandler
-	for _, tool := range c.Tools {
-		if tool.Function.Name == toolCall.Function.Name {
-			handler = tool.Function.Handler
+	var function *ToolFunction
+	for i, tool := range c.Tools {
+		if tool.Function.Name == toolCall.Function.Name && tool.Function.Handler != nil {
+			function = &c.Tools[i].Function
 			break
 		}
 	}
 
-	if handler == nil {
+	if function == nil {
 		return "", fmt.Errorf("tool not found: %s", toolCall.Function.Name)
 	}
 
 	// Parse arguments
-	var args map[string]interface{}
-	if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
+	args, err := parseArguments(toolCall.Function.Arguments)
+	if err != nil {
 		return "", fmt.Errorf("parse tool arguments: %w", err)
 	}
 
-	// Execute tool
+	// Execute tool through the middleware chain, outermost first
+	handler := function.Handler
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		handler = c.middleware[i](*function, handler)
+	}
 	return handler(ctx, args)
 }
 
@@ -266,12 +299,12 @@ func (c *Client) CreateChatCompletionStream(ctx context.Context, req ChatComplet
 	req.Stream = true
 	
 	// Add tools to request if available
-	if len(c.Tools) > 0 {
+	if len(c.Tools) > 0 && !req.PromptTools {
 		req.Tools = c.Tools
 	}
 	
-	// LM Studio doesn't need extra thinking configuration - it provides reasoning automatically
-	// Just make a standard streaming request
+	c.applyThinking(&req, thinkingLevel)
+	req.Messages = requestMessages(req.Messages)
 	reqBody, err := json.Marshal(req)
 	if err != nil {
 		return fmt.Errorf("marshal streaming request: %w", err)
@@ -327,6 +360,7 @@ func (c *Client) performStreamingRequest(ctx context.Context, reqBody []byte, ca
 
 func (c *Client) processStreamingResponse(ctx context.Context, body io.Reader, callback StreamCallback) error {
 	scanner := bufio.NewScanner(body)
+	thinking := make(streamThinking)
 	
 	for scanner.Scan() {
 		select {
@@ -378,7 +412,8 @@ func (c *Client) processStreamingResponse(ctx context.Context, body io.Reader, c
 				fmt.Fprintf(os.Stderr, "[DEBUG] Parsed chunk, choices: %d\n", len(chunk.Choices))
 			}
 			
-			// Call the callback with the parsed chunk
+			// Call the callback with the parsed chunk, reasoning separated
+			thinking.split(&chunk)
 			if err := callback(chunk); err != nil {
 				return fmt.Errorf("streaming callback error: %w", err)
 			}
@@ -388,6 +423,11 @@ func (c *Client) processStreamingResponse(ctx context.Context, body io.Reader, c
 	if err := scanner.Err(); err != nil {
 		return fmt.Errorf("error reading streaming response: %w", err)
 	}
+	if chunk := thinking.flush(); chunk != nil {
+		if err := callback(*chunk); err != nil {
+			return fmt.Errorf("streaming callback error: %w", err)
+		}
+	}
 	
 	return nil
 }
@@ -1,6 +1,7 @@
 package openai
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -104,3 +105,34 @@ func TestMessage(t *testing.T) {
 		t.Errorf("Expected content 'Test message', got %s", msg.Content)
 	}
 }
+
+func TestParseArguments(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		errPart string
+	}{
+		{raw: `{"path": "a.txt"}`, want: "a.txt"},
+		{raw: "", want: ""},
+		{raw: `{"path": "a,}.txt",}`, want: "a,}.txt"},
+		{raw: "```json\n{\"path\": \"a.txt\"}\n```", want: "a.txt"},
+		{raw: `{"path": }`, errPart: `invalid JSON at character 10 (near "{\"path\": }")`},
+		{raw: `["a.txt"]`, errPart: "arguments must be a JSON object"},
+	}
+	for _, tt := range tests {
+		args, err := parseArguments(tt.raw)
+		if tt.errPart != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.errPart) {
+				t.Errorf("%q: expected error containing %q, got %v", tt.raw, tt.errPart, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.raw, err)
+			continue
+		}
+		if got, _ := args["path"].(string); got != tt.want {
+			t.Errorf("%q: expected path %q, got %q", tt.raw, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// Models without native function calling get their tools through the system
+// prompt instead. ToolPrompt asks for Hermes-style <tool_call> blocks, and
+// ParseToolCalls also understands the formats other model families fall
+// back to.
+
+// ToolPrompt describes tools and the text format for calling them, for the
+// system message of a model without native tool calls
+func ToolPrompt(tools []Tool) string {
+	var b strings.Builder
+	b.WriteString("# Tools\n\n")
+	b.WriteString("You may call the tools below. Each is described by its name, what it does and the JSON Schema of its arguments:\n")
+	b.WriteString("<tools>\n")
+	for _, tool := range tools {
+		data, _ := json.Marshal(struct {
+			Name        string                 `json:"name"`
+			Description string                 `json:"description"`
+			Parameters  map[string]interface{} `json:"parameters"`
+		}{tool.Function.Name, tool.Function.Description, tool.Function.Parameters})
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	b.WriteString("</tools>\n\n")
+	b.WriteString("To call a tool, reply with one block per call, exactly like this, and stop after the last block:\n")
+	b.WriteString("<tool_call>\n{\"name\": \"tool_name\", \"arguments\": {\"argument\": \"value\"}}\n</tool_call>\n\n")
+	b.WriteString("The results come back in <tool_response> blocks. Once you have what you need, answer without a <tool_call> block.")
+	return b.String()
+}
+
+// PromptToolMessages rewrites a conversation for a model without native tool
+// calls: the tools are described in the system message, earlier tool calls
+// become <tool_call> blocks in the assistant's content and tool results are
+// sent as user messages of <tool_response> blocks
+func PromptToolMessages(messages []Message, tools []Tool) []Message {
+	prompt := ToolPrompt(tools)
+	out := make([]Message, 0, len(messages)+1)
+	if len(messages) == 0 || messages[0].Role != "system" {
+		out = append(out, Message{Role: "system", Content: prompt})
+	}
+
+	callNames := make(map[string]string) // Tool call ID → tool name
+	lastWasResult := false
+	for i, msg := range messages {
+		isResult := msg.Role == "tool"
+		switch {
+		case i == 0 && msg.Role == "system":
+			msg.Content += "\n\n" + prompt
+		case msg.Role == "assistant" && len(msg.ToolCalls) > 0:
+			blocks := []string{}
+			if strings.TrimSpace(msg.Content) != "" {
+				blocks = append(blocks, msg.Content)
+			}
+			for _, call := range msg.ToolCalls {
+				callNames[call.ID] = call.Function.Name
+				blocks = append(blocks, fmt.Sprintf("<tool_call>\n{\"name\": %s, \"arguments\": %s}\n</tool_call>",
+					jsonString(call.Function.Name), jsonArguments(call.Function.Arguments)))
+			}
+			msg = Message{Role: "assistant", Content: strings.Join(blocks, "\n")}
+		case isResult:
+			response := fmt.Sprintf("<tool_response>\n{\"name\": %s, \"content\": %s}\n</tool_response>",
+				jsonString(callNames[msg.ToolCallID]), jsonString(msg.Content))
+			if lastWasResult {
+				// Results of one turn's calls share a message
+				out[len(out)-1].Content += "\n" + response
+				continue
+			}
+			msg = Message{Role: "user", Content: response}
+		}
+		lastWasResult = isResult
+		out = append(out, msg)
+	}
+	return out
+}
+
+func jsonString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// jsonArguments returns arguments as a JSON object, quoting text that is not
+// valid JSON
+func jsonArguments(arguments string) string {
+	arguments = strings.TrimSpace(arguments)
+	if arguments == "" {
+		return "{}"
+	}
+	if json.Valid([]byte(arguments)) {
+		return arguments
+	}
+	return jsonString(arguments)
+}
+
+var (
+	// Hermes, Qwen and most fine-tunes: <tool_call>{"name": ..., "arguments": ...}</tool_call>.
+	// The closing tag is optional because it is often eaten as a stop token.
+	hermesCall = regexp.MustCompile(`(?s)<tool_call>\s*(.*?)\s*(?:</tool_call>|$)`)
+	// Llama 3.1 and Qwen3 Coder: <function=name>{...}</function>, or with
+	// <parameter=key>value</parameter> elements
+	functionCall = regexp.MustCompile(`(?s)<function=([\w.-]+)>\s*(.*?)\s*</function>`)
+	xmlParameter = regexp.MustCompile(`(?s)<parameter=([\w.-]+)>\s*(.*?)\s*</parameter>`)
+	// XML invocations: <invoke name="name"><parameter name="key">value</parameter></invoke>
+	invokeCall      = regexp.MustCompile(`(?s)(?:<function_calls>\s*)?<invoke name="([\w.-]+)">(.*?)</invoke>(?:\s*</function_calls>)?`)
+	invokeParameter = regexp.MustCompile(`(?s)<parameter name="([\w.-]+)">(.*?)</parameter>`)
+	// gpt-oss harmony: <|channel|>commentary to=functions.name <|constrain|>json<|message|>{...}<|call|>
+	harmonyCall = regexp.MustCompile(`(?s)(?:<\|start\|>assistant)?(?:<\|channel\|>\w+)?\s*to=functions\.([\w.-]+)[^<]*(?:<\|constrain\|>\s*\w+\s*)?<\|message\|>(.*?)(?:<\|call\|>|<\|end\|>|$)`)
+	// JSON in a code fence, or a whole reply that is JSON
+	fencedJSON = regexp.MustCompile("(?s)```(?:json)?\\s*([\\[{].*?[\\]}])\\s*```")
+)
+
+// callIDs numbers tool calls parsed from text
+var callIDs atomic.Int64
+
+// textCall is a tool call found at content[start:end]
+type textCall struct {
+	start, end int
+	call       ToolCall
+}
+
+// ParseToolCalls extracts calls of the given tools written as text, returning
+// the content without them. Calls of unknown tools are left in the content.
+func ParseToolCalls(content string, tools []Tool) (string, []ToolCall) {
+	known := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		known[tool.Function.Name] = true
+	}
+
+	var found []textCall
+	add := func(start, end int, name, arguments string) {
+		if !known[name] {
+			return
+		}
+		for _, f := range found {
+			if start < f.end && f.start < end && (start != f.start || end != f.end) {
+				return // Part of another call
+			}
+		}
+		found = append(found, textCall{start: start, end: end, call: ToolCall{
+			ID:       fmt.Sprintf("call_text_%d", callIDs.Add(1)),
+			Type:     "function",
+			Function: Function{Name: name, Arguments: arguments},
+		}})
+	}
+
+	for _, m := range harmonyCall.FindAllStringSubmatchIndex(content, -1) {
+		add(m[0], m[1], content[m[2]:m[3]], jsonArguments(content[m[4]:m[5]]))
+	}
+	for _, m := range hermesCall.FindAllStringSubmatchIndex(content, -1) {
+		if name, arguments, ok := jsonCall(content[m[2]:m[3]]); ok {
+			add(m[0], m[1], name, arguments)
+		}
+	}
+	for _, m := range functionCall.FindAllStringSubmatchIndex(content, -1) {
+		body := content[m[4]:m[5]]
+		if params := xmlParameter.FindAllStringSubmatch(body, -1); params != nil {
+			add(m[0], m[1], content[m[2]:m[3]], xmlArguments(params))
+		} else {
+			add(m[0], m[1], content[m[2]:m[3]], jsonArguments(body))
+		}
+	}
+	for _, m := range invokeCall.FindAllStringSubmatchIndex(content, -1) {
+		add(m[0], m[1], content[m[2]:m[3]], xmlArguments(invokeParameter.FindAllStringSubmatch(content[m[4]:m[5]], -1)))
+	}
+
+	// Bare JSON is only a call when nothing else is
+	if len(found) == 0 {
+		for _, m := range fencedJSON.FindAllStringSubmatchIndex(content, -1) {
+			for _, call := range jsonCalls(content[m[2]:m[3]]) {
+				add(m[0], m[1], call[0], call[1])
+			}
+		}
+		if trimmed := strings.TrimSpace(content); len(found) == 0 && trimmed != "" {
+			start := strings.Index(content, trimmed)
+			for _, call := range jsonCalls(trimmed) {
+				add(start, start+len(trimmed), call[0], call[1])
+			}
+		}
+	}
+	if len(found) == 0 {
+		return content, nil
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].start < found[j].start })
+	var text strings.Builder
+	calls := make([]ToolCall, 0, len(found))
+	last := 0
+	for _, f := range found {
+		if f.start > last {
+			text.WriteString(content[last:f.start])
+		}
+		if f.end > last {
+			last = f.end
+		}
+		calls = append(calls, f.call)
+	}
+	text.WriteString(content[last:])
+	return strings.TrimSpace(text.String()), calls
+}
+
+// jsonCall decodes {"name": ..., "arguments": {...}}, also accepting
+// "parameters" for the arguments and the OpenAI {"function": {...}} wrapper
+func jsonCall(body string) (string, string, bool) {
+	var call map[string]interface{}
+	if err := json.Unmarshal([]byte(repairJSON(body)), &call); err != nil {
+		return "", "", false
+	}
+	return callFields(call)
+}
+
+// jsonCalls decodes a JSON call or an array of them into name and arguments
+// pairs
+func jsonCalls(body string) [][2]string {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(body), &decoded); err != nil {
+		return nil
+	}
+	items, ok := decoded.([]interface{})
+	if !ok {
+		items = []interface{}{decoded}
+	}
+
+	var calls [][2]string
+	for _, item := range items {
+		call, ok := item.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		name, arguments, ok := callFields(call)
+		if !ok {
+			return nil
+		}
+		calls = append(calls, [2]string{name, arguments})
+	}
+	return calls
+}
+
+func callFields(call map[string]interface{}) (string, string, bool) {
+	if function, ok := call["function"].(map[string]interface{}); ok {
+		call = function
+	}
+	name, ok := call["name"].(string)
+	if !ok {
+		return "", "", false
+	}
+
+	var arguments interface{} = map[string]interface{}{}
+	for _, key := range []string{"arguments", "parameters", "args"} {
+		if value, ok := call[key]; ok {
+			arguments = value
+			break
+		}
+	}
+	if s, ok := arguments.(string); ok {
+		return name, jsonArguments(s), true
+	}
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		return "", "", false
+	}
+	return name, string(data), true
+}
+
+// xmlArguments builds a JSON object from key and value matches. Values stay
+// strings; argument repair converts them to the types the schema declares.
+func xmlArguments(params [][]string) string {
+	arguments := make(map[string]interface{}, len(params))
+	for _, p := range params {
+		value := strings.TrimSpace(p[2])
+		var decoded interface{}
+		if (strings.HasPrefix(value, "{") || strings.HasPrefix(value, "[")) && json.Unmarshal([]byte(value), &decoded) == nil {
+			arguments[p[1]] = decoded
+		} else {
+			arguments[p[1]] = value
+		}
+	}
+	data, _ := json.Marshal(arguments)
+	return string(data)
+}
+
+// toolCallMarkers start tool calls written as text
+var toolCallMarkers = []string{"<tool_call>", "<function=", "<function_calls>", "<invoke ", "<|channel|>", "<|start|>", "to=functions."}
+
+// ToolCallFilter passes streamed content through until the start of a tool
+// call written as text, holding back anything that may begin one, so that
+// the call is not shown to the user
+type ToolCallFilter struct {
+	pending string // Text that may be the start of a marker
+	held    string // Text from the first marker on
+}
+
+// Write returns the part of text that can be shown now
+func (f *ToolCallFilter) Write(text string) string {
+	if f.held != "" {
+		f.held += text
+		return ""
+	}
+	f.pending += text
+
+	first := -1
+	for _, marker := range toolCallMarkers {
+		if i := strings.Index(f.pending, marker); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+	if first >= 0 {
+		show := f.pending[:first]
+		f.held, f.pending = f.pending[first:], ""
+		return show
+	}
+
+	keep := 0
+	for _, marker := range toolCallMarkers {
+		for n := min(len(marker)-1, len(f.pending)); n > keep; n-- {
+			if strings.HasSuffix(f.pending, marker[:n]) {
+				keep = n
+				break
+			}
+		}
+	}
+	show := f.pending[:len(f.pending)-keep]
+	f.pending = f.pending[len(f.pending)-keep:]
+	return show
+}
+
+// Flush returns the text held back, for when the reply turned out not to
+// contain tool calls
+func (f *ToolCallFilter) Flush() string {
+	text := f.pending + f.held
+	f.pending, f.held = "", ""
+	return text
+}
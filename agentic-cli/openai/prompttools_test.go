@@ -0,0 +1,156 @@
+package openai
+
+import (
+	"strings"
+	"testing"
+)
+
+func promptTestTools() []Tool {
+	return []Tool{
+		{Type: "function", Function: ToolFunction{Name: "read_file", Description: "Read a file"}},
+		{Type: "function", Function: ToolFunction{Name: "web_search", Description: "Search the web"}},
+	}
+}
+
+func TestParseToolCalls(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		text    string
+		calls   []string // name + arguments
+	}{
+		{
+			name:    "hermes",
+			content: "Let me look.\n<tool_call>\n{\"name\": \"read_file\", \"arguments\": {\"path\": \"a.txt\"}}\n</tool_call>",
+			text:    "Let me look.",
+			calls:   []string{`read_file {"path":"a.txt"}`},
+		},
+		{
+			name:    "hermes without closing tag",
+			content: `<tool_call>{"name": "web_search", "parameters": {"query": "go"}}`,
+			calls:   []string{`web_search {"query":"go"}`},
+		},
+		{
+			name:    "several hermes calls",
+			content: `<tool_call>{"name": "read_file", "arguments": {"path": "a"}}</tool_call><tool_call>{"name": "read_file", "arguments": "{\"path\": \"b\"}"}</tool_call>`,
+			calls:   []string{`read_file {"path":"a"}`, `read_file {"path": "b"}`},
+		},
+		{
+			name:    "function tag",
+			content: `<function=web_search>{"query": "weather"}</function>`,
+			calls:   []string{`web_search {"query": "weather"}`},
+		},
+		{
+			name:    "function tag with parameters",
+			content: "<function=read_file>\n<parameter=path>\nnotes.txt\n</parameter>\n</function>",
+			calls:   []string{`read_file {"path":"notes.txt"}`},
+		},
+		{
+			name:    "invoke",
+			content: "Checking.\n<function_calls>\n<invoke name=\"read_file\">\n<parameter name=\"path\">a.txt</parameter>\n</invoke>\n</function_calls>",
+			text:    "Checking.",
+			calls:   []string{`read_file {"path":"a.txt"}`},
+		},
+		{
+			name:    "harmony",
+			content: `<|channel|>commentary to=functions.web_search <|constrain|>json<|message|>{"query": "news"}<|call|>`,
+			calls:   []string{`web_search {"query": "news"}`},
+		},
+		{
+			name:    "fenced json array",
+			content: "```json\n[{\"name\": \"read_file\", \"arguments\": {\"path\": \"a\"}}, {\"name\": \"web_search\", \"arguments\": {\"query\": \"b\"}}]\n```",
+			calls:   []string{`read_file {"path":"a"}`, `web_search {"query":"b"}`},
+		},
+		{
+			name:    "bare json with function wrapper",
+			content: `{"type": "function", "function": {"name": "web_search", "arguments": {"query": "x"}}}`,
+			calls:   []string{`web_search {"query":"x"}`},
+		},
+		{
+			name:    "unknown tool",
+			content: `<tool_call>{"name": "delete_everything", "arguments": {}}</tool_call>`,
+			text:    `<tool_call>{"name": "delete_everything", "arguments": {}}</tool_call>`,
+		},
+		{
+			name:    "json that is not a call",
+			content: "```json\n{\"name\": \"Ada\", \"age\": 36}\n```",
+			text:    "```json\n{\"name\": \"Ada\", \"age\": 36}\n```",
+		},
+	}
+	for _, tt := range tests {
+		text, calls := ParseToolCalls(tt.content, promptTestTools())
+		if text != tt.text {
+			t.Errorf("%s: expected text %q, got %q", tt.name, tt.text, text)
+		}
+		var got []string
+		ids := make(map[string]bool)
+		for _, call := range calls {
+			got = append(got, call.Function.Name+" "+call.Function.Arguments)
+			if call.Type != "function" || call.ID == "" || ids[call.ID] {
+				t.Errorf("%s: expected a unique ID and type function, got %+v", tt.name, call)
+			}
+			ids[call.ID] = true
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.calls, "\n") {
+			t.Errorf("%s: expected calls\n%s\ngot\n%s", tt.name, strings.Join(tt.calls, "\n"), strings.Join(got, "\n"))
+		}
+	}
+}
+
+func TestPromptToolMessages(t *testing.T) {
+	history := []Message{
+		{Role: "system", Content: "Be brief."},
+		{Role: "user", Content: "Compare a and b"},
+		{Role: "assistant", ToolCalls: []ToolCall{
+			{ID: "1", Type: "function", Function: Function{Name: "read_file", Arguments: `{"path": "a"}`}},
+			{ID: "2", Type: "function", Function: Function{Name: "read_file", Arguments: `{"path": "b"}`}},
+		}},
+		{Role: "tool", ToolCallID: "1", Content: "alpha"},
+		{Role: "tool", ToolCallID: "2", Content: `"beta"`},
+	}
+
+	messages := PromptToolMessages(history, promptTestTools())
+	if len(messages) != 4 {
+		t.Fatalf("Expected 4 messages, got %d: %+v", len(messages), messages)
+	}
+	if system := messages[0].Content; !strings.HasPrefix(system, "Be brief.\n\n# Tools") ||
+		!strings.Contains(system, `{"name":"web_search","description":"Search the web","parameters":null}`) {
+		t.Errorf("Expected the tools appended to the system prompt, got:\n%s", system)
+	}
+	if call := messages[2]; call.Role != "assistant" || len(call.ToolCalls) != 0 ||
+		strings.Count(call.Content, "<tool_call>\n{\"name\": \"read_file\"") != 2 {
+		t.Errorf("Expected the calls as text, got %+v", call)
+	}
+	want := "<tool_response>\n{\"name\": \"read_file\", \"content\": \"alpha\"}\n</tool_response>\n" +
+		"<tool_response>\n{\"name\": \"read_file\", \"content\": \"\\\"beta\\\"\"}\n</tool_response>"
+	if result := messages[3]; result.Role != "user" || result.Content != want {
+		t.Errorf("Expected both results in one user message, got %+v", result)
+	}
+	if history[0].Content != "Be brief." || len(history[2].ToolCalls) != 2 {
+		t.Error("Expected the history to be left unchanged")
+	}
+
+	if messages := PromptToolMessages([]Message{{Role: "user", Content: "hi"}}, promptTestTools()); len(messages) != 2 || messages[0].Role != "system" {
+		t.Errorf("Expected a system message to be added, got %+v", messages)
+	}
+}
+
+func TestToolCallFilter(t *testing.T) {
+	var f ToolCallFilter
+	var shown strings.Builder
+	for _, chunk := range []string{"Let me ", "check. <", "tool_", "call>{\"name\"", ": \"read_file\"}"} {
+		shown.WriteString(f.Write(chunk))
+	}
+	if shown.String() != "Let me check. " {
+		t.Errorf("Expected text before the call only, got %q", shown.String())
+	}
+
+	f = ToolCallFilter{}
+	shown.Reset()
+	for _, chunk := range []string{"a <", "b> c <to", "ol"} {
+		shown.WriteString(f.Write(chunk))
+	}
+	if shown.String() != "a <b> c " || f.Flush() != "<tool" {
+		t.Errorf("Expected only a possible marker to be held back, got %q", shown.String())
+	}
+}
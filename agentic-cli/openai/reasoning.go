@@ -0,0 +1,315 @@
+package openai
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Reasoning styles: how a provider takes the thinking level
+const (
+	// ReasoningStyleEffort sends "reasoning_effort" (OpenAI, LM Studio, vLLM)
+	ReasoningStyleEffort = "effort"
+	// ReasoningStyleBudget sends "thinking" with a token budget (Anthropic)
+	ReasoningStyleBudget = "budget"
+	// ReasoningStyleOpenRouter sends a "reasoning" object with an effort
+	ReasoningStyleOpenRouter = "openrouter"
+	// ReasoningStyleNone sends nothing, the default: servers and models
+	// that do not reason may reject the parameters
+	ReasoningStyleNone = "none"
+)
+
+// minThinkingBudget is the smallest budget the budget style accepts
+const minThinkingBudget = 1024
+
+// ThinkingConfig is the "thinking" parameter of the budget style
+type ThinkingConfig struct {
+	Type         string `json:"type"` // "enabled" or "disabled"
+	BudgetTokens int    `json:"budget_tokens,omitempty"`
+}
+
+// ReasoningConfig is the "reasoning" parameter of the OpenRouter style
+type ReasoningConfig struct {
+	Effort string `json:"effort,omitempty"`
+}
+
+// reasoningEfforts maps thinking levels to reasoning_effort values
+var reasoningEfforts = map[string]string{
+	"low":  "low",
+	"med":  "medium",
+	"high": "high",
+}
+
+// thinkingBudgets maps thinking levels to budget_tokens for the budget
+// style, whose providers take at least minThinkingBudget
+var thinkingBudgets = map[string]int{
+	"low":  minThinkingBudget,
+	"med":  4096,
+	"high": 16384,
+}
+
+// GetThinkingBudget returns the token budget for a thinking level: zero for
+// "off" and the medium budget for unknown levels
+func GetThinkingBudget(level string) int {
+	if level == "off" {
+		return 0
+	}
+	if budget, ok := thinkingBudgets[level]; ok {
+		return budget
+	}
+	return thinkingBudgets["med"]
+}
+
+// applyThinking sets the request parameters for a thinking level in the
+// client's reasoning style. Level "off" asks for no reasoning where the
+// provider can turn it off and sends nothing otherwise. Without a style
+// nothing is sent.
+func (c *Client) applyThinking(req *ChatCompletionRequest, level string) {
+	effort, ok := reasoningEfforts[level]
+	if !ok && level != "off" {
+		level, effort = "med", "medium"
+	}
+
+	switch c.ReasoningStyle {
+	case ReasoningStyleEffort:
+		req.ReasoningEffort = effort
+	case ReasoningStyleBudget:
+		if level == "off" {
+			req.Thinking = &ThinkingConfig{Type: "disabled"}
+			return
+		}
+		// The budget counts toward max_tokens and must stay below it, so
+		// max_tokens grows by the budget to keep its room for the answer
+		budget := thinkingBudgets[level]
+		if req.MaxTokens > 0 {
+			req.MaxTokens += budget
+		}
+		req.Thinking = &ThinkingConfig{Type: "enabled", BudgetTokens: budget}
+	case ReasoningStyleOpenRouter:
+		if effort != "" {
+			req.Reasoning = &ReasoningConfig{Effort: effort}
+		}
+	}
+}
+
+// requestMessages returns messages without their stored reasoning, which
+// providers do not take back
+func requestMessages(messages []Message) []Message {
+	for i := range messages {
+		if messages[i].Reasoning == "" {
+			continue
+		}
+		out := append([]Message(nil), messages...)
+		for j := i; j < len(out); j++ {
+			out[j].Reasoning = ""
+		}
+		return out
+	}
+	return messages
+}
+
+// UnmarshalJSON reads reasoning from "reasoning" (LM Studio, OpenRouter) or
+// "reasoning_content" (DeepSeek, vLLM, llama.cpp)
+func (m *Message) UnmarshalJSON(data []byte) error {
+	type message Message
+	var decoded struct {
+		message
+		ReasoningContent string `json:"reasoning_content"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*m = Message(decoded.message)
+	if m.Reasoning == "" {
+		m.Reasoning = decoded.ReasoningContent
+	}
+	return nil
+}
+
+// UnmarshalJSON reads reasoning from "reasoning" or "reasoning_content"
+func (d *StreamingDelta) UnmarshalJSON(data []byte) error {
+	type delta StreamingDelta
+	var decoded struct {
+		delta
+		ReasoningContent string `json:"reasoning_content"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*d = StreamingDelta(decoded.delta)
+	if d.Reasoning == "" {
+		d.Reasoning = decoded.ReasoningContent
+	}
+	return nil
+}
+
+// SplitThinking moves reasoning written inline between <think> and </think>
+// tags out of a message's content. A reply that only has the closing tag,
+// because the chat template opened the block, is split at that tag.
+func SplitThinking(msg *Message) {
+	var splitter ThinkSplitter
+	if !strings.Contains(msg.Content, thinkOpen) && strings.Contains(msg.Content, thinkClose) {
+		splitter.inside = true
+	}
+	reasoning, content := splitter.Write(msg.Content)
+	moreReasoning, moreContent := splitter.Flush()
+	reasoning, content = reasoning+moreReasoning, content+moreContent
+	if reasoning == "" {
+		return
+	}
+	msg.Reasoning = joinReasoning(msg.Reasoning, strings.TrimSpace(reasoning))
+	msg.Content = content
+}
+
+func joinReasoning(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + "\n" + b
+}
+
+const (
+	thinkOpen  = "<think>"
+	thinkClose = "</think>"
+)
+
+// ThinkSplitter separates reasoning written inline between <think> and
+// </think> tags from streamed content. Text that may be the start of a tag
+// is held back until the next chunk.
+type ThinkSplitter struct {
+	inside  bool   // Within a <think> block
+	closed  bool   // A block just closed; the whitespace after it is dropped
+	pending string // Text that may be the start of a tag
+}
+
+// Write returns the reasoning and the content in text
+func (s *ThinkSplitter) Write(text string) (reasoning, content string) {
+	text = s.pending + text
+	s.pending = ""
+
+	var thinking, answer strings.Builder
+	for text != "" {
+		tag := thinkOpen
+		if s.inside {
+			tag = thinkClose
+		}
+
+		part := text
+		i := strings.Index(text, tag)
+		if i >= 0 {
+			part, text = text[:i], text[i+len(tag):]
+		} else {
+			keep := partialSuffix(text, tag)
+			part, s.pending, text = text[:len(text)-keep], text[len(text)-keep:], ""
+		}
+
+		if s.inside {
+			thinking.WriteString(part)
+		} else {
+			if s.closed {
+				part = strings.TrimLeft(part, " \t\r\n")
+				s.closed = part == ""
+			}
+			answer.WriteString(part)
+		}
+		if i >= 0 {
+			s.closed = s.inside
+			s.inside = !s.inside
+		}
+	}
+	return thinking.String(), answer.String()
+}
+
+// Flush returns the text held back at the end of the stream
+func (s *ThinkSplitter) Flush() (reasoning, content string) {
+	text := s.pending
+	s.pending = ""
+	if s.inside {
+		return text, ""
+	}
+	return "", text
+}
+
+// partialSuffix returns the length of the longest suffix of text that is a
+// proper prefix of tag
+func partialSuffix(text, tag string) int {
+	for n := min(len(tag)-1, len(text)); n > 0; n-- {
+		if strings.HasSuffix(text, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// streamSplitter splits the inline reasoning of one streamed choice. A
+// chat template may open the <think> block itself, so that the reply has
+// only the closing tag and everything before it is reasoning; content is
+// therefore held back until a tag, or reasoning sent in its own field,
+// shows which kind of reply it is, or until the stream ends.
+type streamSplitter struct {
+	ThinkSplitter
+	decided bool
+	held    string
+}
+
+// write returns the reasoning and the content in text. separate reports
+// that the server sends reasoning in its own field.
+func (s *streamSplitter) write(text string, separate bool) (reasoning, content string) {
+	if !s.decided {
+		s.held += text
+		opening, closing := strings.Index(s.held, thinkOpen), strings.Index(s.held, thinkClose)
+		switch {
+		case closing >= 0 && (opening < 0 || opening > closing):
+			s.inside = true
+		case opening < 0 && !separate:
+			return "", ""
+		}
+		s.decided = true
+		text, s.held = s.held, ""
+	}
+	return s.Write(text)
+}
+
+// flush returns the text held back at the end of the stream
+func (s *streamSplitter) flush() (reasoning, content string) {
+	reasoning, content = s.write("", true)
+	moreReasoning, moreContent := s.Flush()
+	return reasoning + moreReasoning, content + moreContent
+}
+
+// streamThinking splits inline reasoning out of streamed content, with a
+// splitter for each choice
+type streamThinking map[int]*streamSplitter
+
+// split moves the reasoning in the chunk's content to its reasoning
+func (t streamThinking) split(chunk *ChatCompletionStreamResponse) {
+	for i := range chunk.Choices {
+		choice := &chunk.Choices[i]
+		splitter := t[choice.Index]
+		if splitter == nil {
+			splitter = &streamSplitter{}
+			t[choice.Index] = splitter
+		}
+		reasoning, content := splitter.write(choice.Delta.Content, choice.Delta.Reasoning != "")
+		choice.Delta.Reasoning += reasoning
+		choice.Delta.Content = content
+	}
+}
+
+// flush returns a chunk with the text held back at the end of the stream,
+// or nil when there is none
+func (t streamThinking) flush() *ChatCompletionStreamResponse {
+	var chunk ChatCompletionStreamResponse
+	for index, splitter := range t {
+		reasoning, content := splitter.flush()
+		if reasoning != "" || content != "" {
+			chunk.Choices = append(chunk.Choices, StreamingChoice{
+				Index: index,
+				Delta: StreamingDelta{Reasoning: reasoning, Content: content},
+			})
+		}
+	}
+	if len(chunk.Choices) == 0 {
+		return nil
+	}
+	return &chunk
+}
@@ -0,0 +1,176 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestThinkSplitter(t *testing.T) {
+	var s ThinkSplitter
+	var reasoning, content strings.Builder
+	for _, chunk := range []string{"<thi", "nk>Check the ", "file.</th", "ink>\n\n", "It is ", "empty. <b>"} {
+		r, c := s.Write(chunk)
+		reasoning.WriteString(r)
+		content.WriteString(c)
+	}
+	r, c := s.Flush()
+	reasoning.WriteString(r)
+	content.WriteString(c)
+	if reasoning.String() != "Check the file." || content.String() != "It is empty. <b>" {
+		t.Errorf("Expected the reasoning split out, got %q and %q", reasoning.String(), content.String())
+	}
+}
+
+func TestStreamThinking(t *testing.T) {
+	tests := []struct {
+		name      string
+		deltas    []string
+		reasoning string
+		content   string
+		live      bool // Content must stream rather than wait for the end
+	}{
+		{"tagged", []string{`{"content": "<think>Plan."}`, `{"content": "</think>\n\nAnswer."}`}, "Plan.", "Answer.", false},
+		{"opened by the template", []string{`{"content": "Let me"}`, `{"content": " think.</th"}`, `{"content": "ink>\n\nAnswer."}`}, "Let me think.", "Answer.", false},
+		{"no reasoning", []string{`{"content": "Hello"}`, `{"content": ", 1 < 2."}`}, "", "Hello, 1 < 2.", false},
+		{"separate reasoning", []string{`{"reasoning_content": "Plan."}`, `{"content": "Answer."}`}, "Plan.", "Answer.", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body strings.Builder
+			for _, delta := range tt.deltas {
+				fmt.Fprintf(&body, "data: {\"choices\": [{\"index\": 0, \"delta\": %s}]}\n\n", delta)
+			}
+			body.WriteString("data: [DONE]\n\n")
+
+			var reasoning, content strings.Builder
+			var chunks []string
+			err := NewClient("http://localhost", "").processStreamingResponse(context.Background(), strings.NewReader(body.String()),
+				func(chunk ChatCompletionStreamResponse) error {
+					for _, choice := range chunk.Choices {
+						reasoning.WriteString(choice.Delta.Reasoning)
+						content.WriteString(choice.Delta.Content)
+						chunks = append(chunks, choice.Delta.Content)
+					}
+					return nil
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reasoning.String() != tt.reasoning || content.String() != tt.content {
+				t.Errorf("Expected %q and %q, got %q and %q", tt.reasoning, tt.content, reasoning.String(), content.String())
+			}
+			if tt.live && chunks[1] != tt.content {
+				t.Errorf("Expected content to stream once reasoning arrives separately, got chunks %q", chunks)
+			}
+		})
+	}
+}
+
+func TestSplitThinking(t *testing.T) {
+	tests := []struct {
+		content, reasoning, want string
+	}{
+		{"<think>\nPlan.\n</think>\n\nAnswer.", "Plan.", "Answer."},
+		{"Plan first.</think>Answer.", "Plan first.", "Answer."},
+		{"No reasoning, 1 < 2.", "", "No reasoning, 1 < 2."},
+	}
+	for _, tt := range tests {
+		msg := Message{Role: "assistant", Content: tt.content}
+		SplitThinking(&msg)
+		if msg.Reasoning != tt.reasoning || msg.Content != tt.want {
+			t.Errorf("%q: expected %q and %q, got %q and %q", tt.content, tt.reasoning, tt.want, msg.Reasoning, msg.Content)
+		}
+	}
+}
+
+func TestReasoningFields(t *testing.T) {
+	var delta StreamingDelta
+	if err := json.Unmarshal([]byte(`{"content": "a", "reasoning_content": "because"}`), &delta); err != nil {
+		t.Fatal(err)
+	}
+	if delta.Reasoning != "because" || delta.Content != "a" {
+		t.Errorf("Expected reasoning_content as reasoning, got %+v", delta)
+	}
+
+	var msg Message
+	if err := json.Unmarshal([]byte(`{"role": "assistant", "content": "a", "reasoning": "why"}`), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Reasoning != "why" || msg.Role != "assistant" {
+		t.Errorf("Expected the reasoning field to be read, got %+v", msg)
+	}
+
+	history := []Message{{Role: "assistant", Content: "a", Reasoning: "why"}}
+	data, _ := json.Marshal(requestMessages(history))
+	if strings.Contains(string(data), "why") || history[0].Reasoning != "why" {
+		t.Errorf("Expected reasoning to be left out of requests only, got %s", data)
+	}
+}
+
+func TestApplyThinking(t *testing.T) {
+	tests := []struct {
+		style, level, want string
+	}{
+		{"", "med", `"max_tokens":2048,"stream":true}`},
+		{ReasoningStyleEffort, "med", `"reasoning_effort":"medium"`},
+		{ReasoningStyleEffort, "off", `"stream":true}`},
+		{ReasoningStyleBudget, "low", `"max_tokens":3072,"stream":true,"thinking":{"type":"enabled","budget_tokens":1024}`},
+		{ReasoningStyleBudget, "high", `"max_tokens":18432,"stream":true,"thinking":{"type":"enabled","budget_tokens":16384}`},
+		{ReasoningStyleBudget, "off", `"thinking":{"type":"disabled"}`},
+		{ReasoningStyleOpenRouter, "low", `"reasoning":{"effort":"low"}`},
+		{ReasoningStyleNone, "high", `"stream":true}`},
+	}
+	for _, tt := range tests {
+		client := NewClient("http://localhost:1234/v1", "")
+		client.ReasoningStyle = tt.style
+		req := ChatCompletionRequest{Model: "m", MaxTokens: 2048, Stream: true}
+		client.applyThinking(&req, tt.level)
+		data, _ := json.Marshal(req)
+		if !strings.Contains(string(data), tt.want) {
+			t.Errorf("%s/%s: expected %s in %s", tt.style, tt.level, tt.want, data)
+		}
+	}
+
+	if GetThinkingBudget("high") != 16384 || GetThinkingBudget("off") != 0 || GetThinkingBudget("extreme") != 4096 {
+		t.Errorf("Expected the budgets sent in the budget style, got %d, %d and %d",
+			GetThinkingBudget("high"), GetThinkingBudget("off"), GetThinkingBudget("extreme"))
+	}
+}
+
+func TestCreateChatCompletionThinking(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		fmt.Fprint(w, `{"choices": [{"message": {"role": "assistant", "content": "<think>Easy.</think>4", "reasoning_content": "Sum."}}]}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "")
+	req := ChatCompletionRequest{Model: "m", Messages: []Message{{Role: "user", Content: "2+2?"}}}
+	resp, err := client.CreateChatCompletion(context.Background(), req, "high")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := body["reasoning_effort"]; ok {
+		t.Errorf("Expected no reasoning parameters without a style, got %v", body)
+	}
+	if msg := resp.Choices[0].Message; msg.Content != "4" || msg.Reasoning != "Sum.\nEasy." {
+		t.Errorf("Expected the reasoning split from the content, got %+v", msg)
+	}
+
+	client.ReasoningStyle = ReasoningStyleEffort
+	if _, err := client.CreateChatCompletion(context.Background(), req, "high"); err != nil {
+		t.Fatal(err)
+	}
+	if body["reasoning_effort"] != "high" {
+		t.Errorf("Expected reasoning_effort high, got %v", body)
+	}
+}
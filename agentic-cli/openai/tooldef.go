@@ -0,0 +1,206 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// NewTool builds a function tool from a handler that takes a typed
+// arguments struct. The parameter schema is derived from the fields of A:
+//
+//   - the json tag names the property; fields tagged omitempty and pointer
+//     fields are optional, all others are required
+//   - the description tag documents the property
+//   - the schema tag adds comma-separated constraints: minimum=1,
+//     maximum=20, minLength=1, maxLength=100, minItems=1, maxItems=10 and
+//     enum=a|b|c
+//
+// The arguments are decoded into A before the handler runs. A string result
+// is returned as is, a fmt.Stringer is formatted and any other result is
+// encoded as indented JSON.
+func NewTool[A any, R any](name, description string, handler func(ctx context.Context, args A) (R, error)) Tool {
+	return Tool{
+		Type: "function",
+		Function: ToolFunction{
+			Name:        name,
+			Description: description,
+			Parameters:  SchemaFor[A](),
+			Handler: func(ctx context.Context, raw map[string]interface{}) (string, error) {
+				var args A
+				if err := DecodeArguments(raw, &args); err != nil {
+					return "", fmt.Errorf("invalid arguments for %s: %w", name, err)
+				}
+				result, err := handler(ctx, args)
+				if err != nil {
+					return "", err
+				}
+				return formatResult(result)
+			},
+		},
+	}
+}
+
+// AsReadOnly marks the tool as never modifying files or other state
+func (t Tool) AsReadOnly() Tool {
+	t.Function.ReadOnly = true
+	return t
+}
+
+// DecodeArguments converts decoded JSON arguments into the struct pointed to
+// by v, using the same json tags as the schema
+func DecodeArguments(raw map[string]interface{}, v interface{}) error {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return fmt.Errorf("%s: expected %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return err
+	}
+	return nil
+}
+
+func formatResult(result interface{}) (string, error) {
+	switch r := result.(type) {
+	case string:
+		return r, nil
+	case fmt.Stringer:
+		return r.String(), nil
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode tool result: %w", err)
+	}
+	return string(data), nil
+}
+
+// SchemaFor returns the JSON Schema of the arguments struct A, as described
+// for NewTool. It panics if A contains a type that has no JSON Schema
+// equivalent, since tool definitions are fixed at compile time.
+func SchemaFor[A any]() map[string]interface{} {
+	t := reflect.TypeOf((*A)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("tool arguments must be a struct, got %s", t))
+	}
+	return typeSchema(t)
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			panic(fmt.Sprintf("tool argument maps must have string keys, got %s", t))
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Interface:
+		return map[string]interface{}{}
+	}
+	panic(fmt.Sprintf("unsupported tool argument type %s", t))
+}
+
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			// Embedded structs contribute their fields, as in encoding/json
+			embedded := structSchema(field.Type)
+			for name, prop := range embedded["properties"].(map[string]interface{}) {
+				properties[name] = prop
+			}
+			required = append(required, embedded["required"].([]string)...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name, optional := field.Name, field.Type.Kind() == reflect.Pointer
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			parts := strings.Split(tag, ",")
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				if opt == "omitempty" {
+					optional = true
+				}
+			}
+		}
+
+		prop := typeSchema(field.Type)
+		if description := field.Tag.Get("description"); description != "" {
+			prop["description"] = description
+		}
+		if constraints := field.Tag.Get("schema"); constraints != "" {
+			addConstraints(prop, constraints, field)
+		}
+		properties[name] = prop
+		if !optional {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// addConstraints parses a schema tag such as "minimum=1,maximum=20"
+func addConstraints(prop map[string]interface{}, tag string, field reflect.StructField) {
+	for _, constraint := range strings.Split(tag, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(constraint), "=")
+		if !ok {
+			panic(fmt.Sprintf("invalid schema tag %q on field %s", tag, field.Name))
+		}
+		switch key {
+		case "minimum", "maximum":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				panic(fmt.Sprintf("invalid %s %q on field %s", key, value, field.Name))
+			}
+			prop[key] = n
+		case "minLength", "maxLength", "minItems", "maxItems":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				panic(fmt.Sprintf("invalid %s %q on field %s", key, value, field.Name))
+			}
+			prop[key] = n
+		case "enum":
+			prop[key] = strings.Split(value, "|")
+		default:
+			panic(fmt.Sprintf("unsupported schema constraint %q on field %s", key, field.Name))
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type paging struct {
+	Limit int `json:"limit,omitempty" description:"Page size" schema:"minimum=1,maximum=50"`
+}
+
+type lookupArgs struct {
+	Query  string            `json:"query" description:"What to look up" schema:"minLength=1"`
+	Mode   string            `json:"mode,omitempty" schema:"enum=fast|exact"`
+	Tags   []string          `json:"tags,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Ratio  *float64          `json:"ratio"`
+	paging
+	internal string
+}
+
+type lookupResult struct {
+	Query string `json:"query"`
+	Hits  int    `json:"hits"`
+}
+
+func TestNewTool(t *testing.T) {
+	tool := NewTool("lookup", "Look something up", func(_ context.Context, args lookupArgs) (lookupResult, error) {
+		return lookupResult{Query: args.Query, Hits: args.Limit + len(args.Tags)}, nil
+	}).AsReadOnly()
+
+	schema, _ := json.Marshal(tool.Function.Parameters)
+	want := `{"properties":{` +
+		`"labels":{"additionalProperties":{"type":"string"},"type":"object"},` +
+		`"limit":{"description":"Page size","maximum":50,"minimum":1,"type":"integer"},` +
+		`"mode":{"enum":["fast","exact"],"type":"string"},` +
+		`"query":{"description":"What to look up","minLength":1,"type":"string"},` +
+		`"ratio":{"type":"number"},` +
+		`"tags":{"items":{"type":"string"},"type":"array"}},` +
+		`"required":["query"],"type":"object"}`
+	if string(schema) != want {
+		t.Errorf("Unexpected schema\n got %s\nwant %s", schema, want)
+	}
+	if !tool.Function.ReadOnly || tool.Function.Name != "lookup" {
+		t.Errorf("Unexpected tool %+v", tool.Function)
+	}
+
+	result, err := tool.Function.Handler(context.Background(), map[string]interface{}{
+		"query": "go", "limit": float64(3), "tags": []interface{}{"a", "b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "{\n  \"query\": \"go\",\n  \"hits\": 5\n}" {
+		t.Errorf("Unexpected result %q", result)
+	}
+
+	_, err = tool.Function.Handler(context.Background(), map[string]interface{}{"query": "go", "limit": "many"})
+	if err == nil || !strings.Contains(err.Error(), "invalid arguments for lookup: limit: expected int, got string") {
+		t.Errorf("Expected a decoding error, got %v", err)
+	}
+}
+
+func TestNewToolStringResult(t *testing.T) {
+	tool := NewTool("echo", "", func(_ context.Context, args struct {
+		Text string `json:"text"`
+	}) (string, error) {
+		return args.Text, nil
+	})
+	if result, _ := tool.Function.Handler(context.Background(), map[string]interface{}{"text": "hi"}); result != "hi" {
+		t.Errorf("Expected strings to be returned as is, got %q", result)
+	}
+	if required := tool.Function.Parameters["required"]; !reflect.DeepEqual(required, []string{"text"}) {
+		t.Errorf("Unexpected required properties %v", required)
+	}
+}